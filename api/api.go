@@ -0,0 +1,372 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.fmi/issuetracker/command"
+	"go.fmi/issuetracker/comment"
+	"go.fmi/issuetracker/db"
+	"go.fmi/issuetracker/issue"
+	"go.fmi/issuetracker/project"
+	"go.fmi/issuetracker/token"
+	"go.fmi/issuetracker/user"
+)
+
+// failureStatus maps the kinds of failures of the commands to HTTP status codes
+var failureStatus = map[error]int{
+	command.ErrInvalid:         http.StatusBadRequest,
+	command.ErrNotFound:        http.StatusNotFound,
+	command.ErrUnauthenticated: http.StatusUnauthorized,
+	command.ErrForbidden:       http.StatusForbidden,
+	command.ErrExists:          http.StatusConflict,
+	command.ErrConflict:        http.StatusConflict,
+	command.ErrTooManyRequests: http.StatusTooManyRequests,
+	command.ErrUnavailable:     http.StatusServiceUnavailable,
+	command.ErrInternal:        http.StatusInternalServerError,
+}
+
+// credentials is the body of the requests for registering and logging in
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Handler serves the REST API under /api/
+//
+//	POST   /api/users                                  register, responds with a token
+//	GET    /api/users
+//	GET    /api/users/{username}
+//	POST   /api/login                                  responds with a token
+//	POST   /api/logout                                 revokes the token of the request
+//	GET    /api/projects
+//	POST   /api/projects                               {"name"}
+//	GET    /api/projects/{project}/issues
+//	POST   /api/projects/{project}/issues              {"title", "description"}
+//	GET    /api/projects/{project}/issues/{title}      the issue with its comments
+//	POST   /api/projects/{project}/issues/{title}/resolve
+//	GET    /api/projects/{project}/issues/{title}/comments
+//	POST   /api/projects/{project}/issues/{title}/comments  {"content", "parent"}
+//	PATCH  /api/comments/{id}                          {"content"}
+//	DELETE /api/comments/{id}
+//
+// All requests except for registering and logging in need an 'Authorization: Bearer <token>' header
+func Handler() http.Handler {
+	return http.HandlerFunc(serveAPI)
+}
+
+func serveAPI(w http.ResponseWriter, r *http.Request) {
+	path, ok := SplitPath(r.URL.EscapedPath())
+	if !ok || len(path) < 2 || path[0] != "api" {
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	path = path[1:]
+
+	session := &command.Session{RemoteAddr: r.RemoteAddr}
+	switch {
+	case Match(r, path, http.MethodPost, "users"):
+		register(w, r, session)
+		return
+	case Match(r, path, http.MethodPost, "login"):
+		login(w, r, session)
+		return
+	}
+
+	secret, ok := authenticate(r, session)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "A valid token is required")
+		return
+	}
+
+	switch {
+	case Match(r, path, http.MethodPost, "logout"):
+		// Personal access tokens are revoked only explicitly, so that a script logging out doesn't break the others
+		if len(session.Scopes) == 0 {
+			db.DeleteToken(token.Hash(secret))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case Match(r, path, http.MethodGet, "users"):
+		writeData(w, command.UsersCommand{}, session)
+	case Match(r, path, http.MethodGet, "users", ""):
+		writeData(w, command.ShowUserCommand{Username: path[1]}, session)
+	case Match(r, path, http.MethodGet, "projects"):
+		writeJSON(w, http.StatusOK, nonNil(db.ListProjects()))
+	case Match(r, path, http.MethodPost, "projects"):
+		createProject(w, r, session)
+	case Match(r, path, http.MethodGet, "projects", "", "issues"):
+		listIssues(w, path[1])
+	case Match(r, path, http.MethodPost, "projects", "", "issues"):
+		createIssue(w, r, session, path[1])
+	case Match(r, path, http.MethodGet, "projects", "", "issues", ""):
+		findIssue(w, path[1], path[3])
+	case Match(r, path, http.MethodPost, "projects", "", "issues", "", "resolve"):
+		execute(w, command.ResolveCommand{Project: path[1], Title: path[3]}, session, http.StatusOK)
+	case Match(r, path, http.MethodGet, "projects", "", "issues", "", "comments"):
+		listComments(w, path[1], path[3])
+	case Match(r, path, http.MethodPost, "projects", "", "issues", "", "comments"):
+		createComment(w, r, session, path[1], path[3])
+	case Match(r, path, http.MethodPatch, "comments", ""):
+		editComment(w, r, session, path[1])
+	case Match(r, path, http.MethodDelete, "comments", ""):
+		id, _ := strconv.Atoi(path[1])
+		execute(w, command.DeleteCommentCommand{ID: id}, session, http.StatusOK)
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+// SplitPath splits an escaped URL path into unescaped segments, so that names may contain slashes
+func SplitPath(escapedPath string) ([]string, bool) {
+	segments := strings.Split(strings.Trim(escapedPath, "/"), "/")
+	for i, segment := range segments {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return nil, false
+		}
+		segments[i] = unescaped
+	}
+
+	return segments, true
+}
+
+// Match checks the method and the path of a request against a pattern, where empty segments match anything
+func Match(r *http.Request, path []string, method string, pattern ...string) bool {
+	if r.Method != method || len(path) != len(pattern) {
+		return false
+	}
+
+	for i, segment := range pattern {
+		if segment != "" && segment != path[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// authenticate logs the user owning the bearer token of a request in the session and returns the token secret
+func authenticate(r *http.Request, session *command.Session) (string, bool) {
+	secret := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if secret == "" {
+		return "", false
+	}
+
+	existingToken, err := db.FindToken(token.Hash(secret))
+	if err != nil || existingToken.Expired() {
+		return "", false
+	}
+
+	session.Authenticate(existingToken)
+	return secret, true
+}
+
+// execute runs a command on behalf of the session and writes its outcome
+func execute(w http.ResponseWriter, c command.Command, session *command.Session, successStatus int) {
+	message, err := command.ExecuteInSession(c, session)
+	if err != nil {
+		writeFailure(w, message, err)
+		return
+	}
+
+	writeJSON(w, successStatus, map[string]string{"message": strings.TrimSpace(message)})
+}
+
+// decode reads the JSON body of a request, writing an error response if it is malformed
+func decode(w http.ResponseWriter, r *http.Request, body interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body")
+		return false
+	}
+
+	return true
+}
+
+func register(w http.ResponseWriter, r *http.Request, session *command.Session) {
+	var body credentials
+	if !decode(w, r, &body) {
+		return
+	}
+
+	registerCommand := command.RegisterCommand{User: user.User{Username: body.Username, Password: body.Password}}
+	if message, err := registerCommand.ExecuteAs(session); err != nil {
+		writeFailure(w, message, err)
+		return
+	}
+
+	issueToken(w, session.Username, http.StatusCreated)
+}
+
+func login(w http.ResponseWriter, r *http.Request, session *command.Session) {
+	var body credentials
+	if !decode(w, r, &body) {
+		return
+	}
+
+	loginCommand := command.LoginCommand{User: user.User{Username: body.Username, Password: body.Password}}
+	if message, err := loginCommand.ExecuteAs(session); err != nil {
+		writeFailure(w, message, err)
+		return
+	}
+
+	issueToken(w, session.Username, http.StatusOK)
+}
+
+// issueToken creates a session token for a user who has just logged in and writes it
+func issueToken(w http.ResponseWriter, username string, status int) {
+	newToken, secret, err := token.New(username, token.SessionLifetime)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Could not issue a token")
+		return
+	}
+
+	db.InsertToken(newToken)
+	writeJSON(w, status, map[string]interface{}{
+		"username": username,
+		"token":    secret,
+		"expires":  newToken.Expires})
+}
+
+// writeData writes the result of a data command as the user logged in the session may see it
+func writeData(w http.ResponseWriter, c command.SessionDataCommand, session *command.Session) {
+	data, message, err := c.DataAs(session)
+	if err != nil {
+		writeFailure(w, message, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, data)
+}
+
+func createProject(w http.ResponseWriter, r *http.Request, session *command.Session) {
+	var body project.Project
+	if !decode(w, r, &body) {
+		return
+	}
+
+	execute(w, command.ProjectCommand{Project: project.Project{Name: body.Name}}, session, http.StatusCreated)
+}
+
+func listIssues(w http.ResponseWriter, projectName string) {
+	issues, message, err := command.ListCommand{Project: projectName}.Issues()
+	if err != nil {
+		writeFailure(w, message, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, nonNil(issues))
+}
+
+func createIssue(w http.ResponseWriter, r *http.Request, session *command.Session, projectName string) {
+	var body issue.Issue
+	if !decode(w, r, &body) {
+		return
+	}
+
+	execute(w, command.IssueCommand{Issue: issue.Issue{
+		Project:     projectName,
+		Title:       body.Title,
+		Description: body.Description}}, session, http.StatusCreated)
+}
+
+func findIssue(w http.ResponseWriter, projectName string, title string) {
+	foundIssue, comments, message, err := command.FindCommand{Project: projectName, Title: title}.Details()
+	if err != nil {
+		writeFailure(w, message, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issue":    foundIssue,
+		"comments": threaded(comments)})
+}
+
+func listComments(w http.ResponseWriter, projectName string, title string) {
+	_, comments, message, err := command.FindCommand{Project: projectName, Title: title}.Details()
+	if err != nil {
+		writeFailure(w, message, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, threaded(comments))
+}
+
+// threaded orders comments as a thread - every comment is followed by its replies
+func threaded(comments []comment.Comment) []comment.Comment {
+	ordered := []comment.Comment{}
+	for _, entry := range comment.Thread(comments) {
+		ordered = append(ordered, entry.Comment)
+	}
+
+	return ordered
+}
+
+func createComment(w http.ResponseWriter, r *http.Request, session *command.Session, projectName string, title string) {
+	var body comment.Comment
+	if !decode(w, r, &body) {
+		return
+	}
+
+	execute(w, command.CommentCommand{Comment: comment.Comment{
+		Parent:    body.Parent,
+		Project:   projectName,
+		Title:     title,
+		Content:   body.Content,
+		Commenter: session.Username}}, session, http.StatusCreated)
+}
+
+func editComment(w http.ResponseWriter, r *http.Request, session *command.Session, rawID string) {
+	var body comment.Comment
+	if !decode(w, r, &body) {
+		return
+	}
+
+	id, _ := strconv.Atoi(rawID)
+	execute(w, command.EditCommentCommand{ID: id, Content: body.Content}, session, http.StatusOK)
+}
+
+// nonNil makes sure that empty lists are written as [] rather than null
+func nonNil(list interface{}) interface{} {
+	switch typed := list.(type) {
+	case []project.Project:
+		if typed == nil {
+			return []project.Project{}
+		}
+	case []issue.Issue:
+		if typed == nil {
+			return []issue.Issue{}
+		}
+	case []user.User:
+		if typed == nil {
+			return []user.User{}
+		}
+	}
+
+	return list
+}
+
+// FailureStatus returns the HTTP status code matching the kind of a failure of a command
+func FailureStatus(err error) int {
+	if status, ok := failureStatus[err]; ok {
+		return status
+	}
+
+	return http.StatusBadRequest
+}
+
+func writeFailure(w http.ResponseWriter, message string, err error) {
+	writeError(w, FailureStatus(err), message)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": strings.TrimSpace(message)})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.fmi/issuetracker/audit"
+	"go.fmi/issuetracker/command"
+	"go.fmi/issuetracker/comment"
+	"go.fmi/issuetracker/db"
+	"go.fmi/issuetracker/issue"
+	"go.fmi/issuetracker/project"
+	"go.fmi/issuetracker/token"
+	"go.fmi/issuetracker/user"
+
+	"bou.ke/monkey"
+)
+
+// patchToken makes every token valid for the given user
+func patchToken(username string) {
+	monkey.Patch(db.FindToken, func(hash string) (token.Token, error) {
+		return token.Token{Hash: hash, Username: username, Expires: time.Now().Add(time.Hour)}, nil
+	})
+}
+
+func request(method string, target string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer secret")
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, r)
+	return w
+}
+
+func TestRequestWithoutToken(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindToken, func(string) (token.Token, error) {
+		return token.Token{}, errors.New("Not found")
+	})
+
+	w := request(http.MethodGet, "/api/projects", "")
+	if w.Code != http.StatusUnauthorized {
+		t.Error("Invalid status. Expected: 401, but got ", w.Code)
+	}
+}
+
+func TestRequestWithExpiredToken(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindToken, func(hash string) (token.Token, error) {
+		return token.Token{Hash: hash, Username: "user", Expires: time.Now().Add(-time.Hour)}, nil
+	})
+
+	w := request(http.MethodGet, "/api/projects", "")
+	if w.Code != http.StatusUnauthorized {
+		t.Error("Invalid status. Expected: 401, but got ", w.Code)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		return user.User{Username: username, Password: user.HashAndSalt("password")}, nil
+	})
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		return
+	})
+
+	var inserted token.Token
+	monkey.Patch(db.InsertToken, func(newToken token.Token) {
+		inserted = newToken
+	})
+
+	w := request(http.MethodPost, "/api/login", `{"username": "user", "password": "password"}`)
+	if w.Code != http.StatusOK {
+		t.Fatal("Invalid status. Expected: 200, but got ", w.Code)
+	}
+
+	var body map[string]string
+	json.NewDecoder(w.Body).Decode(&body)
+	if body["token"] == "" || token.Hash(body["token"]) != inserted.Hash || inserted.Username != "user" {
+		t.Error("The issued token is not the stored one: ", body)
+	}
+}
+
+func TestLoginWithWrongPassword(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		return user.User{Username: username, Password: user.HashAndSalt("password")}, nil
+	})
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		return
+	})
+
+	w := request(http.MethodPost, "/api/login", `{"username": "user", "password": "wrong"}`)
+	if w.Code != http.StatusUnauthorized {
+		t.Error("Invalid status. Expected: 401, but got ", w.Code)
+	}
+}
+
+func TestListProjects(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	patchToken("user")
+	monkey.Patch(db.ListProjects, func() []project.Project {
+		return []project.Project{{Name: "project", Owner: "user"}}
+	})
+
+	w := request(http.MethodGet, "/api/projects", "")
+	expected := `[{"name":"project","owner":"user"}]`
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != expected {
+		t.Error("Invalid response. Expected: "+expected+", but got ", w.Code, w.Body.String())
+	}
+}
+
+func TestFindUserHidesEmail(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	patchToken("maria")
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		return user.User{Username: username, Email: username + "@example.com"}, nil
+	})
+
+	w := request(http.MethodGet, "/api/users/ivan", "")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "ivan@example.com") {
+		t.Error("The email of another user should be hidden, but got ", w.Code, w.Body.String())
+	}
+
+	w = request(http.MethodGet, "/api/users/maria", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "maria@example.com") {
+		t.Error("The own email should be shown, but got ", w.Code, w.Body.String())
+	}
+}
+
+func TestListIssuesOfMissingProject(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	patchToken("user")
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{}, errors.New("Not found")
+	})
+
+	w := request(http.MethodGet, "/api/projects/missing/issues", "")
+	if w.Code != http.StatusNotFound {
+		t.Error("Invalid status. Expected: 404, but got ", w.Code)
+	}
+
+	var body map[string]string
+	json.NewDecoder(w.Body).Decode(&body)
+	if body["error"] != strings.TrimSpace(command.ProjectNotFound) {
+		t.Error("Invalid error. Expected: "+command.ProjectNotFound+", but got ", body["error"])
+	}
+}
+
+func TestFindIssueWithEscapedTitle(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	patchToken("user")
+	monkey.Patch(db.FindExistingProject, func(name string) (project.Project, error) {
+		return project.Project{Name: name}, nil
+	})
+	monkey.Patch(db.FindExistingIssue, func(projectName string, title string) (issue.Issue, error) {
+		if title != "a/b c" {
+			return issue.Issue{}, errors.New("Not found")
+		}
+		return issue.Issue{Project: projectName, Title: title, Status: issue.StatusOpen}, nil
+	})
+	monkey.Patch(db.FindComments, func(string, string) []comment.Comment {
+		return []comment.Comment{
+			{ID: 2, Parent: 1, Content: "reply"},
+			{ID: 1, Content: "root"},
+			{ID: 3, Content: "other"}}
+	})
+
+	w := request(http.MethodGet, "/api/projects/project/issues/a%2Fb%20c", "")
+	if w.Code != http.StatusOK {
+		t.Fatal("Invalid status. Expected: 200, but got ", w.Code)
+	}
+
+	var body struct {
+		Issue    issue.Issue
+		Comments []comment.Comment
+	}
+	json.NewDecoder(w.Body).Decode(&body)
+	if body.Issue.Title != "a/b c" || len(body.Comments) != 3 ||
+		body.Comments[0].ID != 1 || body.Comments[1].ID != 2 || body.Comments[2].ID != 3 {
+		t.Error("Invalid issue details: ", body)
+	}
+}
+
+func TestCreateProjectWithTakenName(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	patchToken("user")
+	monkey.Patch(db.FindExistingProject, func(name string) (project.Project, error) {
+		return project.Project{Name: name}, nil
+	})
+
+	w := request(http.MethodPost, "/api/projects", `{"name": "project"}`)
+	if w.Code != http.StatusConflict {
+		t.Error("Invalid status. Expected: 409, but got ", w.Code)
+	}
+}
+
+func TestCreateProjectWithInvalidBody(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	patchToken("user")
+
+	w := request(http.MethodPost, "/api/projects", `{"name": `)
+	if w.Code != http.StatusBadRequest {
+		t.Error("Invalid status. Expected: 400, but got ", w.Code)
+	}
+}
+
+func TestUnknownRoute(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	patchToken("user")
+
+	w := request(http.MethodPut, "/api/projects", "")
+	if w.Code != http.StatusNotFound {
+		t.Error("Invalid status. Expected: 404, but got ", w.Code)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	patchToken("user")
+
+	deleted := ""
+	monkey.Patch(db.DeleteToken, func(hash string) {
+		deleted = hash
+	})
+
+	w := request(http.MethodPost, "/api/logout", "")
+	if w.Code != http.StatusNoContent || deleted != token.Hash("secret") {
+		t.Error("The token of the request is not revoked: ", w.Code, deleted)
+	}
+}
+
+func TestAccessTokenScopes(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindToken, func(hash string) (token.Token, error) {
+		return token.Token{Hash: hash, Username: "ci", Expires: time.Now().Add(time.Hour), ID: 1, Scopes: []token.Scope{token.ScopeRead}}, nil
+	})
+	monkey.Patch(db.ListProjects, func() []project.Project {
+		return nil
+	})
+	monkey.Patch(db.InsertNewProject, func(project.Project) error {
+		t.Errorf("A project was created with a read-only token")
+		return nil
+	})
+
+	if w := request(http.MethodGet, "/api/projects", ""); w.Code != http.StatusOK {
+		t.Error("Invalid status. Expected: 200, but got ", w.Code)
+	}
+
+	if w := request(http.MethodPost, "/api/projects", `{"name": "project"}`); w.Code != http.StatusForbidden {
+		t.Error("Invalid status. Expected: 403, but got ", w.Code)
+	}
+}
@@ -0,0 +1,64 @@
+package audit
+
+import "time"
+
+// Actions which are recorded in the audit log
+const (
+	Login                = "login"
+	LoginFailed          = "login-failed"
+	Register             = "register"
+	ProjectCreate        = "project-create"
+	PermissionChange     = "permission-change"
+	PasswordChange       = "password-change"
+	PasswordReset        = "password-reset"
+	Lockout              = "lockout"
+	Unlock               = "unlock"
+	Deactivate           = "deactivate"
+	Activate             = "activate"
+	ProjectDelete        = "project-delete"
+	ClientDisconnect     = "client-disconnect"
+	TokenCreate          = "token-create"
+	TokenRevoke          = "token-revoke"
+	ServiceAccountCreate = "service-account-create"
+	Provision            = "provision"
+)
+
+// Entry is a record of a security-relevant event on the server
+type Entry struct {
+	Time       time.Time
+	Actor      string
+	Action     string
+	Target     string
+	RemoteAddr string
+}
+
+// Filter narrows down the entries returned from the audit log. Zero values match everything
+type Filter struct {
+	Actor  string
+	Action string
+	From   time.Time
+	To     time.Time
+}
+
+// NewEntry creates an entry for an action which happened just now
+func NewEntry(actor string, action string, target string, remoteAddr string) Entry {
+	return Entry{
+		Time:       time.Now().UTC(),
+		Actor:      actor,
+		Action:     action,
+		Target:     target,
+		RemoteAddr: remoteAddr}
+}
+
+// String formats an entry as a single human-readable line
+func (e Entry) String() string {
+	line := e.Time.Format("2006-01-02 15:04:05") + " " + e.Action + " by " + e.Actor
+	if e.Target != "" {
+		line += " on " + e.Target
+	}
+	if e.RemoteAddr != "" {
+		line += " from " + e.RemoteAddr
+	}
+
+	return line
+}
@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"go.fmi/issuetracker/config"
+	"go.fmi/issuetracker/db"
+	"go.fmi/issuetracker/user"
+)
+
+// Names of the supported providers, as listed in the configuration
+const (
+	LocalName = "local"
+	LDAPName  = "ldap"
+)
+
+// ErrInvalidCredentials is returned when a provider doesn't know the user or the password is wrong
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// ErrUnavailable is returned when a provider can't check the password, e.g. when its server is down
+var ErrUnavailable = errors.New("the authentication provider is unavailable")
+
+// Identity is a user whose password was checked by a provider, with the profile which the provider knows of them
+type Identity struct {
+	Username    string
+	DisplayName string
+	Email       string
+	// Provider is stored in the users provisioned on their first login, empty for the local users
+	Provider string
+}
+
+// Provider checks the password of a user
+type Provider interface {
+	Name() string
+	Authenticate(username string, password string) (Identity, error)
+}
+
+// Configured returns the providers listed in the configuration, in the order in which they are tried.
+// Unknown names and an LDAP provider without a URL are skipped
+func Configured() []Provider {
+	var providers []Provider
+	for _, name := range config.AuthProviders() {
+		switch name {
+		case LocalName:
+			providers = append(providers, Local{})
+		case LDAPName:
+			if settings, ok := config.LDAP(); ok {
+				providers = append(providers, LDAP{settings})
+			} else {
+				log.Println("The LDAP authentication provider is skipped, because no URL is set")
+			}
+		default:
+			log.Println("Unknown authentication provider '" + name + "' is skipped")
+		}
+	}
+
+	return providers
+}
+
+// LocalEnabled checks whether users can register with a password stored on the server
+func LocalEnabled() bool {
+	for _, name := range config.AuthProviders() {
+		if name == LocalName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authenticate tries the providers in order and returns the identity from the first one accepting the password.
+// It returns ErrUnavailable when none accepts it and some of them couldn't check it
+func Authenticate(providers []Provider, username string, password string) (Identity, error) {
+	result := ErrInvalidCredentials
+	for _, provider := range providers {
+		identity, err := provider.Authenticate(username, password)
+		if err == nil {
+			return identity, nil
+		}
+
+		if err != ErrInvalidCredentials {
+			log.Println("Authentication with " + provider.Name() + " failed: " + err.Error())
+			result = ErrUnavailable
+		}
+	}
+
+	return Identity{}, result
+}
+
+// Local checks the passwords hashed with bcrypt in the users collection
+type Local struct{}
+
+// Name returns the name of the provider in the configuration
+func (Local) Name() string {
+	return LocalName
+}
+
+// Authenticate compares the password with the one stored for the user. Users provisioned by other providers
+// have no password on the server
+func (Local) Authenticate(username string, password string) (Identity, error) {
+	registeredUser, err := db.FindRegisteredUser(username)
+	isPasswordCorrect := user.ComparePasswords(registeredUser.Password, password)
+	if err != nil || !isPasswordCorrect || registeredUser.Provider != "" {
+		return Identity{}, ErrInvalidCredentials
+	}
+
+	// The password is hashed again when the cost set on the server has changed since it was stored
+	if user.NeedsRehash(registeredUser.Password) {
+		db.UpdatePassword(username, user.HashAndSalt(password))
+	}
+
+	return Identity{
+		Username:    registeredUser.Username,
+		DisplayName: registeredUser.DisplayName,
+		Email:       registeredUser.Email}, nil
+}
+
+// LDAP checks the passwords by binding to an LDAP directory as the entry of the user
+type LDAP struct {
+	Settings config.Directory
+}
+
+// Name returns the name of the provider in the configuration
+func (LDAP) Name() string {
+	return LDAPName
+}
+
+// Authenticate searches the directory for the single entry of the user and binds as it with the password
+func (lp LDAP) Authenticate(username string, password string) (Identity, error) {
+	// An empty password would make an unauthenticated bind, which many directories accept
+	if username == "" || password == "" {
+		return Identity{}, ErrInvalidCredentials
+	}
+
+	conn, err := ldap.DialURL(lp.Settings.URL, ldap.DialWithDialer(&net.Dialer{Timeout: lp.Settings.Timeout}))
+	if err != nil {
+		return Identity{}, err
+	}
+	defer conn.Close()
+	conn.SetTimeout(lp.Settings.Timeout)
+
+	// The binds carry the passwords, so a plain connection is upgraded to TLS before the first of them
+	if strings.HasPrefix(strings.ToLower(lp.Settings.URL), "ldap://") && !lp.Settings.Insecure {
+		address, err := url.Parse(lp.Settings.URL)
+		if err != nil {
+			return Identity{}, err
+		}
+		if err := conn.StartTLS(&tls.Config{ServerName: address.Hostname()}); err != nil {
+			return Identity{}, fmt.Errorf("starting TLS: %w", err)
+		}
+	}
+
+	if lp.Settings.BindDN != "" {
+		if err := conn.Bind(lp.Settings.BindDN, lp.Settings.BindPassword); err != nil {
+			return Identity{}, err
+		}
+	}
+
+	search := ldap.NewSearchRequest(lp.Settings.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		strings.ReplaceAll(lp.Settings.UserFilter, "%s", ldap.EscapeFilter(username)),
+		[]string{lp.Settings.UsernameAttribute, lp.Settings.NameAttribute, lp.Settings.EmailAttribute}, nil)
+	result, err := conn.Search(search)
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return Identity{}, err
+	}
+
+	// A filter matching several entries is ambiguous, so none of them is trusted
+	if result == nil || len(result.Entries) != 1 {
+		return Identity{}, ErrInvalidCredentials
+	}
+
+	entry := result.Entries[0]
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return Identity{}, ErrInvalidCredentials
+		}
+		return Identity{}, fmt.Errorf("binding as %s: %w", entry.DN, err)
+	}
+
+	// The directory matches the typed username regardless of its case, but the user is stored under the one it holds
+	storedUsername := entry.GetAttributeValue(lp.Settings.UsernameAttribute)
+	if storedUsername == "" {
+		return Identity{}, fmt.Errorf("the entry %s has no %s attribute", entry.DN, lp.Settings.UsernameAttribute)
+	}
+
+	return Identity{
+		Username:    storedUsername,
+		DisplayName: entry.GetAttributeValue(lp.Settings.NameAttribute),
+		Email:       entry.GetAttributeValue(lp.Settings.EmailAttribute),
+		Provider:    LDAPName}, nil
+}
@@ -0,0 +1,296 @@
+package auth
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+
+	"go.fmi/issuetracker/config"
+	"go.fmi/issuetracker/db"
+	"go.fmi/issuetracker/user"
+)
+
+// stubEntry is an entry of the stub directory, which users bind as with its password
+type stubEntry struct {
+	dn         string
+	uid        string
+	password   string
+	attributes map[string]string
+}
+
+// stubDirectory is an in-process LDAP server answering simple binds and searches by uid, which like real directories
+// matches the uid regardless of its case
+type stubDirectory struct {
+	listener net.Listener
+	entries  []stubEntry
+	binds    int32
+}
+
+// startDirectory starts a stub directory with the entries on a random local port
+func startDirectory(t *testing.T, entries ...stubEntry) *stubDirectory {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	directory := &stubDirectory{listener: listener, entries: entries}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go directory.serve(conn)
+		}
+	}()
+
+	t.Cleanup(func() { listener.Close() })
+	return directory
+}
+
+// settings returns the settings of a directory searched anonymously under dc=example,dc=org. The stub has no
+// certificate, so its connections stay in cleartext
+func (sd *stubDirectory) settings() config.Directory {
+	return config.Directory{
+		URL:               "ldap://" + sd.listener.Addr().String(),
+		Insecure:          true,
+		BaseDN:            "dc=example,dc=org",
+		UserFilter:        "(uid=%s)",
+		UsernameAttribute: "uid",
+		NameAttribute:     "cn",
+		EmailAttribute:    "mail",
+		Timeout:           time.Second}
+}
+
+// serve answers the requests on a connection until the client unbinds or disconnects
+func (sd *stubDirectory) serve(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		request, err := ber.ReadPacket(conn)
+		if err != nil || len(request.Children) < 2 {
+			return
+		}
+
+		id := request.Children[0].Value.(int64)
+		operation := request.Children[1]
+		switch operation.Tag {
+		case ldap.ApplicationBindRequest:
+			atomic.AddInt32(&sd.binds, 1)
+			code := ldap.LDAPResultInvalidCredentials
+			dn, password := operation.Children[1].Value.(string), operation.Children[2].Data.String()
+			for _, entry := range sd.entries {
+				if entry.dn == dn && entry.password == password {
+					code = ldap.LDAPResultSuccess
+				}
+			}
+			conn.Write(response(id, ldap.ApplicationBindResponse, code).Bytes())
+		case ldap.ApplicationSearchRequest:
+			filter, _ := ldap.DecompileFilter(operation.Children[6])
+			for _, entry := range sd.entries {
+				if strings.EqualFold(filter, "(uid="+ldap.EscapeFilter(entry.uid)+")") {
+					conn.Write(searchEntry(id, entry).Bytes())
+				}
+			}
+			conn.Write(response(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess).Bytes())
+		default:
+			return
+		}
+	}
+}
+
+// envelope wraps an operation in a message with the id of the request
+func envelope(id int64, operation *ber.Packet) *ber.Packet {
+	message := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	message.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "MessageID"))
+	message.AppendChild(operation)
+	return message
+}
+
+// response encodes a result of an operation
+func response(id int64, application ber.Tag, code int) *ber.Packet {
+	operation := ber.Encode(ber.ClassApplication, ber.TypeConstructed, application, nil, "Response")
+	operation.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "Result Code"))
+	operation.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	operation.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	return envelope(id, operation)
+}
+
+// searchEntry encodes an entry found by a search
+func searchEntry(id int64, entry stubEntry) *ber.Packet {
+	operation := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Entry")
+	operation.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, "DN"))
+
+	attributes := ber.NewSequence("Attributes")
+	all := map[string]string{"uid": entry.uid}
+	for name, value := range entry.attributes {
+		all[name] = value
+	}
+	for name, value := range all {
+		attribute := ber.NewSequence("Attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+		values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+		attribute.AppendChild(values)
+		attributes.AppendChild(attribute)
+	}
+	operation.AppendChild(attributes)
+
+	return envelope(id, operation)
+}
+
+var ivan = stubEntry{
+	dn:       "uid=ivan,ou=people,dc=example,dc=org",
+	uid:      "ivan",
+	password: "directory-password",
+	attributes: map[string]string{
+		"cn":   "Ivan Petrov",
+		"mail": "ivan@example.org"}}
+
+func TestLDAPAuthenticate(t *testing.T) {
+	directory := startDirectory(t, ivan)
+
+	identity, err := LDAP{directory.settings()}.Authenticate("ivan", "directory-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Identity{Username: "ivan", DisplayName: "Ivan Petrov", Email: "ivan@example.org", Provider: LDAPName}
+	if identity != expected {
+		t.Errorf("Invalid identity. Expected: %+v, but got %+v", expected, identity)
+	}
+}
+
+func TestLDAPUsernameFromDirectory(t *testing.T) {
+	directory := startDirectory(t, ivan)
+
+	identity, err := LDAP{directory.settings()}.Authenticate("Ivan", "directory-password")
+	if err != nil || identity.Username != "ivan" {
+		t.Errorf("The username should be taken from the entry, not as typed, but got %q and %v", identity.Username, err)
+	}
+}
+
+func TestLDAPRequiresTLS(t *testing.T) {
+	directory := startDirectory(t, ivan)
+
+	settings := directory.settings()
+	settings.Insecure = false
+	if _, err := (LDAP{settings}).Authenticate("ivan", "directory-password"); err == nil || err == ErrInvalidCredentials {
+		t.Errorf("A directory without StartTLS should make the provider unavailable, but got %v", err)
+	}
+
+	if binds := atomic.LoadInt32(&directory.binds); binds != 0 {
+		t.Errorf("The password was sent in cleartext in %d binds", binds)
+	}
+}
+
+func TestLDAPWrongPassword(t *testing.T) {
+	directory := startDirectory(t, ivan)
+
+	if _, err := (LDAP{directory.settings()}).Authenticate("ivan", "wrong"); err != ErrInvalidCredentials {
+		t.Errorf("A wrong password should be rejected, but got %v", err)
+	}
+}
+
+func TestLDAPEmptyPassword(t *testing.T) {
+	directory := startDirectory(t, stubEntry{dn: "uid=anna,ou=people,dc=example,dc=org", uid: "anna"})
+
+	if _, err := (LDAP{directory.settings()}).Authenticate("anna", ""); err != ErrInvalidCredentials {
+		t.Errorf("An empty password should be rejected without an unauthenticated bind, but got %v", err)
+	}
+}
+
+func TestLDAPUnknownUser(t *testing.T) {
+	directory := startDirectory(t, ivan)
+
+	if _, err := (LDAP{directory.settings()}).Authenticate("maria", "directory-password"); err != ErrInvalidCredentials {
+		t.Errorf("A user missing in the directory should be rejected, but got %v", err)
+	}
+}
+
+func TestLDAPEscapesUsername(t *testing.T) {
+	directory := startDirectory(t, ivan, stubEntry{dn: "uid=*,ou=people,dc=example,dc=org", uid: "*", password: "star"})
+
+	if _, err := (LDAP{directory.settings()}).Authenticate("*", "directory-password"); err != ErrInvalidCredentials {
+		t.Errorf("The username should be escaped in the filter, but got %v", err)
+	}
+
+	if identity, err := (LDAP{directory.settings()}).Authenticate("*", "star"); err != nil || identity.Username != "*" {
+		t.Errorf("The escaped username should match its own entry, but got %v", err)
+	}
+}
+
+func TestLDAPServiceBind(t *testing.T) {
+	reader := stubEntry{dn: "cn=reader,dc=example,dc=org", password: "reader-password"}
+	directory := startDirectory(t, ivan, reader)
+
+	settings := directory.settings()
+	settings.BindDN, settings.BindPassword = reader.dn, "wrong"
+	if _, err := (LDAP{settings}).Authenticate("ivan", "directory-password"); err == nil || err == ErrInvalidCredentials {
+		t.Errorf("A failing bind of the service account should make the provider unavailable, but got %v", err)
+	}
+
+	settings.BindPassword = reader.password
+	if _, err := (LDAP{settings}).Authenticate("ivan", "directory-password"); err != nil {
+		t.Errorf("The user should be found with the service account, but got %v", err)
+	}
+}
+
+func TestAuthenticateTriesProvidersInOrder(t *testing.T) {
+	defer monkey.UnpatchAll()
+	directory := startDirectory(t, ivan)
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return user.User{}, errors.New("User doesn't exist")
+	})
+
+	identity, err := Authenticate([]Provider{Local{}, LDAP{directory.settings()}}, "ivan", "directory-password")
+	if err != nil || identity.Provider != LDAPName {
+		t.Errorf("The user should be authenticated by the directory after the local provider, but got %v", err)
+	}
+}
+
+func TestAuthenticateUnavailable(t *testing.T) {
+	directory := startDirectory(t)
+	settings := directory.settings()
+	directory.listener.Close()
+
+	if _, err := Authenticate([]Provider{LDAP{settings}}, "ivan", "directory-password"); err != ErrUnavailable {
+		t.Errorf("An unreachable directory should make the login unavailable, but got %v", err)
+	}
+}
+
+func TestLocalRejectsProvisionedUsers(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return user.User{Username: "ivan", Provider: LDAPName}, nil
+	})
+
+	monkey.Patch(user.ComparePasswords, func(string, string) bool {
+		return true
+	})
+
+	if _, err := (Local{}).Authenticate("ivan", "directory-password"); err != ErrInvalidCredentials {
+		t.Errorf("A user provisioned by another provider should have no local password, but got %v", err)
+	}
+}
+
+func TestLocalEnabled(t *testing.T) {
+	t.Setenv(config.AuthProvidersVariable, "")
+	if !LocalEnabled() {
+		t.Errorf("Local passwords should be enabled by default")
+	}
+
+	t.Setenv(config.AuthProvidersVariable, " LDAP ")
+	if LocalEnabled() {
+		t.Errorf("Local passwords should be disabled when only LDAP is listed")
+	}
+}
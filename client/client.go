@@ -8,46 +8,121 @@ import (
 	"net"
 	"os"
 	"strings"
+	"sync"
+
+	"go.fmi/issuetracker/protocol"
+	"go.fmi/issuetracker/push"
+
+	"github.com/peterh/liner"
 )
 
 // LoggedUser is the user who is currently logged in the system
 var LoggedUser string
 
+// serverLine is a single line read from the server or the error which stopped the reading
+type serverLine struct {
+	text string
+	err  error
+}
+
+// promptLock guards the state of the prompt, which is shared with the goroutine reading from the server
+var (
+	promptLock    sync.Mutex
+	atPrompt      bool
+	pendingEvents []string
+)
+
 func main() {
-	con, err := net.Dial("tcp", "0.0.0.0:9999")
+	profileName, args, err := selectProfile(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if len(args) > 0 {
+		os.Exit(runSubcommand(profileName, args))
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	profileName, selected, err := config.profile(profileName)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	con, err := net.Dial("tcp", selected.Server)
 	if err != nil {
 		log.Fatalln(err)
 	}
 	defer con.Close()
 
-	clientReader := bufio.NewReader(os.Stdin)
-	serverReader := bufio.NewReader(con)
+	lastRequest := ""
+	responses := make(chan serverLine)
+	go readServer(bufio.NewReader(con), responses)
+	exchange := func(request string) (string, bool, error) {
+		con.Write([]byte(request + "\n"))
+		response := <-responses
+		if response.err != nil {
+			return "", false, response.err
+		}
+
+		message, ok := protocol.ParseResponse(response.text)
+		return strings.TrimSpace(message), ok, nil
+	}
+
+	if secret, fromEnvironment := selected.sessionToken(); secret != "" {
+		message, ok, err := exchange(protocol.Request("resume", secret))
+		switch {
+		case err != nil:
+			log.Printf("Server error: %v\n", err)
+			return
+		case ok:
+			LoggedUser = loggedUsername(message)
+			log.Println(message)
+		case fromEnvironment:
+			log.Println("The access token in " + TokenVariable + " was not accepted - " + message)
+		default:
+			log.Println("The stored session could not be resumed - " + message)
+			config.forgetSession(profileName)
+		}
+	}
+
+	closeShell := openShell(exchange)
+	defer closeShell()
 
 	for {
 		// Waiting for the client request
-		fmt.Print("Command: ")
-		clientRequest, err := clientReader.ReadString('\n')
+		showPrompt()
+		clientRequest, err := shell.Prompt("Command: ")
+		leavePrompt()
 
 		switch err {
 		case nil:
-			clientRequest := strings.TrimSpace(clientRequest)
-
-			if clientRequest == "logout" {
-				if LoggedUser != "" {
-					LoggedUser = ""
-					log.Println("Successfully logged out")
-				} else {
-					log.Println("You are not logged in")
-				}
+			name, args, err := parseShellLine(clientRequest)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			if name == "" {
 				continue
 			}
 
-			if command, ok := constructCommand(clientRequest); ok {
-				con.Write([]byte(command + "\n"))
-			} else {
+			inlineArgs = args
+			command, ok := constructCommand(name)
+			inlineArgs = nil
+			if !ok {
 				log.Println(command)
 				continue
 			}
+
+			shell.AppendHistory(strings.TrimSpace(clientRequest))
+			con.Write([]byte(command + "\n"))
+			lastRequest = command
+		case liner.ErrPromptAborted:
+			continue
 		case io.EOF:
 			log.Println("Client closed the connection")
 			return
@@ -57,7 +132,9 @@ func main() {
 		}
 
 		// Waiting for the server response
-		serverResponse, err := serverReader.ReadString('\n')
+		response := <-responses
+		serverResponse, ok := protocol.ParseResponse(response.text)
+		err = response.err
 		if strings.Index(serverResponse, "Login successful") == 0 ||
 			strings.Index(serverResponse, "Registration successful") == 0 {
 			serverResponseFields := strings.Fields(serverResponse)
@@ -66,7 +143,7 @@ func main() {
 
 		switch err {
 		case nil:
-			log.Println(strings.TrimSpace(serverResponse))
+			showResult(lastRequest, serverResponse, ok)
 		case io.EOF:
 			log.Println("Server closed the connection")
 			return
@@ -74,29 +151,186 @@ func main() {
 			log.Printf("Server error: %v\n", err)
 			return
 		}
+
+		// The session is stored in the profile, so that the next run of the client resumes it
+		switch {
+		case ok && (strings.HasPrefix(lastRequest, "login|-|") || strings.HasPrefix(lastRequest, "register|-|")):
+			if err := storeSession(exchange, config, profileName, LoggedUser); err != nil {
+				log.Println("The session could not be stored - " + err.Error())
+			}
+		case ok && lastRequest == "logout":
+			LoggedUser = ""
+			config.forgetSession(profileName)
+		}
+	}
+}
+
+// showResult shows the response to a request. Structured results are shown as tables
+func showResult(request string, message string, ok bool) {
+	r, err := newResult(request, message, ok)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if r.data == nil {
+		log.Println(r.message)
+		return
+	}
+
+	r.write(os.Stdout, outputTable)
+}
+
+// readServer reads the lines sent by the server. Pushed events are shown to the user and everything else is
+// passed on as a response to the last request. Reading stops after the first error, which is passed on as well
+func readServer(serverReader *bufio.Reader, responses chan<- serverLine) {
+	for {
+		line, err := serverReader.ReadString('\n')
+		if err == nil && strings.HasPrefix(line, push.EventPrefix) {
+			showEvent(protocol.Unescape(strings.TrimSpace(strings.TrimPrefix(line, push.EventPrefix))))
+			continue
+		}
+
+		responses <- serverLine{text: line, err: err}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// showPrompt shows the events which arrived while the user was busy before the prompt for the next command
+func showPrompt() {
+	promptLock.Lock()
+	defer promptLock.Unlock()
+
+	for _, event := range pendingEvents {
+		log.Println("Notification: " + event)
 	}
+	pendingEvents = nil
+
+	atPrompt = true
+}
+
+// leavePrompt marks that the user is no longer idle at the command prompt
+func leavePrompt() {
+	promptLock.Lock()
+	defer promptLock.Unlock()
+
+	atPrompt = false
+}
+
+// showEvent shows an event right away if the user is idle at the command prompt. Otherwise it is kept
+// until the next prompt, so that it doesn't get mixed with the input of a command
+func showEvent(event string) {
+	promptLock.Lock()
+	defer promptLock.Unlock()
+
+	if !atPrompt {
+		pendingEvents = append(pendingEvents, event)
+		return
+	}
+
+	fmt.Println()
+	log.Println("Notification: " + event)
+	fmt.Print("Command: ")
 }
 
 func constructCommand(clientRequest string) (string, bool) {
 	switch clientRequest {
 	case "disconnect":
 		return "disconnect", true
+	case "logout":
+		return ConstructLogoutCommand()
 	case "login":
 		return ConstructLoginCommand()
 	case "register":
 		return ConstructRegisterCommand()
+	case "passwd":
+		return ConstructPasswdCommand()
+	case "password reset":
+		return ConstructResetPasswordCommand()
+	case "password set":
+		return ConstructSetPasswordCommand()
 	case "project":
 		return ConstructProjectCommand()
+	case "projects":
+		return ConstructProjectsCommand()
 	case "issue":
 		return ConstructIssueCommand()
 	case "resolve":
 		return ConstructResolveCommand()
+	case "assign":
+		return ConstructAssignCommand()
+	case "label":
+		return ConstructLabelCommand()
+	case "watch":
+		return ConstructWatchCommand()
+	case "unwatch":
+		return ConstructUnwatchCommand()
 	case "list":
 		return ConstructListCommand()
 	case "find":
 		return ConstructFindCommand()
 	case "comment":
 		return ConstructCommentCommand()
+	case "comment reply":
+		return ConstructReplyCommand()
+	case "comment edit":
+		return ConstructEditCommentCommand()
+	case "comment delete":
+		return ConstructDeleteCommentCommand()
+	case "inbox":
+		return ConstructInboxCommand()
+	case "inbox unread":
+		return ConstructUnreadInboxCommand()
+	case "inbox read":
+		return ConstructMarkReadCommand()
+	case "webhook add":
+		return ConstructAddWebhookCommand()
+	case "webhook remove":
+		return ConstructRemoveWebhookCommand()
+	case "webhook list":
+		return ConstructListWebhooksCommand()
+	case "webhook deliveries":
+		return ConstructDeliveriesCommand()
+	case "email":
+		return ConstructEmailCommand()
+	case "audit":
+		return ConstructAuditCommand()
+	case "unlock":
+		return ConstructUnlockCommand()
+	case "whoami":
+		return ConstructWhoamiCommand()
+	case "users":
+		return ConstructUsersCommand()
+	case "user show":
+		return ConstructShowUserCommand()
+	case "user edit":
+		return ConstructProfileCommand()
+	case "user deactivate":
+		return ConstructDeactivateCommand()
+	case "user activate":
+		return ConstructActivateCommand()
+	case "project delete":
+		return ConstructDeleteProjectCommand()
+	case "admin grant":
+		return ConstructGrantAdminCommand()
+	case "admin revoke":
+		return ConstructRevokeAdminCommand()
+	case "admin stats":
+		return ConstructStatsCommand()
+	case "admin clients":
+		return ConstructClientsCommand()
+	case "admin kick":
+		return ConstructKickCommand()
+	case "token create":
+		return ConstructCreateAccessTokenCommand()
+	case "token list":
+		return ConstructAccessTokensCommand()
+	case "token revoke":
+		return ConstructRevokeAccessTokenCommand()
+	case "service create":
+		return ConstructServiceAccountCommand()
 	default:
 		return "Invallid command", false
 	}
@@ -110,17 +344,23 @@ func ConstructLoginCommand() (string, bool) {
 
 	scanner := bufio.NewScanner(os.Stdin)
 
-	var username, password string
-	fmt.Print("Username: ")
-	if scanner.Scan() {
-		username = scanner.Text()
+	username := readField(scanner, "Username: ")
+
+	password, err := readPassword("Password: ", false, scanner)
+	if err != nil {
+		return err.Error(), false
 	}
-	fmt.Print("Password: ")
-	if scanner.Scan() {
-		password = scanner.Text()
+
+	return protocol.Request("login", strings.TrimSpace(username), password), true
+}
+
+// ConstructLogoutCommand creates a logout command, which ends the session and revokes its stored token on the server
+func ConstructLogoutCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
 	}
 
-	return "login|-|" + strings.TrimSpace(username) + "|-|" + strings.TrimSpace(password), true
+	return "logout", true
 }
 
 // ConstructRegisterCommand parses the user input for a register command into a string, which the server can handle
@@ -131,17 +371,64 @@ func ConstructRegisterCommand() (string, bool) {
 
 	scanner := bufio.NewScanner(os.Stdin)
 
-	var username, password string
-	fmt.Print("Username: ")
-	if scanner.Scan() {
-		username = scanner.Text()
+	username := readField(scanner, "Username: ")
+
+	password, err := readPassword("Password: ", true, scanner)
+	if err != nil {
+		return err.Error(), false
+	}
+
+	return protocol.Request("register", strings.TrimSpace(username), password), true
+}
+
+// ConstructPasswdCommand reads the current and the new password of the logged in user into a string, which the server can handle
+func ConstructPasswdCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	oldPassword, err := readPassword("Current password: ", false, scanner)
+	if err != nil {
+		return err.Error(), false
+	}
+
+	newPassword, err := readNewPassword(scanner)
+	if err != nil {
+		return err.Error(), false
+	}
+
+	return protocol.Request("passwd", oldPassword, newPassword), true
+}
+
+// ConstructResetPasswordCommand parses the user input for issuing a password reset token into a string, which the server can handle
+func ConstructResetPasswordCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
 	}
-	fmt.Print("Password: ")
-	if scanner.Scan() {
-		password = scanner.Text()
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	username := readField(scanner, "Username: ")
+
+	return protocol.Request("resetpassword", strings.TrimSpace(username)), true
+}
+
+// ConstructSetPasswordCommand parses the user input for setting a password with a reset token into a string, which the server can handle
+func ConstructSetPasswordCommand() (string, bool) {
+	scanner := bufio.NewScanner(os.Stdin)
+
+	username := readField(scanner, "Username: ")
+
+	resetToken := readField(scanner, "Reset token: ")
+
+	password, err := readNewPassword(scanner)
+	if err != nil {
+		return err.Error(), false
 	}
 
-	return "register|-|" + strings.TrimSpace(username) + "|-|" + strings.TrimSpace(password), true
+	return protocol.Request("setpassword", strings.TrimSpace(username), strings.TrimSpace(resetToken), password), true
 }
 
 // ConstructProjectCommand parses the user input for a project command into a string, which the server can handle
@@ -152,13 +439,18 @@ func ConstructProjectCommand() (string, bool) {
 
 	scanner := bufio.NewScanner(os.Stdin)
 
-	var projectName string
-	fmt.Print("Project name: ")
-	if scanner.Scan() {
-		projectName = scanner.Text()
+	projectName := readField(scanner, "Project name: ")
+
+	return protocol.Request("project", strings.TrimSpace(projectName)), true
+}
+
+// ConstructProjectsCommand creates a command for listing all projects, which the server can handle
+func ConstructProjectsCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
 	}
 
-	return "project|-|" + strings.TrimSpace(projectName), true
+	return protocol.DataRequest(protocol.Request("projects")), true
 }
 
 // ConstructIssueCommand parses the user input for an issue command into a string, which the server can handle
@@ -169,25 +461,16 @@ func ConstructIssueCommand() (string, bool) {
 
 	scanner := bufio.NewScanner(os.Stdin)
 
-	var project string
-	fmt.Print("Project name: ")
-	if scanner.Scan() {
-		project = scanner.Text()
-	}
+	project := readField(scanner, "Project name: ")
 
-	var title string
-	fmt.Print("Title: ")
-	if scanner.Scan() {
-		title = scanner.Text()
-	}
+	title := readField(scanner, "Title: ")
 
-	var description string
-	fmt.Print("Description: ")
-	if scanner.Scan() {
-		description = scanner.Text()
+	description, err := readText("Description: ", descriptionTemplate, scanner)
+	if err != nil {
+		return err.Error(), false
 	}
 
-	return "issue|-|" + strings.TrimSpace(project) + "|-|" + LoggedUser + "|-|" + strings.TrimSpace(title) + "|-|" + strings.TrimSpace(description) + "|-|false", true
+	return protocol.Request("issue", strings.TrimSpace(project), strings.TrimSpace(title), description, "false"), true
 }
 
 // ConstructResolveCommand parses the user input for a resolve command into a string, which the server can handle
@@ -198,19 +481,74 @@ func ConstructResolveCommand() (string, bool) {
 
 	scanner := bufio.NewScanner(os.Stdin)
 
-	var project string
-	fmt.Print("Project name: ")
-	if scanner.Scan() {
-		project = scanner.Text()
+	project := readField(scanner, "Project name: ")
+
+	title := readField(scanner, "Title: ")
+
+	return protocol.Request("resolve", strings.TrimSpace(project), strings.TrimSpace(title)), true
+}
+
+// ConstructAssignCommand parses the user input for an assign command into a string, which the server can handle
+func ConstructAssignCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	project := readField(scanner, "Project name: ")
+
+	title := readField(scanner, "Title: ")
+
+	assignee := readField(scanner, "Assignee: ")
+
+	return protocol.Request("assign", strings.TrimSpace(project), strings.TrimSpace(title), strings.TrimSpace(assignee)), true
+}
+
+// ConstructLabelCommand parses the user input for a label command into a string, which the server can handle
+func ConstructLabelCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	project := readField(scanner, "Project name: ")
+	title := readField(scanner, "Title: ")
+	labels := readField(scanner, "Labels (separated by commas, empty to remove all): ")
+
+	return protocol.Request("label", strings.TrimSpace(project), strings.TrimSpace(title), strings.TrimSpace(labels)), true
+}
+
+// ConstructWatchCommand parses the user input for a watch command into a string, which the server can handle
+func ConstructWatchCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
 	}
 
-	var title string
-	fmt.Print("Title: ")
-	if scanner.Scan() {
-		title = scanner.Text()
+	project, title := scanWatched()
+	return protocol.Request("watch", project, title), true
+}
+
+// ConstructUnwatchCommand parses the user input for an unwatch command into a string, which the server can handle
+func ConstructUnwatchCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
 	}
 
-	return "resolve|-|" + strings.TrimSpace(project) + "|-|" + strings.TrimSpace(title), true
+	project, title := scanWatched()
+	return protocol.Request("unwatch", project, title), true
+}
+
+// scanWatched reads the project and the optional issue title which are the subject of a watch
+func scanWatched() (string, string) {
+	scanner := bufio.NewScanner(os.Stdin)
+
+	project := readField(scanner, "Project name: ")
+
+	title := readField(scanner, "Title (empty for the whole project): ")
+
+	return strings.TrimSpace(project), strings.TrimSpace(title)
 }
 
 // ConstructListCommand parses the user input for a list command into a string, which the server can handle
@@ -221,13 +559,9 @@ func ConstructListCommand() (string, bool) {
 
 	scanner := bufio.NewScanner(os.Stdin)
 
-	var project string
-	fmt.Print("Project name: ")
-	if scanner.Scan() {
-		project = scanner.Text()
-	}
+	project := readField(scanner, "Project name: ")
 
-	return "list|-|" + strings.TrimSpace(project), true
+	return protocol.DataRequest(protocol.Request("list", strings.TrimSpace(project))), true
 }
 
 // ConstructFindCommand parses the user input for a find command into a string, which the server can handle
@@ -238,19 +572,11 @@ func ConstructFindCommand() (string, bool) {
 
 	scanner := bufio.NewScanner(os.Stdin)
 
-	var project string
-	fmt.Print("Project name: ")
-	if scanner.Scan() {
-		project = scanner.Text()
-	}
+	project := readField(scanner, "Project name: ")
 
-	var title string
-	fmt.Print("Title: ")
-	if scanner.Scan() {
-		title = scanner.Text()
-	}
+	title := readField(scanner, "Title: ")
 
-	return "find|-|" + strings.TrimSpace(project) + "|-|" + strings.TrimSpace(title), true
+	return protocol.DataRequest(protocol.Request("find", strings.TrimSpace(project), strings.TrimSpace(title))), true
 }
 
 // ConstructCommentCommand parses the user input for a comment command into a string, which the server can handle
@@ -261,23 +587,407 @@ func ConstructCommentCommand() (string, bool) {
 
 	scanner := bufio.NewScanner(os.Stdin)
 
-	var project string
-	fmt.Print("Project name: ")
-	if scanner.Scan() {
-		project = scanner.Text()
+	project := readField(scanner, "Project name: ")
+
+	title := readField(scanner, "Title: ")
+
+	comment, err := readText("Comment: ", commentTemplate, scanner)
+	if err != nil {
+		return err.Error(), false
 	}
 
-	var title string
-	fmt.Print("Title: ")
-	if scanner.Scan() {
-		title = scanner.Text()
+	return protocol.Request("comment", strings.TrimSpace(project), strings.TrimSpace(title), comment, LoggedUser), true
+}
+
+// ConstructReplyCommand parses the user input for a reply to a comment into a string, which the server can handle
+func ConstructReplyCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	project := readField(scanner, "Project name: ")
+
+	title := readField(scanner, "Title: ")
+
+	parent := readField(scanner, "Reply to comment ID: ")
+
+	comment, err := readText("Comment: ", commentTemplate, scanner)
+	if err != nil {
+		return err.Error(), false
+	}
+
+	return protocol.Request("comment", strings.TrimSpace(project), strings.TrimSpace(title), comment, LoggedUser, strings.TrimSpace(parent)), true
+}
+
+// ConstructEditCommentCommand parses the user input for editing a comment into a string, which the server can handle
+func ConstructEditCommentCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
 	}
 
-	var comment string
-	fmt.Print("Comment: ")
-	if scanner.Scan() {
-		comment = scanner.Text()
+	scanner := bufio.NewScanner(os.Stdin)
+
+	id := readField(scanner, "Comment ID: ")
+
+	comment, err := readText("New comment: ", commentTemplate, scanner)
+	if err != nil {
+		return err.Error(), false
 	}
 
-	return "comment|-|" + strings.TrimSpace(project) + "|-|" + strings.TrimSpace(title) + "|-|" + strings.TrimSpace(comment) + "|-|" + LoggedUser, true
+	return protocol.Request("editcomment", strings.TrimSpace(id), comment), true
+}
+
+// ConstructDeleteCommentCommand parses the user input for deleting a comment into a string, which the server can handle
+func ConstructDeleteCommentCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	id := readField(scanner, "Comment ID: ")
+
+	return protocol.Request("deletecomment", strings.TrimSpace(id)), true
+}
+
+// ConstructInboxCommand constructs a command for listing all notifications, which the server can handle
+func ConstructInboxCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	return protocol.Request("inbox", ""), true
+}
+
+// ConstructUnreadInboxCommand constructs a command for listing the unread notifications, which the server can handle
+func ConstructUnreadInboxCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	return protocol.Request("inbox", "unread"), true
+}
+
+// ConstructMarkReadCommand parses the user input for marking notifications as read into a string, which the server can handle
+func ConstructMarkReadCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	id := readField(scanner, "Notification ID (empty for all): ")
+
+	return protocol.Request("markread", strings.TrimSpace(id)), true
+}
+
+// ConstructAddWebhookCommand parses the user input for adding a webhook into a string, which the server can handle
+func ConstructAddWebhookCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	project := readField(scanner, "Project name: ")
+
+	url := readField(scanner, "URL: ")
+
+	secret := readField(scanner, "Secret: ")
+
+	return protocol.Request("addwebhook", strings.TrimSpace(project), strings.TrimSpace(url), strings.TrimSpace(secret)), true
+}
+
+// ConstructRemoveWebhookCommand parses the user input for removing a webhook into a string, which the server can handle
+func ConstructRemoveWebhookCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	id := readField(scanner, "Webhook ID: ")
+
+	return protocol.Request("removewebhook", strings.TrimSpace(id)), true
+}
+
+// ConstructListWebhooksCommand parses the user input for listing webhooks into a string, which the server can handle
+func ConstructListWebhooksCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	project := readField(scanner, "Project name: ")
+
+	return protocol.Request("webhooks", strings.TrimSpace(project)), true
+}
+
+// ConstructDeliveriesCommand parses the user input for listing webhook deliveries into a string, which the server can handle
+func ConstructDeliveriesCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	project := readField(scanner, "Project name: ")
+
+	return protocol.Request("deliveries", strings.TrimSpace(project)), true
+}
+
+// ConstructEmailCommand parses the user input for changing the email preferences into a string, which the server can handle
+func ConstructEmailCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	email := readField(scanner, "Email: ")
+
+	mode := readField(scanner, "Mode (off, instant or digest): ")
+
+	kinds := readField(scanner, "Notify about (mentions, assignments, status - empty for all): ")
+
+	return protocol.Request("email", strings.TrimSpace(email), strings.TrimSpace(mode), strings.TrimSpace(kinds)), true
+}
+
+// ConstructAuditCommand parses the user input for an audit command into a string, which the server can handle
+func ConstructAuditCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	username := readField(scanner, "Username (optional): ")
+
+	action := readField(scanner, "Action (optional): ")
+
+	from := readField(scanner, "From YYYY-MM-DD (optional): ")
+
+	to := readField(scanner, "To YYYY-MM-DD (optional): ")
+
+	return protocol.Request("audit", strings.TrimSpace(username), strings.TrimSpace(action), strings.TrimSpace(from), strings.TrimSpace(to)), true
+}
+
+// ConstructUnlockCommand parses the user input for unlocking an account or an address into a string, which the server can handle
+func ConstructUnlockCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	username := readField(scanner, "Username (optional): ")
+
+	address := readField(scanner, "Address (optional): ")
+
+	return protocol.Request("unlock", strings.TrimSpace(username), strings.TrimSpace(address)), true
+}
+
+// ConstructWhoamiCommand creates a command for showing the profile of the logged in user, which the server can handle
+func ConstructWhoamiCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	return protocol.DataRequest(protocol.Request("user", LoggedUser)), true
+}
+
+// ConstructUsersCommand creates a command for listing all users, which the server can handle
+func ConstructUsersCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	return protocol.DataRequest(protocol.Request("users")), true
+}
+
+// ConstructShowUserCommand parses the user input for showing the profile of a user into a string, which the server can handle
+func ConstructShowUserCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	username := readField(scanner, "Username: ")
+
+	return protocol.DataRequest(protocol.Request("user", strings.TrimSpace(username))), true
+}
+
+// ConstructProfileCommand parses the user input for changing the profile of the logged in user into a string, which the server can handle
+func ConstructProfileCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	displayName := readField(scanner, "Display name (optional): ")
+
+	email := readField(scanner, "Email (optional): ")
+
+	timezone := readField(scanner, "Timezone, e.g. Europe/Sofia (optional): ")
+
+	return protocol.Request("profile", strings.TrimSpace(displayName), strings.TrimSpace(email), strings.TrimSpace(timezone)), true
+}
+
+// ConstructDeactivateCommand parses the user input for deactivating a user into a string, which the server can handle
+func ConstructDeactivateCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	username := readField(scanner, "Username: ")
+
+	return protocol.Request("deactivate", strings.TrimSpace(username)), true
+}
+
+// ConstructActivateCommand parses the user input for activating a deactivated user into a string, which the server can handle
+func ConstructActivateCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	username := readField(scanner, "Username: ")
+
+	return protocol.Request("activate", strings.TrimSpace(username)), true
+}
+
+// ConstructDeleteProjectCommand parses the user input for deleting a project into a string, which the server can handle
+func ConstructDeleteProjectCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	project := readField(scanner, "Project name: ")
+
+	return protocol.Request("deleteproject", strings.TrimSpace(project)), true
+}
+
+// ConstructGrantAdminCommand parses the user input for granting administrator rights into a string, which the server can handle
+func ConstructGrantAdminCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	username := readField(scanner, "Username: ")
+
+	return protocol.Request("grantadmin", strings.TrimSpace(username)), true
+}
+
+// ConstructRevokeAdminCommand parses the user input for revoking administrator rights into a string, which the server can handle
+func ConstructRevokeAdminCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	username := readField(scanner, "Username: ")
+
+	return protocol.Request("revokeadmin", strings.TrimSpace(username)), true
+}
+
+// ConstructStatsCommand creates a command for showing the statistics of the server, which the server can handle
+func ConstructStatsCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	return protocol.DataRequest(protocol.Request("stats")), true
+}
+
+// ConstructClientsCommand creates a command for listing the connected clients, which the server can handle
+func ConstructClientsCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	return protocol.DataRequest(protocol.Request("clients")), true
+}
+
+// ConstructKickCommand parses the user input for disconnecting a client into a string, which the server can handle
+func ConstructKickCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	id := readField(scanner, "Client number: ")
+
+	return protocol.Request("kick", strings.TrimSpace(id)), true
+}
+
+// ConstructCreateAccessTokenCommand parses the user input for creating a personal access token into a string, which the server can handle
+func ConstructCreateAccessTokenCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	name := readField(scanner, "Token name: ")
+
+	scopes := readField(scanner, "Scopes (read, write, admin separated by commas): ")
+
+	days := readField(scanner, "Valid for days (optional): ")
+
+	username := readField(scanner, "Owner (empty for yourself): ")
+
+	return protocol.Request("createtoken", strings.TrimSpace(username), strings.TrimSpace(name), strings.TrimSpace(scopes), strings.TrimSpace(days)), true
+}
+
+// ConstructAccessTokensCommand parses the user input for listing personal access tokens into a string, which the server can handle
+func ConstructAccessTokensCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	username := readField(scanner, "Owner (empty for yourself): ")
+
+	return protocol.DataRequest(protocol.Request("tokens", strings.TrimSpace(username))), true
+}
+
+// ConstructRevokeAccessTokenCommand parses the user input for revoking a personal access token into a string, which the server can handle
+func ConstructRevokeAccessTokenCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	id := readField(scanner, "Token number: ")
+
+	return protocol.Request("revoketoken", strings.TrimSpace(id)), true
+}
+
+// ConstructServiceAccountCommand parses the user input for creating a service account into a string, which the server can handle
+func ConstructServiceAccountCommand() (string, bool) {
+	if LoggedUser == "" {
+		return "You are not logged in", false
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	username := readField(scanner, "Username: ")
+
+	return protocol.Request("serviceaccount", strings.TrimSpace(username)), true
 }
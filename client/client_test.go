@@ -21,6 +21,24 @@ func TestConstructLoginCommandSkeletonNotLogged(t *testing.T) {
 	}
 }
 
+func TestConstructLogoutCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "logout"
+	command, _ := ConstructLogoutCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructLogoutCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructLogoutCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
 func TestConstructRegisterCommandSkeletonLogged(t *testing.T) {
 	LoggedUser = "test"
 	expected := "You are already logged in"
@@ -39,6 +57,51 @@ func TestConstructRegisterCommandSkeletonNotLogged(t *testing.T) {
 	}
 }
 
+func TestConstructPasswdCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "passwd|-||-|"
+	command, _ := ConstructPasswdCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructPasswdCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructPasswdCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructResetPasswordCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "resetpassword|-|"
+	command, _ := ConstructResetPasswordCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructResetPasswordCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructResetPasswordCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructSetPasswordCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "setpassword|-||-||-|"
+	command, _ := ConstructSetPasswordCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
 func TestConstructProjectCommandSkeletonLogged(t *testing.T) {
 	LoggedUser = "test"
 	expected := "project|-|"
@@ -57,9 +120,27 @@ func TestConstructProjectCommandSkeletonNotLogged(t *testing.T) {
 	}
 }
 
+func TestConstructProjectsCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "data|-|projects"
+	command, _ := ConstructProjectsCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructProjectsCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructProjectsCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
 func TestConstructIssueCommandSkeletonLogged(t *testing.T) {
 	LoggedUser = "test"
-	expected := "issue|-||-|test|-||-||-|false"
+	expected := "issue|-||-||-||-|false"
 	command, _ := ConstructIssueCommand()
 	if command != expected {
 		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
@@ -93,9 +174,81 @@ func TestConstructResolveCommandSkeletonNotLogged(t *testing.T) {
 	}
 }
 
+func TestConstructAssignCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "assign|-||-||-|"
+	command, _ := ConstructAssignCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructAssignCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructAssignCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructLabelCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "label|-||-||-|"
+	command, _ := ConstructLabelCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructLabelCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructLabelCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructWatchCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "watch|-||-|"
+	command, _ := ConstructWatchCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructWatchCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructWatchCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructUnwatchCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "unwatch|-||-|"
+	command, _ := ConstructUnwatchCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructUnwatchCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructUnwatchCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
 func TestConstructListCommandSkeletonLogged(t *testing.T) {
 	LoggedUser = "test"
-	expected := "list|-|"
+	expected := "data|-|list|-|"
 	command, _ := ConstructListCommand()
 	if command != expected {
 		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
@@ -113,7 +266,7 @@ func TestConstructListCommandSkeletonNotLogged(t *testing.T) {
 
 func TestConstructFindCommandSkeletonLogged(t *testing.T) {
 	LoggedUser = "test"
-	expected := "find|-||-|"
+	expected := "data|-|find|-||-|"
 	command, _ := ConstructFindCommand()
 	if command != expected {
 		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
@@ -146,3 +299,435 @@ func TestConstructCommentCommandSkeletonNotLogged(t *testing.T) {
 		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
 	}
 }
+
+func TestConstructReplyCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "comment|-||-||-||-|test|-|"
+	command, _ := ConstructReplyCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructReplyCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructReplyCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructEditCommentCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "editcomment|-||-|"
+	command, _ := ConstructEditCommentCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructEditCommentCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructEditCommentCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructDeleteCommentCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "deletecomment|-|"
+	command, _ := ConstructDeleteCommentCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructDeleteCommentCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructDeleteCommentCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructInboxCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "inbox|-|"
+	command, _ := ConstructInboxCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructInboxCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructInboxCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructUnreadInboxCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "inbox|-|unread"
+	command, _ := ConstructUnreadInboxCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructUnreadInboxCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructUnreadInboxCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructMarkReadCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "markread|-|"
+	command, _ := ConstructMarkReadCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructMarkReadCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructMarkReadCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructAddWebhookCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "addwebhook|-||-||-|"
+	command, _ := ConstructAddWebhookCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructAddWebhookCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructAddWebhookCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructRemoveWebhookCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "removewebhook|-|"
+	command, _ := ConstructRemoveWebhookCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructRemoveWebhookCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructRemoveWebhookCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructListWebhooksCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "webhooks|-|"
+	command, _ := ConstructListWebhooksCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructListWebhooksCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructListWebhooksCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructDeliveriesCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "deliveries|-|"
+	command, _ := ConstructDeliveriesCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructDeliveriesCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructDeliveriesCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructEmailCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "email|-||-||-|"
+	command, _ := ConstructEmailCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructEmailCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructEmailCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructAuditCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "audit|-||-||-||-|"
+	command, _ := ConstructAuditCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructAuditCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructAuditCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructUnlockCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "unlock|-||-|"
+	command, _ := ConstructUnlockCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructUnlockCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructUnlockCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructWhoamiCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "data|-|user|-|test"
+	command, _ := ConstructWhoamiCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructWhoamiCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructWhoamiCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructUsersCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "data|-|users"
+	command, _ := ConstructUsersCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructUsersCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructUsersCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructProfileCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "profile|-||-||-|"
+	command, _ := ConstructProfileCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructProfileCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructProfileCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructDeactivateCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "deactivate|-|"
+	command, _ := ConstructDeactivateCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructDeactivateCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructDeactivateCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructDeleteProjectCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "deleteproject|-|"
+	command, _ := ConstructDeleteProjectCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructDeleteProjectCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructDeleteProjectCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructStatsCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "data|-|stats"
+	command, _ := ConstructStatsCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructStatsCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructStatsCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructKickCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "kick|-|"
+	command, _ := ConstructKickCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructKickCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructKickCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructCreateAccessTokenCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "createtoken|-||-||-||-|"
+	command, _ := ConstructCreateAccessTokenCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructCreateAccessTokenCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructCreateAccessTokenCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructAccessTokensCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "data|-|tokens|-|"
+	command, _ := ConstructAccessTokensCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructAccessTokensCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructAccessTokensCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructServiceAccountCommandSkeletonLogged(t *testing.T) {
+	LoggedUser = "test"
+	expected := "serviceaccount|-|"
+	command, _ := ConstructServiceAccountCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestConstructServiceAccountCommandSkeletonNotLogged(t *testing.T) {
+	LoggedUser = ""
+	expected := "You are not logged in"
+	command, _ := ConstructServiceAccountCommand()
+	if command != expected {
+		t.Errorf("Command skeleton was not costructed properly. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+func TestShowEventWhileBusy(t *testing.T) {
+	leavePrompt()
+	pendingEvents = nil
+
+	showEvent("alice mentioned you")
+
+	if len(pendingEvents) != 1 || pendingEvents[0] != "alice mentioned you" {
+		t.Errorf("Event should be kept until the next prompt while the user is entering a command")
+	}
+
+	showPrompt()
+	leavePrompt()
+
+	if len(pendingEvents) != 0 {
+		t.Errorf("Pending events should be shown at the next prompt")
+	}
+}
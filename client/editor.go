@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Environment variables naming the editor in which multi-line texts are written, in order of preference
+const (
+	VisualVariable = "VISUAL"
+	EditorVariable = "EDITOR"
+)
+
+// defaultEditor is used when neither of the editor variables is set
+const defaultEditor = "vi"
+
+// instructions are appended to the templates and removed from the texts after they are edited
+const instructions = `<!--
+  Write the text in markdown above. This comment is removed.
+  Leaving the text empty or unchanged aborts the command.
+-->
+`
+
+// Templates for the texts written in the editor
+const (
+	descriptionTemplate = "## Steps to reproduce\n\n\n## Expected behaviour\n\n\n## Actual behaviour\n\n\n" + instructions
+	commentTemplate     = "\n\n" + instructions
+)
+
+// editorArgs returns the editor command with its arguments, e.g. 'code --wait'
+func editorArgs() []string {
+	for _, variable := range []string{VisualVariable, EditorVariable} {
+		if args := strings.Fields(os.Getenv(variable)); len(args) > 0 {
+			return args
+		}
+	}
+
+	return []string{defaultEditor}
+}
+
+// readText reads a multi-line markdown text, e.g. an issue description or a comment. In a terminal the text
+// is typed as a single line, or written in the editor when the line is left empty. Otherwise it is read as
+// a single line with the scanner
+func readText(prompt string, template string, scanner *bufio.Scanner) (string, error) {
+	terminal := term.IsTerminal(int(os.Stdin.Fd()))
+	if terminal {
+		prompt = strings.TrimSuffix(prompt, ": ") + " (empty to open the editor): "
+	}
+
+	line := readField(scanner, prompt)
+	if !terminal || strings.TrimSpace(line) != "" {
+		return strings.TrimSpace(line), nil
+	}
+
+	return editText(template)
+}
+
+// readTextOption returns the text of a flag given as '-', which is written in the editor when the input is
+// a terminal and read from the input otherwise, e.g. a stack trace piped to the client
+func readTextOption(template string, input io.Reader) (string, error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return editText(template)
+	}
+
+	text, err := io.ReadAll(input)
+	if err != nil {
+		return "", err
+	}
+
+	return trimText(string(text)), nil
+}
+
+// editText opens the editor with a temporary markdown file containing the template and returns the text
+// written in it without the instructions
+func editText(template string) (string, error) {
+	file, err := os.CreateTemp("", "issuetracker-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(template)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	args := editorArgs()
+	editor := exec.Command(args[0], append(args[1:], file.Name())...)
+	editor.Stdin, editor.Stdout, editor.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := runCooked(editor.Run); err != nil {
+		return "", errors.New("The editor failed - " + err.Error())
+	}
+
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+
+	return stripTemplate(string(edited), template)
+}
+
+// stripTemplate removes the instructions from an edited text. A text which is empty or the same as
+// the template aborts the command
+func stripTemplate(edited string, template string) (string, error) {
+	text := trimText(strings.Replace(edited, instructions, "", 1))
+	if text == "" || text == trimText(strings.Replace(template, instructions, "", 1)) {
+		return "", errors.New("Aborted - the text is empty")
+	}
+
+	return text, nil
+}
+
+// trimText removes the blank lines at the start of a text and the whitespace at its end, keeping the
+// indentation of its first line, e.g. in a code block
+func trimText(text string) string {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), " \t\n")
+	for strings.HasPrefix(strings.TrimLeft(text, " \t"), "\n") {
+		text = text[strings.Index(text, "\n")+1:]
+	}
+
+	return text
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeEditor sets an editor which replaces the edited file with a text
+func fakeEditor(t *testing.T, text string) {
+	dir := t.TempDir()
+	textPath := filepath.Join(dir, "text.md")
+	if err := os.WriteFile(textPath, []byte(text), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	script := filepath.Join(dir, "editor")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncp "+textPath+" \"$1\"\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(VisualVariable, "")
+	t.Setenv(EditorVariable, script)
+}
+
+func TestEditText(t *testing.T) {
+	fakeEditor(t, "\n\n    go test ./...\n\npanic: boom\n"+instructions+"\n")
+
+	text, err := editText(commentTemplate)
+	if err != nil || text != "    go test ./...\n\npanic: boom" {
+		t.Errorf("Invalid edited text %q, %v", text, err)
+	}
+}
+
+func TestEditTextUnchangedTemplate(t *testing.T) {
+	fakeEditor(t, descriptionTemplate)
+
+	if text, err := editText(descriptionTemplate); err == nil {
+		t.Errorf("Expected the command to be aborted, but got %q", text)
+	}
+}
+
+func TestEditTextFailingEditor(t *testing.T) {
+	t.Setenv(VisualVariable, "false")
+
+	if _, err := editText(commentTemplate); err == nil {
+		t.Errorf("Expected an error from the failing editor")
+	}
+}
+
+func TestEditorArgs(t *testing.T) {
+	t.Setenv(VisualVariable, "")
+	t.Setenv(EditorVariable, "code --wait")
+
+	if args := editorArgs(); len(args) != 2 || args[0] != "code" || args[1] != "--wait" {
+		t.Errorf("Invalid editor arguments %q", args)
+	}
+}
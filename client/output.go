@@ -0,0 +1,355 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go.fmi/issuetracker/clients"
+	"go.fmi/issuetracker/command"
+	"go.fmi/issuetracker/comment"
+	"go.fmi/issuetracker/issue"
+	"go.fmi/issuetracker/project"
+	"go.fmi/issuetracker/protocol"
+	"go.fmi/issuetracker/stats"
+	"go.fmi/issuetracker/token"
+	"go.fmi/issuetracker/user"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats for the results of commands
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputCSV   = "csv"
+	outputYAML  = "yaml"
+)
+
+// validOutput checks whether a format is one of the supported output formats
+func validOutput(format string) bool {
+	switch format {
+	case outputTable, outputJSON, outputCSV, outputYAML:
+		return true
+	}
+
+	return false
+}
+
+// table is a result as columns and rows of text
+type table struct {
+	columns []string
+	rows    [][]string
+}
+
+// body is a multi-line text of a result, e.g. a markdown description, shown in full after the tables
+type body struct {
+	heading string
+	text    string
+}
+
+// result is the outcome of a request as it is shown to the user
+type result struct {
+	ok      bool
+	message string
+	// data is the structured result of a data request and tables is its form as text, both empty for plain messages
+	data   interface{}
+	tables []table
+	bodies []body
+}
+
+// newResult decodes the response to a request. The responses to data requests are decoded according to the
+// type of the requested command
+func newResult(request string, message string, ok bool) (result, error) {
+	message = strings.TrimSpace(message)
+
+	encoded, isData := protocol.Data(message)
+	if !ok || !isData {
+		return result{ok: ok, message: message}, nil
+	}
+
+	requestType := strings.Split(strings.TrimPrefix(request, protocol.DataPrefix), protocol.Separator)[0]
+	switch requestType {
+	case "projects":
+		var projects []project.Project
+		if err := json.Unmarshal(encoded, &projects); err != nil {
+			return result{}, err
+		}
+		return result{ok: true, data: projects, tables: []table{projectsTable(projects)}}, nil
+	case "list":
+		var issues []issue.Issue
+		if err := json.Unmarshal(encoded, &issues); err != nil {
+			return result{}, err
+		}
+		return result{ok: true, data: issues, tables: []table{issuesTable(issues)}}, nil
+	case "find":
+		var details command.IssueDetails
+		if err := json.Unmarshal(encoded, &details); err != nil {
+			return result{}, err
+		}
+		return result{ok: true, data: details, tables: []table{issuesTable([]issue.Issue{details.Issue}), commentsTable(details.Comments)},
+			bodies: detailsBodies(details)}, nil
+	case "users":
+		var users []user.User
+		if err := json.Unmarshal(encoded, &users); err != nil {
+			return result{}, err
+		}
+		return result{ok: true, data: users, tables: []table{usersTable(users)}}, nil
+	case "user":
+		var u user.User
+		if err := json.Unmarshal(encoded, &u); err != nil {
+			return result{}, err
+		}
+		return result{ok: true, data: u, tables: []table{usersTable([]user.User{u})}}, nil
+	case "stats":
+		var s stats.Stats
+		if err := json.Unmarshal(encoded, &s); err != nil {
+			return result{}, err
+		}
+		return result{ok: true, data: s, tables: []table{statsTable(s)}}, nil
+	case "clients":
+		var connected []clients.Client
+		if err := json.Unmarshal(encoded, &connected); err != nil {
+			return result{}, err
+		}
+		return result{ok: true, data: connected, tables: []table{clientsTable(connected)}}, nil
+	case "tokens":
+		var tokens []token.Token
+		if err := json.Unmarshal(encoded, &tokens); err != nil {
+			return result{}, err
+		}
+		return result{ok: true, data: tokens, tables: []table{tokensTable(tokens)}}, nil
+	default:
+		return result{}, fmt.Errorf("unexpected data for a %s request", requestType)
+	}
+}
+
+func projectsTable(projects []project.Project) table {
+	t := table{columns: []string{"NAME", "OWNER"}}
+	for _, p := range projects {
+		t.rows = append(t.rows, []string{p.Name, p.Owner})
+	}
+
+	return t
+}
+
+func issuesTable(issues []issue.Issue) table {
+	t := table{columns: []string{"PROJECT", "TITLE", "REPORTER", "ASSIGNEE", "STATUS", "LABELS", "DESCRIPTION"}}
+	for _, i := range issues {
+		t.rows = append(t.rows, []string{i.Project, i.Title, i.Reporter, i.Assignee, string(i.Status), i.Labels, i.Description})
+	}
+
+	return t
+}
+
+func commentsTable(entries []comment.ThreadEntry) table {
+	t := table{columns: []string{"ID", "PARENT", "DEPTH", "COMMENTER", "CREATED", "EDITED", "CONTENT"}}
+	for _, entry := range entries {
+		c := entry.Comment
+		content := c.Content
+		if c.Deleted {
+			content = "[deleted]"
+		}
+
+		t.rows = append(t.rows, []string{
+			strconv.Itoa(c.ID), strconv.Itoa(c.Parent), strconv.Itoa(entry.Depth), c.Commenter,
+			formatTime(c.Created), formatTime(c.Edited), content})
+	}
+
+	return t
+}
+
+func usersTable(users []user.User) table {
+	t := table{columns: []string{"USERNAME", "NAME", "EMAIL", "TIMEZONE", "ROLE", "STATUS"}}
+	for _, u := range users {
+		t.rows = append(t.rows, []string{u.Username, u.DisplayName, u.Email, u.Timezone, u.Role(), u.Status()})
+	}
+
+	return t
+}
+
+func tokensTable(tokens []token.Token) table {
+	t := table{columns: []string{"ID", "NAME", "USER", "SCOPES", "CREATED", "EXPIRES"}}
+	for _, tk := range tokens {
+		t.rows = append(t.rows, []string{strconv.Itoa(tk.ID), tk.Name, tk.Username, token.FormatScopes(tk.Scopes),
+			formatTime(tk.Created), formatTime(tk.Expires)})
+	}
+
+	return t
+}
+
+func statsTable(s stats.Stats) table {
+	count := func(n int64) string { return strconv.FormatInt(n, 10) }
+	return table{
+		columns: []string{"USERS", "DEACTIVATED", "PROJECTS", "OPEN", "RESOLVED", "COMMENTS", "SESSIONS", "CLIENTS", "STARTED"},
+		rows: [][]string{{count(s.Users), count(s.DeactivatedUsers), count(s.Projects), count(s.OpenIssues), count(s.ResolvedIssues),
+			count(s.Comments), count(s.Sessions), strconv.Itoa(s.Clients), formatTime(s.Started)}}}
+}
+
+func clientsTable(connected []clients.Client) table {
+	t := table{columns: []string{"ID", "USERNAME", "ADDRESS", "CONNECTED"}}
+	for _, c := range connected {
+		t.rows = append(t.rows, []string{strconv.Itoa(c.ID), c.Username, c.RemoteAddr, formatTime(c.Connected)})
+	}
+
+	return t
+}
+
+// detailsBodies returns the multi-line description and comments of an issue, which don't fit in the tables
+func detailsBodies(details command.IssueDetails) []body {
+	var bodies []body
+	if strings.Contains(details.Issue.Description, "\n") {
+		bodies = append(bodies, body{"DESCRIPTION", details.Issue.Description})
+	}
+	for _, entry := range details.Comments {
+		if c := entry.Comment; !c.Deleted && strings.Contains(c.Content, "\n") {
+			bodies = append(bodies, body{"COMMENT #" + strconv.Itoa(c.ID) + " by " + c.Commenter, c.Content})
+		}
+	}
+
+	return bodies
+}
+
+// formatTime formats a moment for the tables, leaving the zero time empty
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// write shows a result in one of the output formats. Plain messages are shown as they are in a table
+// and as an object with 'ok' and 'message' in the other formats
+func (r result) write(w io.Writer, format string) error {
+	value := r.data
+	if value == nil {
+		value = map[string]interface{}{"ok": r.ok, "message": r.message}
+	}
+
+	switch format {
+	case outputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case outputYAML:
+		return writeYAML(w, value)
+	case outputCSV:
+		tables := r.tables
+		if r.data == nil {
+			tables = []table{{columns: []string{"ok", "message"}, rows: [][]string{{strconv.FormatBool(r.ok), r.message}}}}
+		}
+		return writeCSV(w, tables)
+	default:
+		if r.data == nil {
+			_, err := fmt.Fprintln(w, r.message)
+			return err
+		}
+		if err := writeTables(w, r.tables); err != nil {
+			return err
+		}
+		return writeBodies(w, r.bodies)
+	}
+}
+
+// writeYAML writes a value as YAML with the same keys as its JSON form
+func writeYAML(w io.Writer, value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(encoded, &generic); err != nil {
+		return err
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(generic); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// writeCSV writes tables as CSV with a header row, separating them with an empty line
+func writeCSV(w io.Writer, tables []table) error {
+	for i, t := range tables {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		writer := csv.NewWriter(w)
+		writer.Write(t.columns)
+		writer.WriteAll(t.rows)
+		if err := writer.Error(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeTables writes tables as aligned columns. Only the first line of a multi-line cell is shown, followed by
+// a mark, so that the rows stay aligned
+func writeTables(w io.Writer, tables []table) error {
+	for i, t := range tables {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.Join(t.columns, "\t"))
+		for _, row := range t.rows {
+			cells := make([]string, len(row))
+			for j, cell := range row {
+				cells[j] = firstLine(cell)
+			}
+			fmt.Fprintln(writer, strings.Join(cells, "\t"))
+		}
+		if len(t.rows) == 0 {
+			fmt.Fprintln(writer, "(none)")
+		}
+
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// firstLine returns the first non-empty line of a text with its whitespace collapsed, marking that the rest is left out
+func firstLine(text string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	switch len(lines) {
+	case 0:
+		return ""
+	case 1:
+		return lines[0]
+	default:
+		return lines[0] + " [...]"
+	}
+}
+
+// writeBodies writes multi-line texts in full, each under its heading
+func writeBodies(w io.Writer, bodies []body) error {
+	for _, b := range bodies {
+		if _, err := fmt.Fprintf(w, "\n%s\n%s\n", b.heading, b.text); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.fmi/issuetracker/command"
+)
+
+const findResponse = `data|-|{"issue":{"project":"p","reporter":"r","title":"t","description":"line one\nline two","status":"open"},` +
+	`"comments":[{"comment":{"id":1,"parent":0,"content":"hi, there","commenter":"c","created":"2021-01-05T10:30:00Z"},"depth":0}]}`
+
+func TestPlainMessageResult(t *testing.T) {
+	r, err := newResult("project|-|p", "Project created successfully\n", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	r.write(&output, outputJSON)
+	if !strings.Contains(output.String(), `"message": "Project created successfully"`) || !strings.Contains(output.String(), `"ok": true`) {
+		t.Errorf("Invalid JSON output: " + output.String())
+	}
+}
+
+func TestFindResultAsCSV(t *testing.T) {
+	r, err := newResult("data|-|find|-|p|-|t", findResponse, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	r.write(&output, outputCSV)
+	expected := "PROJECT,TITLE,REPORTER,ASSIGNEE,STATUS,LABELS,DESCRIPTION\np,t,r,,open,,\"line one\nline two\"\n\n" +
+		"ID,PARENT,DEPTH,COMMENTER,CREATED,EDITED,CONTENT\n1,0,0,c," + formatTime(r.data.(command.IssueDetails).Comments[0].Comment.Created) + ",,\"hi, there\"\n"
+	if output.String() != expected {
+		t.Errorf("Invalid CSV output. Expected: " + expected + ", but got: " + output.String())
+	}
+}
+
+func TestFindResultAsTable(t *testing.T) {
+	r, _ := newResult("data|-|find|-|p|-|t", findResponse, true)
+
+	var output bytes.Buffer
+	r.write(&output, outputTable)
+	lines := strings.Split(output.String(), "\n")
+	if !strings.HasPrefix(lines[0], "PROJECT  TITLE  REPORTER") || !strings.HasSuffix(lines[1], "line one [...]") {
+		t.Errorf("Invalid table output: " + output.String())
+	}
+	if !strings.HasSuffix(output.String(), "\nDESCRIPTION\nline one\nline two\n") {
+		t.Errorf("The description is not shown in full: " + output.String())
+	}
+}
+
+func TestListResultAsYAML(t *testing.T) {
+	r, err := newResult("data|-|list|-|p", `data|-|[{"project":"p","reporter":"r","title":"t","description":"d","status":"resolved"}]`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	r.write(&output, outputYAML)
+	if !strings.HasPrefix(output.String(), "- description: d\n  project: p\n") {
+		t.Errorf("Invalid YAML output: " + output.String())
+	}
+}
+
+func TestFailureResult(t *testing.T) {
+	r, _ := newResult("data|-|list|-|p", "Could not find project \n", false)
+	if r.ok || r.data != nil || r.message != "Could not find project" {
+		t.Errorf("Invalid failure result: %+v", r)
+	}
+}
+
+func TestUsersResultAsCSV(t *testing.T) {
+	r, err := newResult("data|-|users", `data|-|[{"username":"ivan","displayName":"Ivan Petrov","timezone":"Europe/Sofia","admin":true},{"username":"old","deactivated":true}]`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	r.write(&output, outputCSV)
+	expected := "USERNAME,NAME,EMAIL,TIMEZONE,ROLE,STATUS\nivan,Ivan Petrov,,Europe/Sofia,admin,active\nold,,,,user,deactivated\n"
+	if output.String() != expected {
+		t.Errorf("Invalid CSV output. Expected: " + expected + ", but got: " + output.String())
+	}
+}
+
+func TestClientsResultAsTable(t *testing.T) {
+	r, err := newResult("data|-|clients", `data|-|[{"id":3,"username":"ivan","remoteAddr":"10.0.0.1:5000","connected":"2021-01-05T10:30:00Z"}]`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	r.write(&output, outputTable)
+	lines := strings.Split(output.String(), "\n")
+	if !strings.HasPrefix(lines[0], "ID  USERNAME  ADDRESS") || !strings.HasPrefix(lines[1], "3   ivan      10.0.0.1:5000") {
+		t.Errorf("Invalid table output: " + output.String())
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Environment variables for passing a password to the client in scripts, where there is no terminal to type it in
+const (
+	// PasswordVariable holds the password itself
+	PasswordVariable = "ISSUETRACKER_PASSWORD"
+	// PasswordFDVariable holds the number of a file descriptor, from which the first line is read as the password
+	PasswordFDVariable = "ISSUETRACKER_PASSWORD_FD"
+	// NewPasswordVariable holds the new password when it is changed, while PasswordVariable holds the current one
+	NewPasswordVariable = "ISSUETRACKER_NEW_PASSWORD"
+)
+
+// readPassword reads a password from the environment if it is passed there, and otherwise from the terminal
+// without echoing it. When the input is not a terminal, the password is read as a line with the scanner.
+// Confirmation is asked for only when the password is typed in the terminal
+func readPassword(prompt string, confirm bool, scanner *bufio.Scanner) (string, error) {
+	return readSecret(PasswordVariable, PasswordFDVariable, prompt, confirm, scanner)
+}
+
+// readNewPassword reads a new password from the environment if it is passed there, and otherwise the same way
+// as readPassword, asking for confirmation in the terminal
+func readNewPassword(scanner *bufio.Scanner) (string, error) {
+	return readSecret(NewPasswordVariable, "", "New password: ", true, scanner)
+}
+
+// readSecret reads a password from an environment variable or from the file descriptor named by another one,
+// and otherwise from the terminal or with the scanner
+func readSecret(variable string, fdVariable string, prompt string, confirm bool, scanner *bufio.Scanner) (string, error) {
+	if password, ok := os.LookupEnv(variable); ok {
+		return strings.TrimSpace(password), nil
+	}
+
+	if fd := os.Getenv(fdVariable); fd != "" {
+		return readPasswordFD(fd)
+	}
+
+	stdin := int(os.Stdin.Fd())
+	if !term.IsTerminal(stdin) {
+		return strings.TrimSpace(readLine(scanner, prompt)), nil
+	}
+
+	password, err := readHidden(stdin, prompt)
+	if err != nil || !confirm {
+		return password, err
+	}
+
+	repeated, err := readHidden(stdin, "Repeat password: ")
+	if err != nil {
+		return "", err
+	}
+	if repeated != password {
+		return "", errors.New("Passwords do not match")
+	}
+
+	return password, nil
+}
+
+// readHidden reads a line from the terminal without echoing it
+func readHidden(fd int, prompt string) (string, error) {
+	if shell != nil {
+		password, err := shell.PasswordPrompt(prompt)
+		return strings.TrimSpace(password), err
+	}
+
+	fmt.Print(prompt)
+	password, err := term.ReadPassword(fd)
+	fmt.Println()
+
+	return strings.TrimSpace(string(password)), err
+}
+
+// readPasswordFD reads the first line from a file descriptor given by its number
+func readPasswordFD(fd string) (string, error) {
+	number, err := strconv.Atoi(fd)
+	if err != nil || number < 0 {
+		return "", errors.New("Invalid file descriptor in " + PasswordFDVariable + ": " + fd)
+	}
+
+	file := os.NewFile(uintptr(number), "password")
+	if file == nil {
+		return "", errors.New("Invalid file descriptor in " + PasswordFDVariable + ": " + fd)
+	}
+	defer file.Close()
+
+	line, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	return strings.TrimSpace(line), nil
+}
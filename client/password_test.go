@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestReadPasswordFromEnvironment(t *testing.T) {
+	t.Setenv(PasswordVariable, "secret")
+
+	password, err := readPassword("Password: ", true, bufio.NewScanner(strings.NewReader("other\n")))
+	if err != nil || password != "secret" {
+		t.Errorf("Expected the password from the environment, but got %q, %v", password, err)
+	}
+}
+
+func TestReadPasswordFromFileDescriptor(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.WriteString("secret\nnext line\n")
+	writer.Close()
+
+	t.Setenv(PasswordFDVariable, strconv.Itoa(int(reader.Fd())))
+
+	password, err := readPassword("Password: ", false, bufio.NewScanner(strings.NewReader("other\n")))
+	if err != nil || password != "secret" {
+		t.Errorf("Expected the password from the file descriptor, but got %q, %v", password, err)
+	}
+}
+
+func TestReadPasswordFromInvalidFileDescriptor(t *testing.T) {
+	t.Setenv(PasswordFDVariable, "stdin")
+
+	if _, err := readPassword("Password: ", false, bufio.NewScanner(strings.NewReader(""))); err == nil {
+		t.Errorf("Expected an error for an invalid file descriptor")
+	}
+}
+
+func TestReadNewPasswordFromEnvironment(t *testing.T) {
+	t.Setenv(PasswordVariable, "current")
+	t.Setenv(NewPasswordVariable, "changed")
+
+	password, err := readNewPassword(bufio.NewScanner(strings.NewReader("other\n")))
+	if err != nil || password != "changed" {
+		t.Errorf("Expected the new password from the environment, but got %q, %v", password, err)
+	}
+}
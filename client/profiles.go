@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultServerAddress is the address of the TCP server in the profile which is used when there is no configuration
+const defaultServerAddress = "0.0.0.0:9999"
+
+// defaultProfile is the name of the profile which is used when there is no configuration
+const defaultProfile = "default"
+
+// ProfileVariable is the environment variable selecting the profile, when it is not given with --profile
+const ProfileVariable = "ISSUETRACKER_PROFILE"
+
+// TokenVariable is the environment variable holding a personal access token, which is used instead of the stored
+// session, e.g. in CI
+const TokenVariable = "ISSUETRACKER_TOKEN"
+
+// profile is a server which the client connects to together with the session stored for it
+type profile struct {
+	Server   string `json:"server"`
+	Username string `json:"username,omitempty"`
+	Token    string `json:"token,omitempty"`
+}
+
+// sessionToken returns the token with which the session is resumed and whether it is an access token from the environment
+func (p profile) sessionToken() (string, bool) {
+	if secret := os.Getenv(TokenVariable); secret != "" {
+		return secret, true
+	}
+
+	return p.Token, false
+}
+
+// loggedUsername returns the username in the response to resuming a session
+func loggedUsername(message string) string {
+	return strings.TrimPrefix(message, "Login successful as ")
+}
+
+// clientConfig is stored in a file which only the current user can read, because it holds session tokens
+type clientConfig struct {
+	Current  string             `json:"current"`
+	Profiles map[string]profile `json:"profiles"`
+}
+
+// configPath returns the path of the client configuration file
+func configPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(configDir, "issuetracker", "config.json"), nil
+}
+
+// loadConfig reads the client configuration. Without a configuration file there is a single default profile
+func loadConfig() (*clientConfig, error) {
+	config := &clientConfig{
+		Current:  defaultProfile,
+		Profiles: map[string]profile{defaultProfile: {Server: defaultServerAddress}}}
+
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %v", path, err)
+	}
+	if config.Profiles == nil {
+		config.Profiles = make(map[string]profile)
+	}
+
+	return config, nil
+}
+
+// save writes the client configuration to a file which only the current user can read and write
+func (c *clientConfig) save() error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+
+	// The permissions are not changed by WriteFile when the file already exists
+	return os.Chmod(path, 0600)
+}
+
+// profile returns the profile with the given name, or the current one when the name is empty
+func (c *clientConfig) profile(name string) (string, profile, error) {
+	if name == "" {
+		name = c.Current
+	}
+
+	selected, ok := c.Profiles[name]
+	if !ok {
+		return name, selected, errors.New("There is no profile " + name)
+	}
+
+	return name, selected, nil
+}
+
+// rememberSession stores the session token of a user in a profile
+func (c *clientConfig) rememberSession(name string, username string, token string) error {
+	selected := c.Profiles[name]
+	selected.Username = username
+	selected.Token = token
+	c.Profiles[name] = selected
+
+	return c.save()
+}
+
+// forgetSession removes the session stored in a profile
+func (c *clientConfig) forgetSession(name string) error {
+	return c.rememberSession(name, "", "")
+}
+
+// describe lists the profiles, marking the current one
+func (c *clientConfig) describe() string {
+	var names []string
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		line := "  "
+		if name == c.Current {
+			line = "* "
+		}
+
+		line += name + " " + c.Profiles[name].Server
+		if c.Profiles[name].Username != "" {
+			line += " (logged in as " + c.Profiles[name].Username + ")"
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// selectProfile takes the --profile flag from the start of the arguments. Without it the profile is taken from
+// the environment, and an empty name means the current profile
+func selectProfile(args []string) (string, []string, error) {
+	if len(args) > 0 && strings.HasPrefix(args[0], "--profile=") {
+		return strings.TrimPrefix(args[0], "--profile="), args[1:], nil
+	}
+
+	if len(args) > 0 && args[0] == "--profile" {
+		if len(args) < 2 {
+			return "", nil, errors.New("missing name of the profile")
+		}
+		return args[1], args[2:], nil
+	}
+
+	return os.Getenv(ProfileVariable), args, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"strings"
+	"testing"
+)
+
+// fakeServer answers the requests of a client with canned responses and records the requests it gets
+func fakeServer(t *testing.T, responses map[string]string) (string, *[]string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var requests []string
+	go func() {
+		for {
+			con, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			reader := bufio.NewReader(con)
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil || strings.TrimSpace(line) == "disconnect" {
+					break
+				}
+
+				request := strings.TrimSpace(line)
+				requests = append(requests, request)
+				con.Write([]byte(responses[strings.Split(request, "|-|")[0]] + "\n"))
+			}
+			con.Close()
+		}
+	}()
+
+	return listener.Addr().String(), &requests
+}
+
+// useConfigDir points the client configuration to a temporary directory
+func useConfigDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+}
+
+func TestSelectProfile(t *testing.T) {
+	t.Setenv(ProfileVariable, "env")
+
+	cases := []struct {
+		args    []string
+		profile string
+		rest    int
+	}{
+		{[]string{"--profile", "work", "list"}, "work", 1},
+		{[]string{"--profile=work", "list"}, "work", 1},
+		{[]string{"list", "--profile", "work"}, "env", 3},
+	}
+
+	for _, c := range cases {
+		name, rest, err := selectProfile(c.args)
+		if err != nil || name != c.profile || len(rest) != c.rest {
+			t.Errorf("Invalid profile selection for %v: %s %v %v", c.args, name, rest, err)
+		}
+	}
+}
+
+func TestConfigIsPrivate(t *testing.T) {
+	useConfigDir(t)
+
+	config, _ := loadConfig()
+	if err := config.rememberSession(defaultProfile, "user", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	path, _ := configPath()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Invalid permissions of the configuration file: %v", info.Mode().Perm())
+	}
+
+	loaded, _ := loadConfig()
+	if loaded.Profiles[defaultProfile].Token != "secret" {
+		t.Errorf("The session was not stored")
+	}
+}
+
+func TestLoginStoresAndLogoutRevokesSession(t *testing.T) {
+	useConfigDir(t)
+	address, requests := fakeServer(t, map[string]string{
+		"login":  "Login successful as user",
+		"token":  "Token: secret",
+		"resume": "Login successful as user",
+		"logout": "Successfully logged out"})
+
+	config, _ := loadConfig()
+	config.Profiles["work"] = profile{Server: address}
+	config.save()
+
+	if code := runSubcommand("work", []string{"login", "--username", "user", "--password", "password"}); code != 0 {
+		t.Fatalf("Login failed with exit code %d", code)
+	}
+
+	config, _ = loadConfig()
+	if stored := config.Profiles["work"]; stored.Username != "user" || stored.Token != "secret" {
+		t.Fatalf("The session was not stored in the profile: %+v", stored)
+	}
+
+	if code := runSubcommand("work", []string{"logout"}); code != 0 {
+		t.Fatalf("Logout failed with exit code %d", code)
+	}
+
+	config, _ = loadConfig()
+	if config.Profiles["work"].Token != "" {
+		t.Errorf("The session was not forgotten")
+	}
+
+	expected := "login|-|user|-|password,token,resume|-|secret,logout"
+	if got := strings.Join(*requests, ","); got != expected {
+		t.Errorf("Invalid requests. Expected: " + expected + ", but got: " + got)
+	}
+}
+
+func TestSubcommandWithoutSession(t *testing.T) {
+	useConfigDir(t)
+
+	if code := runSubcommand("", []string{"list", "--project", "X"}); code != 1 {
+		t.Errorf("Expected exit code 1 without a stored session, but got %d", code)
+	}
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.fmi/issuetracker/issue"
+	"go.fmi/issuetracker/project"
+	"go.fmi/issuetracker/protocol"
+
+	"github.com/peterh/liner"
+)
+
+// shell edits the lines typed in the interactive client, nil when the client runs a single command
+var shell *liner.State
+
+// cookedMode is the mode of the terminal before the shell was opened, which other programs, e.g. the editor, expect
+var cookedMode liner.ModeApplier
+
+// inlineArgs are the arguments typed after the name of a command, which are taken instead of prompting for its fields
+var inlineArgs []string
+
+// argumentKind is what an argument of a command names, which determines how it is completed
+type argumentKind int
+
+const (
+	otherArgument argumentKind = iota
+	projectArgument
+	issueArgument
+)
+
+// shellCommands are the commands of the interactive client with the kinds of their arguments, in the order
+// in which they are prompted for
+var shellCommands = map[string][]argumentKind{
+	"disconnect":         nil,
+	"login":              {otherArgument},
+	"logout":             nil,
+	"register":           {otherArgument},
+	"passwd":             nil,
+	"password reset":     {otherArgument},
+	"password set":       {otherArgument, otherArgument},
+	"project":            {otherArgument},
+	"projects":           nil,
+	"issue":              {projectArgument, otherArgument, otherArgument},
+	"resolve":            {projectArgument, issueArgument},
+	"assign":             {projectArgument, issueArgument, otherArgument},
+	"label":              {projectArgument, issueArgument, otherArgument},
+	"watch":              {projectArgument, issueArgument},
+	"unwatch":            {projectArgument, issueArgument},
+	"list":               {projectArgument},
+	"find":               {projectArgument, issueArgument},
+	"comment":            {projectArgument, issueArgument, otherArgument},
+	"comment reply":      {projectArgument, issueArgument, otherArgument, otherArgument},
+	"comment edit":       {otherArgument, otherArgument},
+	"comment delete":     {otherArgument},
+	"inbox":              nil,
+	"inbox unread":       nil,
+	"inbox read":         {otherArgument},
+	"webhook add":        {projectArgument, otherArgument, otherArgument},
+	"webhook remove":     {otherArgument},
+	"webhook list":       {projectArgument},
+	"webhook deliveries": {projectArgument},
+	"email":              {otherArgument, otherArgument, otherArgument},
+	"audit":              {otherArgument, otherArgument, otherArgument, otherArgument},
+	"unlock":             {otherArgument, otherArgument},
+	"whoami":             nil,
+	"users":              nil,
+	"user show":          {otherArgument},
+	"user edit":          {otherArgument, otherArgument, otherArgument},
+	"user deactivate":    {otherArgument},
+	"user activate":      {otherArgument},
+	"project delete":     {projectArgument},
+	"admin grant":        {otherArgument},
+	"admin revoke":       {otherArgument},
+	"admin stats":        nil,
+	"admin clients":      nil,
+	"admin kick":         {otherArgument},
+	"token create":       {otherArgument, otherArgument, otherArgument, otherArgument},
+	"token list":         {otherArgument},
+	"token revoke":       {otherArgument},
+	"service create":     {otherArgument},
+}
+
+// historyPath returns the path of the file keeping the lines typed in the shell, next to the configuration
+func historyPath() (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(path), "history"), nil
+}
+
+// openShell takes over the terminal for editing lines, loads the history and completes the lines using
+// the data fetched from the server. The returned function saves the history and restores the terminal
+func openShell(fetch func(request string) (string, bool, error)) func() {
+	cookedMode, _ = liner.TerminalMode()
+	shell = liner.NewLiner()
+	shell.SetCtrlCAborts(true)
+	shell.SetTabCompletionStyle(liner.TabPrints)
+	shell.SetWordCompleter(completer{fetch}.complete)
+
+	path, err := historyPath()
+	if err == nil {
+		if file, err := os.Open(path); err == nil {
+			shell.ReadHistory(file)
+			file.Close()
+		}
+	}
+
+	return func() {
+		if path != "" && os.MkdirAll(filepath.Dir(path), 0700) == nil {
+			if file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600); err == nil {
+				shell.WriteHistory(file)
+				file.Close()
+			}
+		}
+
+		shell.Close()
+		shell, cookedMode = nil, nil
+	}
+}
+
+// runCooked runs a function, e.g. an editor, with the terminal in the mode it had before the shell was opened
+func runCooked(f func() error) error {
+	if shell == nil || cookedMode == nil {
+		return f()
+	}
+
+	shellMode, err := liner.TerminalMode()
+	if err != nil {
+		return f()
+	}
+
+	cookedMode.ApplyMode()
+	defer shellMode.ApplyMode()
+	return f()
+}
+
+// readLine prompts for a line in the shell, or reads it with the scanner when the shell isn't open
+func readLine(scanner *bufio.Scanner, prompt string) string {
+	if shell != nil {
+		line, _ := shell.Prompt(prompt)
+		return line
+	}
+
+	fmt.Print(prompt)
+	if scanner.Scan() {
+		return scanner.Text()
+	}
+
+	return ""
+}
+
+// readField returns the next argument typed after the name of a command, and prompts for the field when
+// there isn't one
+func readField(scanner *bufio.Scanner, prompt string) string {
+	if len(inlineArgs) > 0 {
+		arg := inlineArgs[0]
+		inlineArgs = inlineArgs[1:]
+		return arg
+	}
+
+	return readLine(scanner, prompt)
+}
+
+// parseShellLine splits a line typed in the shell into the name of a command and its arguments,
+// e.g. 'find MyProj "Login crash"'
+func parseShellLine(line string) (string, []string, error) {
+	words, unterminated := scanWords(line)
+	if unterminated {
+		return "", nil, errors.New("Unterminated quote")
+	}
+	if len(words) == 0 {
+		return "", nil, nil
+	}
+
+	name, args := words[0].text, texts(words[1:])
+	if len(words) > 1 {
+		if _, ok := shellCommands[name+" "+words[1].text]; ok {
+			name, args = name+" "+words[1].text, args[1:]
+		}
+	}
+
+	if kinds, ok := shellCommands[name]; ok && len(args) > len(kinds) {
+		return "", nil, fmt.Errorf("Too many arguments - %s takes at most %d", name, len(kinds))
+	}
+
+	return name, args, nil
+}
+
+// word is a word of a line typed in the shell, with the offset at which it starts
+type word struct {
+	text  string
+	start int
+}
+
+// scanWords splits a line into words separated by whitespace. Quotes group words with whitespace in them and
+// a backslash escapes the next character outside single quotes. It also reports whether the last quote is open
+func scanWords(line string) ([]word, bool) {
+	var words []word
+	var current strings.Builder
+	inWord, escaped := false, false
+	var quote rune
+	start := 0
+
+	for i, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word{current.String(), start})
+				current.Reset()
+				inWord = false
+			}
+			continue
+		default:
+			current.WriteRune(r)
+		}
+
+		if !inWord {
+			inWord, start = true, i
+		}
+	}
+
+	if inWord {
+		words = append(words, word{current.String(), start})
+	}
+
+	return words, quote != 0
+}
+
+// texts returns the texts of words
+func texts(words []word) []string {
+	result := make([]string, len(words))
+	for i, w := range words {
+		result[i] = w.text
+	}
+
+	return result
+}
+
+// quoteWord quotes a completed word if it can't be typed as it is
+func quoteWord(text string) string {
+	if text == "" || strings.ContainsAny(text, " \t\"'\\") {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text) + `"`
+	}
+
+	return text
+}
+
+// completer completes the names of commands, and the names of projects and the titles of issues fetched from the server
+type completer struct {
+	fetch func(request string) (string, bool, error)
+}
+
+// complete returns the candidates for the word before the cursor, given in runes
+func (c completer) complete(line string, pos int) (string, []string, string) {
+	runes := []rune(line)
+	before, tail := string(runes[:pos]), string(runes[pos:])
+
+	words, unterminated := scanWords(before)
+	current := word{start: len(before)}
+	if len(words) > 0 && (unterminated || !strings.HasSuffix(before, " ") && !strings.HasSuffix(before, "\t")) {
+		current, words = words[len(words)-1], words[:len(words)-1]
+	}
+
+	var candidates []string
+	switch len(words) {
+	case 0:
+		candidates = commandWords("")
+	case 1:
+		candidates = commandWords(words[0].text)
+	}
+
+	if name, args, ok := completedCommand(texts(words)); ok {
+		candidates = append(candidates, c.arguments(shellCommands[name], args)...)
+	}
+
+	var completions []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, current.text) {
+			completions = append(completions, quoteWord(candidate))
+		}
+	}
+	sort.Strings(completions)
+
+	return before[:current.start], completions, tail
+}
+
+// commandWords returns the first words of the names of commands, or their second words after a first word
+func commandWords(first string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for name := range shellCommands {
+		parts := strings.SplitN(name, " ", 2)
+		candidate := parts[0]
+		if first != "" {
+			if len(parts) < 2 || parts[0] != first {
+				continue
+			}
+			candidate = parts[1]
+		}
+
+		if !seen[candidate] {
+			seen[candidate] = true
+			result = append(result, candidate)
+		}
+	}
+
+	return result
+}
+
+// completedCommand returns the command named by the first words and its arguments typed so far
+func completedCommand(words []string) (string, []string, bool) {
+	if len(words) >= 2 {
+		if _, ok := shellCommands[words[0]+" "+words[1]]; ok {
+			return words[0] + " " + words[1], words[2:], true
+		}
+	}
+	if len(words) >= 1 {
+		if _, ok := shellCommands[words[0]]; ok {
+			return words[0], words[1:], true
+		}
+	}
+
+	return "", nil, false
+}
+
+// arguments returns the candidates for the next argument of a command, given the arguments typed before it
+func (c completer) arguments(kinds []argumentKind, args []string) []string {
+	if len(args) >= len(kinds) {
+		return nil
+	}
+
+	switch kinds[len(args)] {
+	case projectArgument:
+		var projects []project.Project
+		if !c.fetchData(protocol.Request("projects"), &projects) {
+			return nil
+		}
+
+		names := make([]string, len(projects))
+		for i, p := range projects {
+			names[i] = p.Name
+		}
+		return names
+	case issueArgument:
+		// The issue belongs to the project named by the closest argument before it
+		for i := len(args) - 1; i >= 0; i-- {
+			if kinds[i] == projectArgument {
+				return c.issueTitles(args[i])
+			}
+		}
+	}
+
+	return nil
+}
+
+// issueTitles returns the titles of the issues in a project
+func (c completer) issueTitles(projectName string) []string {
+	var issues []issue.Issue
+	if !c.fetchData(protocol.Request("list", projectName), &issues) {
+		return nil
+	}
+
+	titles := make([]string, len(issues))
+	for i, is := range issues {
+		titles[i] = is.Title
+	}
+	return titles
+}
+
+// fetchData sends a data request to the server and decodes the data in its response
+func (c completer) fetchData(request string, data interface{}) bool {
+	if c.fetch == nil {
+		return false
+	}
+
+	message, ok, err := c.fetch(protocol.DataRequest(request))
+	if err != nil || !ok {
+		return false
+	}
+
+	encoded, isData := protocol.Data(message)
+	return isData && json.Unmarshal(encoded, data) == nil
+}
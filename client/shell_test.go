@@ -0,0 +1,123 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"go.fmi/issuetracker/protocol"
+)
+
+func TestParseShellLine(t *testing.T) {
+	name, args, err := parseShellLine(`find MyProj "Login crash"`)
+	if err != nil || name != "find" || !reflect.DeepEqual(args, []string{"MyProj", "Login crash"}) {
+		t.Errorf("Invalid parsed line %q, %q, %v", name, args, err)
+	}
+
+	name, args, err = parseShellLine(`comment reply p 'it''s' 3 "say \"hi\""`)
+	if err != nil || name != "comment reply" || !reflect.DeepEqual(args, []string{"p", "its", "3", `say "hi"`}) {
+		t.Errorf("Invalid parsed line %q, %q, %v", name, args, err)
+	}
+}
+
+func TestParseShellLineErrors(t *testing.T) {
+	if _, _, err := parseShellLine(`find p "Login crash`); err == nil {
+		t.Errorf("Expected an error for an unterminated quote")
+	}
+
+	if _, _, err := parseShellLine("list p q"); err == nil {
+		t.Errorf("Expected an error for too many arguments")
+	}
+}
+
+func TestShellCommandsAreConstructed(t *testing.T) {
+	defer func() { LoggedUser = "" }()
+
+	for name := range shellCommands {
+		LoggedUser = ""
+		if name == "login" || name == "register" {
+			LoggedUser = "test"
+		}
+
+		if command, _ := constructCommand(name); command == "Invallid command" {
+			t.Errorf("The shell command %s is not constructed", name)
+		}
+	}
+}
+
+func TestInlineArguments(t *testing.T) {
+	LoggedUser = "test"
+	defer func() { LoggedUser = "" }()
+
+	inlineArgs = []string{"MyProj", "Login crash"}
+	command, ok := constructCommand("find")
+	if expected := protocol.DataRequest(protocol.Request("find", "MyProj", "Login crash")); !ok || command != expected {
+		t.Errorf("Invalid command. Expected: " + expected + ", but got: " + command)
+	}
+	if len(inlineArgs) != 0 {
+		t.Errorf("The inline arguments were not used")
+	}
+}
+
+func TestInlineArgumentsAreEscaped(t *testing.T) {
+	LoggedUser = "test"
+	defer func() { LoggedUser = "" }()
+
+	inlineArgs = []string{"My|-|Proj", `C:\crash`, "ivan"}
+	command, ok := constructCommand("assign")
+	if expected := protocol.Request("assign", "My|-|Proj", `C:\crash`, "ivan"); !ok || command != expected {
+		t.Errorf("Invalid command. Expected: " + expected + ", but got: " + command)
+	}
+
+	LoggedUser = ""
+	t.Setenv(PasswordVariable, `pass\word|-|`)
+	inlineArgs = []string{"ivan"}
+	command, ok = constructCommand("login")
+	if expected := protocol.Request("login", "ivan", `pass\word|-|`); !ok || command != expected {
+		t.Errorf("The password should be sent as the login subcommand sends it. Expected: " + expected + ", but got: " + command)
+	}
+}
+
+// fakeFetch answers the data requests of the completer
+func fakeFetch(request string) (string, bool, error) {
+	switch request {
+	case protocol.DataRequest(protocol.Request("projects")):
+		return protocol.DataPrefix + `[{"name":"MyProj"},{"name":"Mobile"},{"name":"Web"}]`, true, nil
+	case protocol.DataRequest(protocol.Request("list", "MyProj")):
+		return protocol.DataPrefix + `[{"title":"Login crash"},{"title":"Logout"}]`, true, nil
+	}
+
+	return "Could not find project", false, nil
+}
+
+func TestCompleteCommands(t *testing.T) {
+	c := completer{fakeFetch}
+
+	if head, completions, tail := c.complete("co", 2); head != "" || !reflect.DeepEqual(completions, []string{"comment"}) || tail != "" {
+		t.Errorf("Invalid completion %q, %q, %q", head, completions, tail)
+	}
+
+	if head, completions, _ := c.complete("inbox r", 7); head != "inbox " || !reflect.DeepEqual(completions, []string{"read"}) {
+		t.Errorf("Invalid completion %q, %q", head, completions)
+	}
+}
+
+func TestCompleteProjectsAndIssues(t *testing.T) {
+	c := completer{fakeFetch}
+
+	if head, completions, _ := c.complete("find M", 6); head != "find " || !reflect.DeepEqual(completions, []string{"Mobile", "MyProj"}) {
+		t.Errorf("Invalid completion %q, %q", head, completions)
+	}
+
+	if head, completions, tail := c.complete("find MyProj Log", 15); head != "find MyProj " || tail != "" ||
+		!reflect.DeepEqual(completions, []string{`"Login crash"`, "Logout"}) {
+		t.Errorf("Invalid completion %q, %q, %q", head, completions, tail)
+	}
+
+	if _, completions, _ := c.complete(`comment reply MyProj "Login c`, 29); !reflect.DeepEqual(completions, []string{`"Login crash"`}) {
+		t.Errorf("Invalid completion %q", completions)
+	}
+
+	if _, completions, _ := c.complete("find Unknown ", 13); len(completions) != 0 {
+		t.Errorf("Expected no completions for an unknown project, but got %q", completions)
+	}
+}
@@ -0,0 +1,564 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"go.fmi/issuetracker/protocol"
+	"go.fmi/issuetracker/push"
+)
+
+// option is a flag of a subcommand, whose value becomes a field of the request sent to the server
+type option struct {
+	name     string
+	usage    string
+	required bool
+}
+
+// sessionEffect is the effect of a subcommand on the session stored in the profile
+type sessionEffect int
+
+const (
+	// usesSession subcommands resume the stored session before their request
+	usesSession sessionEffect = iota
+	// startsSession subcommands don't need a stored session and store the one they start
+	startsSession
+	// endsSession subcommands forget the stored session once it is ended on the server
+	endsSession
+	// noSession subcommands neither need a stored session nor change it
+	noSession
+)
+
+// resumes checks whether a subcommand resumes the stored session before its request
+func (e sessionEffect) resumes() bool {
+	return e == usesSession || e == endsSession
+}
+
+// subcommand is a command which is run once from the command line, e.g. 'client issue create --project X'
+type subcommand struct {
+	name    string
+	summary string
+	options []option
+	session sessionEffect
+	// request builds the request for the server from the flag values and the name of the logged in user
+	request func(values map[string]string, username string) string
+	// local subcommands change the configuration of the client instead of sending a request
+	local func(values map[string]string, config *clientConfig) (string, error)
+	// run takes over the terminal for the whole subcommand and returns the exit code of the client
+	run func(profileName string) int
+}
+
+// passwordUsage describes the password flag, which is better left out, because the arguments of a process are visible to other users
+const passwordUsage = "password - prefer typing it when prompted or passing it in " + PasswordVariable + " or " + PasswordFDVariable
+
+// textUsage describes the flags holding multi-line markdown texts
+const textUsage = "'-' to write it in the editor or, when the input is not a terminal, to read it from the input"
+
+// textTemplates are the templates of the flags holding multi-line texts, which can be given as '-'
+var textTemplates = map[string]string{"description": descriptionTemplate, "content": commentTemplate}
+
+var subcommands = []subcommand{
+	{name: "register", summary: "Register a user and store their session", session: startsSession,
+		options: []option{{"username", "username", true}, {"password", passwordUsage, false}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("register", v["username"], v["password"])
+		}},
+	{name: "login", summary: "Log in and store the session for the next runs", session: startsSession,
+		options: []option{{"username", "username", true}, {"password", passwordUsage, false}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("login", v["username"], v["password"])
+		}},
+	{name: "logout", summary: "Log out and revoke the stored session", session: endsSession,
+		request: func(map[string]string, string) string {
+			return protocol.Request("logout")
+		}},
+	{name: "passwd", summary: "Change the password, end the other sessions and revoke the personal access tokens",
+		options: []option{{"current-password", passwordUsage, false}, {"new-password", "new password - prefer typing it when prompted or passing it in " + NewPasswordVariable, false}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("passwd", v["current-password"], v["new-password"])
+		}},
+	{name: "password reset", summary: "Issue a one-time token for setting the password of a user (administrators only)",
+		options: []option{{"username", "username", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("resetpassword", v["username"])
+		}},
+	{name: "password set", summary: "Set a new password with a reset token, ending all sessions and revoking the personal access tokens", session: noSession,
+		options: []option{{"username", "username", true}, {"token", "reset token issued by an administrator", true}, {"new-password", "new password - prefer typing it when prompted or passing it in " + NewPasswordVariable, false}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("setpassword", v["username"], v["token"], v["new-password"])
+		}},
+	{name: "profile list", summary: "List the server profiles",
+		local: func(_ map[string]string, config *clientConfig) (string, error) {
+			return config.describe(), nil
+		}},
+	{name: "profile add", summary: "Add a server profile",
+		options: []option{{"name", "profile name", true}, {"server", "address of the server as host:port", true}},
+		local: func(v map[string]string, config *clientConfig) (string, error) {
+			if _, ok := config.Profiles[v["name"]]; ok {
+				return "", errors.New("Profile " + v["name"] + " already exists")
+			}
+			config.Profiles[v["name"]] = profile{Server: v["server"]}
+			return "Profile added", config.save()
+		}},
+	{name: "profile use", summary: "Make a server profile the current one",
+		options: []option{{"name", "profile name", true}},
+		local: func(v map[string]string, config *clientConfig) (string, error) {
+			if _, ok := config.Profiles[v["name"]]; !ok {
+				return "", errors.New("There is no profile " + v["name"])
+			}
+			config.Current = v["name"]
+			return "Using profile " + v["name"], config.save()
+		}},
+	{name: "profile remove", summary: "Remove a server profile together with its session",
+		options: []option{{"name", "profile name", true}},
+		local: func(v map[string]string, config *clientConfig) (string, error) {
+			if v["name"] == config.Current {
+				return "", errors.New("The current profile can't be removed")
+			}
+			if _, ok := config.Profiles[v["name"]]; !ok {
+				return "", errors.New("There is no profile " + v["name"])
+			}
+			delete(config.Profiles, v["name"])
+			return "Profile removed", config.save()
+		}},
+	{name: "tui", summary: "Triage the issues in a full-screen terminal UI", run: runTUI},
+	{name: "project create", summary: "Create a project",
+		options: []option{{"name", "project name", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("project", v["name"])
+		}},
+	{name: "project list", summary: "List the projects",
+		request: func(_ map[string]string, _ string) string {
+			return protocol.DataRequest(protocol.Request("projects"))
+		}},
+	{name: "project delete", summary: "Delete a project with its issues, comments and webhooks",
+		options: []option{{"name", "project name", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("deleteproject", v["name"])
+		}},
+	{name: "issue create", summary: "Create an issue",
+		options: []option{{"project", "project name", true}, {"title", "issue title", true}, {"description", "issue description - " + textUsage, false}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("issue", v["project"], v["title"], v["description"], "false")
+		}},
+	{name: "issue resolve", summary: "Resolve an issue",
+		options: []option{{"project", "project name", true}, {"title", "issue title", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("resolve", v["project"], v["title"])
+		}},
+	{name: "issue assign", summary: "Assign an issue to a user",
+		options: []option{{"project", "project name", true}, {"title", "issue title", true}, {"assignee", "username of the assignee", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("assign", v["project"], v["title"], v["assignee"])
+		}},
+	{name: "issue label", summary: "Replace the labels of an issue",
+		options: []option{{"project", "project name", true}, {"title", "issue title", true}, {"labels", "labels separated by commas, empty to remove all", false}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("label", v["project"], v["title"], v["labels"])
+		}},
+	{name: "list", summary: "List the issues in a project",
+		options: []option{{"project", "project name", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.DataRequest(protocol.Request("list", v["project"]))
+		}},
+	{name: "find", summary: "Show an issue with its comments",
+		options: []option{{"project", "project name", true}, {"title", "issue title", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.DataRequest(protocol.Request("find", v["project"], v["title"]))
+		}},
+	{name: "comment add", summary: "Comment an issue or reply to a comment",
+		options: []option{{"project", "project name", true}, {"title", "issue title", true}, {"content", "comment - " + textUsage, true}, {"parent", "number of the comment which is replied to", false}},
+		request: func(v map[string]string, username string) string {
+			return protocol.Request("comment", v["project"], v["title"], v["content"], username, v["parent"])
+		}},
+	{name: "comment edit", summary: "Edit a comment",
+		options: []option{{"id", "comment number", true}, {"content", "new comment - " + textUsage, true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("editcomment", v["id"], v["content"])
+		}},
+	{name: "comment delete", summary: "Delete a comment",
+		options: []option{{"id", "comment number", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("deletecomment", v["id"])
+		}},
+	{name: "watch", summary: "Watch an issue or a whole project",
+		options: []option{{"project", "project name", true}, {"title", "issue title, empty for the whole project", false}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("watch", v["project"], v["title"])
+		}},
+	{name: "unwatch", summary: "Stop watching an issue or a whole project",
+		options: []option{{"project", "project name", true}, {"title", "issue title, empty for the whole project", false}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("unwatch", v["project"], v["title"])
+		}},
+	{name: "inbox", summary: "Show all notifications",
+		request: func(map[string]string, string) string {
+			return protocol.Request("inbox", "")
+		}},
+	{name: "inbox unread", summary: "Show the unread notifications",
+		request: func(map[string]string, string) string {
+			return protocol.Request("inbox", "unread")
+		}},
+	{name: "inbox read", summary: "Mark notifications as read",
+		options: []option{{"id", "notification number, empty for all", false}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("markread", v["id"])
+		}},
+	{name: "webhook add", summary: "Register a webhook for a project",
+		options: []option{{"project", "project name", true}, {"url", "URL of the webhook", true}, {"secret", "secret for signing the payloads", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("addwebhook", v["project"], v["url"], v["secret"])
+		}},
+	{name: "webhook remove", summary: "Remove a webhook",
+		options: []option{{"id", "webhook number", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("removewebhook", v["id"])
+		}},
+	{name: "webhook list", summary: "List the webhooks of a project",
+		options: []option{{"project", "project name", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("webhooks", v["project"])
+		}},
+	{name: "webhook deliveries", summary: "Show the last deliveries to the webhooks of a project",
+		options: []option{{"project", "project name", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("deliveries", v["project"])
+		}},
+	{name: "email", summary: "Set up email notifications",
+		options: []option{{"address", "email address", false}, {"mode", "off, instant or digest", true}, {"kinds", "mentions, assignments and status, empty for all", false}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("email", v["address"], v["mode"], v["kinds"])
+		}},
+	{name: "audit", summary: "Show the audit log",
+		options: []option{{"user", "username", false}, {"action", "action", false}, {"from", "start date as YYYY-MM-DD", false}, {"to", "end date as YYYY-MM-DD", false}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("audit", v["user"], v["action"], v["from"], v["to"])
+		}},
+	{name: "unlock", summary: "Forget the failed logins to an account or from an address, ending their lockout",
+		options: []option{{"user", "username", false}, {"address", "remote address", false}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("unlock", v["user"], v["address"])
+		}},
+	{name: "whoami", summary: "Show the profile of the logged in user",
+		request: func(_ map[string]string, username string) string {
+			return protocol.DataRequest(protocol.Request("user", username))
+		}},
+	{name: "users", summary: "List the users",
+		request: func(map[string]string, string) string {
+			return protocol.DataRequest(protocol.Request("users"))
+		}},
+	{name: "user show", summary: "Show the profile of a user",
+		options: []option{{"user", "username", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.DataRequest(protocol.Request("user", v["user"]))
+		}},
+	{name: "user edit", summary: "Replace the profile of the logged in user, empty fields are cleared",
+		options: []option{{"name", "display name", false}, {"email", "email address", false}, {"timezone", "timezone, e.g. Europe/Sofia", false}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("profile", v["name"], v["email"], v["timezone"])
+		}},
+	{name: "user deactivate", summary: "Keep a user from logging in and revoke their sessions and personal access tokens, keeping their issues and comments",
+		options: []option{{"user", "username", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("deactivate", v["user"])
+		}},
+	{name: "user activate", summary: "Let a deactivated user log in again",
+		options: []option{{"user", "username", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("activate", v["user"])
+		}},
+	{name: "admin grant", summary: "Give a user administrator rights",
+		options: []option{{"user", "username", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("grantadmin", v["user"])
+		}},
+	{name: "admin revoke", summary: "Take the administrator rights of a user",
+		options: []option{{"user", "username", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("revokeadmin", v["user"])
+		}},
+	{name: "admin stats", summary: "Show the statistics of the server",
+		request: func(map[string]string, string) string {
+			return protocol.DataRequest(protocol.Request("stats"))
+		}},
+	{name: "admin clients", summary: "List the clients connected to the server",
+		request: func(map[string]string, string) string {
+			return protocol.DataRequest(protocol.Request("clients"))
+		}},
+	{name: "admin kick", summary: "Disconnect a client from the server",
+		options: []option{{"id", "client number from admin clients", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("kick", v["id"])
+		}},
+	{name: "token create", summary: "Create a personal access token, e.g. for CI",
+		options: []option{{"name", "token name", true}, {"scopes", "read, write and admin separated by commas", true},
+			{"days", "how many days the token is valid (default 90)", false}, {"user", "owner of the token (default yourself)", false}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("createtoken", v["user"], v["name"], v["scopes"], v["days"])
+		}},
+	{name: "token list", summary: "List personal access tokens",
+		options: []option{{"user", "owner of the tokens (default yourself)", false}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.DataRequest(protocol.Request("tokens", v["user"]))
+		}},
+	{name: "token revoke", summary: "Revoke a personal access token",
+		options: []option{{"id", "token number", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("revoketoken", v["id"])
+		}},
+	{name: "service create", summary: "Create a service account, which authenticates only with access tokens",
+		options: []option{{"name", "username of the account", true}},
+		request: func(v map[string]string, _ string) string {
+			return protocol.Request("serviceaccount", v["name"])
+		}},
+}
+
+// findSubcommand looks up the subcommand named by the first one or two arguments and returns the remaining arguments
+func findSubcommand(args []string) (*subcommand, []string, bool) {
+	for _, words := range []int{2, 1} {
+		if len(args) < words {
+			continue
+		}
+
+		name := strings.Join(args[:words], " ")
+		for i := range subcommands {
+			if subcommands[i].name == name {
+				return &subcommands[i], args[words:], true
+			}
+		}
+	}
+
+	return nil, args, false
+}
+
+// parseOptions parses the flags of a subcommand and returns their values and the requested output format
+func parseOptions(sc *subcommand, args []string, output io.Writer) (map[string]string, string, error) {
+	flags := flag.NewFlagSet(sc.name, flag.ContinueOnError)
+	flags.SetOutput(output)
+
+	pointers := make(map[string]*string)
+	for _, o := range sc.options {
+		pointers[o.name] = flags.String(o.name, "", o.usage)
+	}
+	format := flags.String("output", outputTable, "output format: table, json, csv or yaml")
+	asJSON := flags.Bool("json", false, "shorthand for --output json")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, "", err
+	}
+	if flags.NArg() > 0 {
+		return nil, "", errors.New("unexpected argument " + flags.Arg(0))
+	}
+	if *asJSON {
+		*format = outputJSON
+	}
+	if !validOutput(*format) {
+		return nil, "", errors.New("unknown output format " + *format)
+	}
+
+	values := make(map[string]string)
+	for _, o := range sc.options {
+		values[o.name] = strings.TrimSpace(*pointers[o.name])
+		if o.required && values[o.name] == "" {
+			return nil, "", errors.New("missing --" + o.name)
+		}
+	}
+
+	return values, *format, nil
+}
+
+// printUsage lists the subcommands
+func printUsage(output io.Writer) {
+	fmt.Fprintln(output, "Usage: client [--profile <name>] [<command> [flags]]")
+	fmt.Fprintln(output, "Without a command the client starts an interactive session. Commands:")
+	for _, sc := range subcommands {
+		fmt.Fprintf(output, "  %-20s %s\n", sc.name, sc.summary)
+	}
+	fmt.Fprintln(output, "Run 'client <command> -h' for the flags of a command")
+}
+
+// runSubcommand runs a single command given on the command line and returns the exit code of the client
+func runSubcommand(profileName string, args []string) int {
+	sc, rest, ok := findSubcommand(args)
+	if !ok {
+		printUsage(os.Stderr)
+		return 2
+	}
+
+	values, format, err := parseOptions(sc, rest, os.Stderr)
+	if err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return 2
+	}
+
+	if sc.run != nil {
+		return sc.run(profileName)
+	}
+
+	config, err := loadConfig()
+	if err != nil {
+		return report("", "", false, err, format)
+	}
+
+	if err := readPasswordOptions(sc, values, bufio.NewScanner(os.Stdin)); err != nil {
+		return report("", "", false, err, format)
+	}
+
+	for name, template := range textTemplates {
+		if values[name] == "-" {
+			if values[name], err = readTextOption(template, os.Stdin); err != nil {
+				return report("", "", false, err, format)
+			}
+		}
+	}
+
+	if sc.local != nil {
+		message, err := sc.local(values, config)
+		return report("", message, err == nil, err, format)
+	}
+
+	name, selected, err := config.profile(profileName)
+	if err != nil {
+		return report("", "", false, err, format)
+	}
+	secret, _ := selected.sessionToken()
+	if sc.session.resumes() && secret == "" {
+		return report("", "You are not logged in - run 'client login' first", false, nil, format)
+	}
+
+	exchange, disconnect, err := dial(selected.Server)
+	if err != nil {
+		return report("", "", false, err, format)
+	}
+	defer disconnect()
+
+	username := selected.Username
+	if sc.session.resumes() {
+		message, ok, err := exchange(protocol.Request("resume", secret))
+		if err != nil || !ok {
+			return report("", message, false, err, format)
+		}
+		username = loggedUsername(message)
+	}
+
+	request := sc.request(values, username)
+	message, ok, err := exchange(request)
+	if err == nil && ok {
+		switch sc.session {
+		case startsSession:
+			err = storeSession(exchange, config, name, values["username"])
+		case endsSession:
+			err = config.forgetSession(name)
+		}
+	}
+
+	return report(request, message, ok, err, format)
+}
+
+// readPasswordOptions reads the passwords left out of the flags of a subcommand, which is safer than passing them there
+func readPasswordOptions(sc *subcommand, values map[string]string, scanner *bufio.Scanner) error {
+	var err error
+	for _, o := range sc.options {
+		if values[o.name] != "" {
+			continue
+		}
+
+		switch o.name {
+		case "password":
+			values[o.name], err = readPassword("Password: ", sc.name == "register", scanner)
+		case "current-password":
+			values[o.name], err = readPassword("Current password: ", false, scanner)
+		case "new-password":
+			values[o.name], err = readNewPassword(scanner)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dial connects to a server and returns the function exchanging a request for its response, skipping the pushed
+// events, and the function which ends the connection
+func dial(server string) (func(string) (string, bool, error), func(), error) {
+	con, err := net.Dial("tcp", server)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serverReader := bufio.NewReader(con)
+	exchange := func(request string) (string, bool, error) {
+		if _, err := con.Write([]byte(request + "\n")); err != nil {
+			return "", false, err
+		}
+		return readResponse(serverReader)
+	}
+	disconnect := func() {
+		con.Write([]byte("disconnect\n"))
+		con.Close()
+	}
+
+	return exchange, disconnect, nil
+}
+
+// storeSession asks the server for a session token for the user who has just logged in and stores it in a profile
+func storeSession(exchange func(string) (string, bool, error), config *clientConfig, name string, username string) error {
+	message, ok, err := exchange(protocol.Request("token"))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New(strings.TrimSpace(message))
+	}
+
+	return config.rememberSession(name, username, strings.TrimSpace(strings.TrimPrefix(message, "Token: ")))
+}
+
+// readResponse reads the response to the last request, skipping the events pushed in the meantime
+func readResponse(serverReader *bufio.Reader) (string, bool, error) {
+	for {
+		line, err := serverReader.ReadString('\n')
+		if err != nil {
+			return "", false, err
+		}
+
+		if !strings.HasPrefix(line, push.EventPrefix) {
+			message, ok := protocol.ParseResponse(line)
+			return message, ok, nil
+		}
+	}
+}
+
+// report prints the outcome of a request in an output format and returns the exit code of the client
+func report(request string, message string, ok bool, err error, format string) int {
+	var r result
+	if err == nil {
+		r, err = newResult(request, message, ok)
+	}
+	if err != nil {
+		r = result{message: err.Error()}
+	}
+
+	output := os.Stdout
+	if !r.ok && format == outputTable {
+		output = os.Stderr
+	}
+	if err := r.write(output, format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if !r.ok {
+		return 1
+	}
+	return 0
+}
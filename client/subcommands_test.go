@@ -0,0 +1,66 @@
+package main
+
+import (
+	"io"
+	"testing"
+)
+
+func TestFindTwoWordSubcommand(t *testing.T) {
+	sc, rest, ok := findSubcommand([]string{"issue", "create", "--project", "X"})
+	if !ok || sc.name != "issue create" || len(rest) != 2 {
+		t.Errorf("Subcommand was not found properly")
+	}
+}
+
+func TestFindOneWordSubcommand(t *testing.T) {
+	sc, rest, ok := findSubcommand([]string{"list", "--project", "X"})
+	if !ok || sc.name != "list" || len(rest) != 2 {
+		t.Errorf("Subcommand was not found properly")
+	}
+}
+
+func TestFindUnknownSubcommand(t *testing.T) {
+	if _, _, ok := findSubcommand([]string{"issue", "--project", "X"}); ok {
+		t.Errorf("Unknown subcommand was found")
+	}
+}
+
+func TestSubcommandRequest(t *testing.T) {
+	sc, rest, _ := findSubcommand([]string{"issue", "create", "--project", "X", "--title", "Y", "--description", "Z", "--json"})
+	values, format, err := parseOptions(sc, rest, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "issue|-|X|-|Y|-|Z|-|false"
+	request := sc.request(values, "test")
+	if request != expected || format != outputJSON {
+		t.Errorf("Request was not constructed properly. Expected: " + expected + ", but got: " + request)
+	}
+}
+
+func TestSubcommandMissingRequiredOption(t *testing.T) {
+	sc, rest, _ := findSubcommand([]string{"find", "--project", "X"})
+	if _, _, err := parseOptions(sc, rest, io.Discard); err == nil || err.Error() != "missing --title" {
+		t.Errorf("Expected an error for the missing title, but got %v", err)
+	}
+}
+
+func TestSubcommandReadsLeftOutPasswords(t *testing.T) {
+	t.Setenv(PasswordVariable, "current")
+	t.Setenv(NewPasswordVariable, "changed")
+
+	sc, rest, _ := findSubcommand([]string{"passwd"})
+	values, _, err := parseOptions(sc, rest, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := readPasswordOptions(sc, values, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "passwd|-|current|-|changed"
+	if request := sc.request(values, "test"); request != expected {
+		t.Errorf("Request was not constructed properly. Expected: " + expected + ", but got: " + request)
+	}
+}
@@ -0,0 +1,605 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.fmi/issuetracker/command"
+	"go.fmi/issuetracker/issue"
+	"go.fmi/issuetracker/project"
+	"go.fmi/issuetracker/protocol"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Panes of the triage UI, in the order in which Tab moves the focus
+const (
+	projectsPane = iota
+	issuesPane
+	detailsPane
+	paneCount
+)
+
+// issueSort is an order in which the issues are listed
+type issueSort struct {
+	name string
+	key  func(i issue.Issue) string
+}
+
+// issueSorts are the orders between which the triage UI switches
+var issueSorts = []issueSort{
+	{"title", func(i issue.Issue) string { return strings.ToLower(i.Title) }},
+	{"status", func(i issue.Issue) string { return string(i.Status) + strings.ToLower(i.Title) }},
+	{"assignee", func(i issue.Issue) string { return strings.ToLower(i.Assignee) + "\x00" + strings.ToLower(i.Title) }},
+	{"reporter", func(i issue.Issue) string { return strings.ToLower(i.Reporter) + "\x00" + strings.ToLower(i.Title) }},
+}
+
+// statusFilters are the states between which the status filter switches, where empty shows all issues
+var statusFilters = []string{"", "open", "resolved"}
+
+// triageHelp lists the keybindings of the triage UI
+const triageHelp = "Tab: pane  Enter: open  /: search  f: status  m: mine  s: sort  " +
+	"r: resolve  a: assign  l: label  c: comment  F5: refresh  q: quit"
+
+// input is a line typed at the bottom of the triage UI, e.g. the assignee of an issue
+type input struct {
+	prompt string
+	text   []rune
+	submit func(text string)
+}
+
+// triage is the full-screen terminal UI for browsing the projects and triaging their issues
+type triage struct {
+	screen   tcell.Screen
+	exchange func(request string) (string, bool, error)
+	username string
+
+	focus    int
+	projects []project.Project
+	project  int
+	issues   []issue.Issue
+	visible  []issue.Issue
+	selected int
+	details  *command.IssueDetails
+	scroll   int
+
+	filter issue.Filter
+	order  int
+
+	status string
+	input  *input
+}
+
+// runTUI runs the triage UI with the session stored in a profile and returns the exit code of the client
+func runTUI(profileName string) int {
+	config, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	_, selected, err := config.profile(profileName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	secret, _ := selected.sessionToken()
+	if secret == "" {
+		fmt.Fprintln(os.Stderr, "You are not logged in - run 'client login' first")
+		return 1
+	}
+
+	exchange, disconnect, err := dial(selected.Server)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer disconnect()
+
+	message, ok, err := exchange(protocol.Request("resume", secret))
+	if err != nil || !ok {
+		fmt.Fprintln(os.Stderr, strings.TrimSpace(message), err)
+		return 1
+	}
+
+	screen, err := tcell.NewScreen()
+	if err == nil {
+		err = screen.Init()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer screen.Fini()
+
+	t := &triage{screen: screen, exchange: exchange, username: loggedUsername(message)}
+	t.loadProjects()
+	t.run()
+	return 0
+}
+
+// run draws the UI and handles the events until the user quits
+func (t *triage) run() {
+	for {
+		t.draw()
+
+		switch ev := t.screen.PollEvent().(type) {
+		case *tcell.EventResize:
+			t.screen.Sync()
+		case *tcell.EventKey:
+			if !t.handleKey(ev) {
+				return
+			}
+		case nil:
+			return
+		}
+	}
+}
+
+// fetch sends a data request to the server and decodes the data in its response. Failures are shown in the status line
+func (t *triage) fetch(request string, data interface{}) bool {
+	message, ok, err := t.exchange(protocol.DataRequest(request))
+	if err != nil {
+		t.status = err.Error()
+		return false
+	}
+	if !ok {
+		t.status = strings.TrimSpace(message)
+		return false
+	}
+
+	encoded, isData := protocol.Data(message)
+	if !isData {
+		t.status = "Unexpected response from the server"
+		return false
+	}
+	if err := json.Unmarshal(encoded, data); err != nil {
+		t.status = err.Error()
+		return false
+	}
+
+	return true
+}
+
+// perform sends a request changing the selected issue, shows the outcome and reloads the issues
+func (t *triage) perform(request string) {
+	message, _, err := t.exchange(request)
+	if err != nil {
+		t.status = err.Error()
+		return
+	}
+
+	t.status = strings.TrimSpace(message)
+	t.loadIssues()
+}
+
+func (t *triage) loadProjects() {
+	var projects []project.Project
+	if t.fetch(protocol.Request("projects"), &projects) {
+		sort.Slice(projects, func(i, j int) bool { return strings.ToLower(projects[i].Name) < strings.ToLower(projects[j].Name) })
+		t.projects = projects
+	}
+	t.project = clamp(t.project, len(t.projects))
+	t.loadIssues()
+}
+
+// loadIssues loads the issues of the selected project, keeping the selected issue if it is still listed
+func (t *triage) loadIssues() {
+	t.issues = nil
+	if p, ok := t.currentProject(); ok {
+		var issues []issue.Issue
+		if t.fetch(protocol.Request("list", p.Name), &issues) {
+			t.issues = issues
+		}
+	}
+
+	t.applyFilter()
+}
+
+// applyFilter lists the issues which pass the filter in the selected order and loads the details of the selected one
+func (t *triage) applyFilter() {
+	previous, hadSelection := t.currentIssue()
+
+	t.visible = nil
+	for _, i := range t.issues {
+		if t.filter.Matches(i) {
+			t.visible = append(t.visible, i)
+		}
+	}
+
+	key := issueSorts[t.order].key
+	sort.SliceStable(t.visible, func(i, j int) bool { return key(t.visible[i]) < key(t.visible[j]) })
+
+	if hadSelection {
+		for index, i := range t.visible {
+			if i.Title == previous.Title {
+				t.selected = index
+			}
+		}
+	}
+	t.selected = clamp(t.selected, len(t.visible))
+	t.loadDetails()
+}
+
+func (t *triage) loadDetails() {
+	t.details, t.scroll = nil, 0
+	if i, ok := t.currentIssue(); ok {
+		var details command.IssueDetails
+		if t.fetch(protocol.Request("find", i.Project, i.Title), &details) {
+			t.details = &details
+		}
+	}
+}
+
+func (t *triage) currentProject() (project.Project, bool) {
+	if t.project >= len(t.projects) {
+		return project.Project{}, false
+	}
+	return t.projects[t.project], true
+}
+
+func (t *triage) currentIssue() (issue.Issue, bool) {
+	if t.selected >= len(t.visible) {
+		return issue.Issue{}, false
+	}
+	return t.visible[t.selected], true
+}
+
+// clamp keeps an index within a list of a length
+func clamp(index int, length int) int {
+	if index >= length {
+		index = length - 1
+	}
+	if index < 0 {
+		index = 0
+	}
+	return index
+}
+
+// handleKey handles a key and reports whether the UI keeps running
+func (t *triage) handleKey(ev *tcell.EventKey) bool {
+	if t.input != nil {
+		t.handleInput(ev)
+		return true
+	}
+
+	switch ev.Key() {
+	case tcell.KeyCtrlC:
+		return false
+	case tcell.KeyTab:
+		t.focus = (t.focus + 1) % paneCount
+	case tcell.KeyBacktab:
+		t.focus = (t.focus + paneCount - 1) % paneCount
+	case tcell.KeyRight, tcell.KeyEnter:
+		if t.focus < detailsPane {
+			t.focus++
+		}
+	case tcell.KeyLeft, tcell.KeyEscape:
+		if t.focus > projectsPane {
+			t.focus--
+		}
+	case tcell.KeyUp:
+		t.move(-1)
+	case tcell.KeyDown:
+		t.move(1)
+	case tcell.KeyPgUp:
+		t.move(-10)
+	case tcell.KeyPgDn:
+		t.move(10)
+	case tcell.KeyF5, tcell.KeyCtrlL:
+		t.status = ""
+		t.loadProjects()
+	case tcell.KeyRune:
+		return t.handleRune(ev.Rune())
+	}
+
+	return true
+}
+
+func (t *triage) handleRune(r rune) bool {
+	switch r {
+	case 'q':
+		return false
+	case 'k':
+		t.move(-1)
+	case 'j':
+		t.move(1)
+	case '/':
+		t.ask("Search: ", t.filter.Text, func(text string) {
+			t.filter.Text = text
+			t.applyFilter()
+		})
+	case 'f':
+		for i, status := range statusFilters {
+			if status == t.filter.Status {
+				t.filter.Status = statusFilters[(i+1)%len(statusFilters)]
+				break
+			}
+		}
+		t.applyFilter()
+	case 'm':
+		if t.filter.Assignee == "" {
+			t.filter.Assignee = t.username
+		} else {
+			t.filter.Assignee = ""
+		}
+		t.applyFilter()
+	case 's':
+		t.order = (t.order + 1) % len(issueSorts)
+		t.applyFilter()
+	case 'r', 'a', 'l', 'c':
+		t.act(r)
+	}
+
+	return true
+}
+
+// act starts an action on the selected issue
+func (t *triage) act(action rune) {
+	i, ok := t.currentIssue()
+	if !ok {
+		t.status = "There isn't a selected issue"
+		return
+	}
+
+	switch action {
+	case 'r':
+		t.perform(protocol.Request("resolve", i.Project, i.Title))
+	case 'a':
+		t.ask("Assignee: ", i.Assignee, func(assignee string) {
+			t.perform(protocol.Request("assign", i.Project, i.Title, assignee))
+		})
+	case 'l':
+		t.ask("Labels (separated by commas): ", i.Labels, func(labels string) {
+			t.perform(protocol.Request("label", i.Project, i.Title, labels))
+		})
+	case 'c':
+		t.ask("Comment (empty to open the editor): ", "", func(content string) {
+			if content == "" {
+				var err error
+				if content, err = t.edit(commentTemplate); err != nil {
+					t.status = err.Error()
+					return
+				}
+			}
+			t.perform(protocol.Request("comment", i.Project, i.Title, content, t.username, ""))
+		})
+	}
+}
+
+// edit suspends the UI while a text is written in the editor
+func (t *triage) edit(template string) (string, error) {
+	if err := t.screen.Suspend(); err != nil {
+		return "", err
+	}
+	defer t.screen.Resume()
+
+	return editText(template)
+}
+
+// move moves the selection in the focused pane, or scrolls the details
+func (t *triage) move(delta int) {
+	switch t.focus {
+	case projectsPane:
+		if project := clamp(t.project+delta, len(t.projects)); project != t.project {
+			t.project, t.selected = project, 0
+			t.loadIssues()
+		}
+	case issuesPane:
+		if selected := clamp(t.selected+delta, len(t.visible)); selected != t.selected {
+			t.selected = selected
+			t.loadDetails()
+		}
+	case detailsPane:
+		t.scroll += delta
+		if t.scroll < 0 {
+			t.scroll = 0
+		}
+	}
+}
+
+// ask shows an input line with an initial text, which calls submit when Enter is pressed
+func (t *triage) ask(prompt string, initial string, submit func(string)) {
+	t.input = &input{prompt: prompt, text: []rune(initial), submit: submit}
+}
+
+func (t *triage) handleInput(ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyEscape, tcell.KeyCtrlC:
+		t.input = nil
+	case tcell.KeyEnter:
+		in := t.input
+		t.input = nil
+		in.submit(strings.TrimSpace(string(in.text)))
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(t.input.text) > 0 {
+			t.input.text = t.input.text[:len(t.input.text)-1]
+		}
+	case tcell.KeyCtrlU:
+		t.input.text = nil
+	case tcell.KeyRune:
+		t.input.text = append(t.input.text, ev.Rune())
+	}
+}
+
+// draw draws the panes with the header and the status line
+func (t *triage) draw() {
+	t.screen.Clear()
+	t.screen.HideCursor()
+	width, height := t.screen.Size()
+	if width < 20 || height < 5 {
+		drawText(t.screen, 0, 0, width, tcell.StyleDefault, "Too small")
+		t.screen.Show()
+		return
+	}
+
+	projectsWidth := width / 5
+	issuesWidth := (width - projectsWidth) * 2 / 5
+	detailsX := projectsWidth + issuesWidth
+	paneHeight := height - 2
+
+	drawText(t.screen, 0, 0, width, tcell.StyleDefault.Reverse(true), t.header())
+
+	var projectLines []string
+	for _, p := range t.projects {
+		projectLines = append(projectLines, p.Name)
+	}
+	t.drawPane(projectsPane, 0, projectsWidth, paneHeight, "Projects", projectLines, t.project)
+
+	var issueLines []string
+	for _, i := range t.visible {
+		mark := "  "
+		if i.IsResolved() {
+			mark = "✓ "
+		}
+		line := mark + i.Title
+		if i.Assignee != "" {
+			line += " @" + i.Assignee
+		}
+		if i.Labels != "" {
+			line += " [" + i.Labels + "]"
+		}
+		issueLines = append(issueLines, line)
+	}
+	t.drawPane(issuesPane, projectsWidth, issuesWidth, paneHeight, "Issues", issueLines, t.selected)
+
+	detailLines := t.detailLines(width - detailsX - 2)
+	t.scroll = clamp(t.scroll, len(detailLines))
+	t.drawPane(detailsPane, detailsX, width-detailsX, paneHeight, "Details", detailLines[t.scroll:], -1)
+
+	bottom := height - 1
+	if t.input != nil {
+		line := t.input.prompt + string(t.input.text)
+		drawText(t.screen, 0, bottom, width, tcell.StyleDefault, line)
+		t.screen.ShowCursor(len([]rune(line)), bottom)
+	} else {
+		drawText(t.screen, 0, bottom, width, tcell.StyleDefault.Bold(true), t.status)
+	}
+
+	t.screen.Show()
+}
+
+// header describes the user, the filter and the order of the issues and lists the keybindings
+func (t *triage) header() string {
+	status := t.filter.Status
+	if status == "" {
+		status = "all"
+	}
+	header := " " + t.username + " | " + status + " issues by " + issueSorts[t.order].name
+	if t.filter.Assignee != "" {
+		header += " | assigned to " + t.filter.Assignee
+	}
+	if t.filter.Text != "" {
+		header += " | matching '" + t.filter.Text + "'"
+	}
+
+	return header + " | " + triageHelp
+}
+
+// drawPane draws a pane with a title and lines, highlighting the selected line when the pane has the focus
+func (t *triage) drawPane(pane int, x int, width int, height int, title string, lines []string, selected int) {
+	titleStyle := tcell.StyleDefault.Underline(true)
+	if t.focus == pane {
+		titleStyle = titleStyle.Bold(true)
+	}
+	drawText(t.screen, x+1, 1, width-2, titleStyle, title)
+
+	// The list scrolls so that the selected line stays visible
+	first := 0
+	if rows := height - 1; selected >= rows {
+		first = selected - rows + 1
+	}
+
+	for row := 1; row < height && first+row-1 < len(lines); row++ {
+		index := first + row - 1
+		style := tcell.StyleDefault
+		if index == selected {
+			style = style.Reverse(t.focus == pane).Bold(true)
+		}
+		drawText(t.screen, x+1, row+1, width-2, style, lines[index])
+	}
+}
+
+// detailLines are the lines of the details of the selected issue, wrapped to a width
+func (t *triage) detailLines(width int) []string {
+	if t.details == nil {
+		return []string{"No issue selected"}
+	}
+
+	i := t.details.Issue
+	lines := []string{i.Title, "Status: " + string(i.Status), "Reporter: " + i.Reporter}
+	if i.Assignee != "" {
+		lines = append(lines, "Assignee: "+i.Assignee)
+	}
+	if i.Labels != "" {
+		lines = append(lines, "Labels: "+i.Labels)
+	}
+	lines = append(lines, "")
+	lines = append(lines, wrap(i.Description, width)...)
+	lines = append(lines, "", "Comments ("+strconv.Itoa(len(t.details.Comments))+")")
+
+	for _, entry := range t.details.Comments {
+		c := entry.Comment
+		indent := strings.Repeat("  ", entry.Depth)
+		heading := "#" + strconv.Itoa(c.ID) + " " + c.Commenter + " " + formatTime(c.Created)
+		if !c.Edited.IsZero() {
+			heading += " (edited)"
+		}
+		content := c.Content
+		if c.Deleted {
+			content = "[deleted]"
+		}
+
+		lines = append(lines, "", indent+heading)
+		for _, line := range wrap(content, width-len(indent)) {
+			lines = append(lines, indent+line)
+		}
+	}
+
+	return lines
+}
+
+// wrap breaks a text into lines no longer than a width, breaking the lines between words where possible
+func wrap(text string, width int) []string {
+	if width < 1 {
+		width = 1
+	}
+
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		runes := []rune(strings.TrimRight(paragraph, " \t\r"))
+		for len(runes) > width {
+			cut := width
+			for i := width; i > 0; i-- {
+				if runes[i] == ' ' {
+					cut = i
+					break
+				}
+			}
+			lines = append(lines, string(runes[:cut]))
+			runes = []rune(strings.TrimLeft(string(runes[cut:]), " "))
+		}
+		lines = append(lines, string(runes))
+	}
+
+	return lines
+}
+
+// drawText draws a line of text, cutting it at a width
+func drawText(screen tcell.Screen, x int, y int, width int, style tcell.Style, text string) {
+	column := 0
+	for _, r := range text {
+		if column >= width {
+			return
+		}
+		if r == '\t' {
+			r = ' '
+		}
+		screen.SetContent(x+column, y, r, nil, style)
+		column++
+	}
+}
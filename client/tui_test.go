@@ -0,0 +1,153 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"go.fmi/issuetracker/protocol"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// fakeTracker answers the requests of the triage UI and records the requests changing the issues
+type fakeTracker struct {
+	changes []string
+}
+
+func (f *fakeTracker) exchange(request string) (string, bool, error) {
+	switch request {
+	case protocol.DataRequest(protocol.Request("projects")):
+		return protocol.DataPrefix + `[{"name":"Web"},{"name":"Mobile"}]`, true, nil
+	case protocol.DataRequest(protocol.Request("list", "Mobile")):
+		return protocol.DataPrefix + `[{"project":"Mobile","title":"Slow sync","status":"open","assignee":"ivan"},` +
+			`{"project":"Mobile","title":"Crash on start","status":"open","labels":"bug"},` +
+			`{"project":"Mobile","title":"Typo","status":"resolved"}]`, true, nil
+	case protocol.DataRequest(protocol.Request("list", "Web")):
+		return protocol.DataPrefix + `[]`, true, nil
+	}
+
+	if strings.HasPrefix(request, protocol.DataPrefix+"find") {
+		fields := protocol.Fields(strings.TrimPrefix(request, protocol.DataPrefix))
+		return protocol.DataPrefix + `{"issue":{"project":"` + fields[1] + `","title":"` + fields[2] + `","description":"line one\nline two"},` +
+			`"comments":[{"comment":{"id":1,"content":"me too","commenter":"maria"},"depth":0}]}`, true, nil
+	}
+
+	f.changes = append(f.changes, request)
+	return "Done\n", true, nil
+}
+
+func newTestTriage(t *testing.T) (*triage, *fakeTracker, tcell.SimulationScreen) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	screen.SetSize(120, 30)
+	t.Cleanup(screen.Fini)
+
+	tracker := &fakeTracker{}
+	tr := &triage{screen: screen, exchange: tracker.exchange, username: "ivan"}
+	tr.loadProjects()
+	return tr, tracker, screen
+}
+
+func press(tr *triage, keys ...interface{}) {
+	for _, key := range keys {
+		switch k := key.(type) {
+		case rune:
+			tr.handleKey(tcell.NewEventKey(tcell.KeyRune, k, tcell.ModNone))
+		case string:
+			for _, r := range k {
+				tr.handleKey(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+			}
+		case tcell.Key:
+			tr.handleKey(tcell.NewEventKey(k, 0, tcell.ModNone))
+		}
+	}
+}
+
+func titles(tr *triage) []string {
+	var result []string
+	for _, i := range tr.visible {
+		result = append(result, i.Title)
+	}
+	return result
+}
+
+func TestTriageFilterAndSort(t *testing.T) {
+	tr, _, _ := newTestTriage(t)
+
+	if len(tr.projects) != 2 || tr.projects[0].Name != "Mobile" {
+		t.Fatalf("The projects should be sorted by name, got %v", tr.projects)
+	}
+	if got := strings.Join(titles(tr), ","); got != "Crash on start,Slow sync,Typo" {
+		t.Errorf("Invalid issues: " + got)
+	}
+
+	press(tr, 'f')
+	if got := strings.Join(titles(tr), ","); got != "Crash on start,Slow sync" {
+		t.Errorf("Invalid open issues: " + got)
+	}
+
+	press(tr, 'm')
+	if got := strings.Join(titles(tr), ","); got != "Slow sync" {
+		t.Errorf("Invalid issues assigned to the user: " + got)
+	}
+
+	press(tr, 'm', 'f', 'f', 's', 's')
+	if got := strings.Join(titles(tr), ","); got != "Crash on start,Typo,Slow sync" {
+		t.Errorf("Invalid issues sorted by assignee: " + got)
+	}
+
+	press(tr, '/', "BUG", tcell.KeyEnter)
+	if got := strings.Join(titles(tr), ","); got != "Crash on start" {
+		t.Errorf("Invalid issues matching the search: " + got)
+	}
+}
+
+func TestTriageActions(t *testing.T) {
+	tr, tracker, _ := newTestTriage(t)
+
+	press(tr, tcell.KeyTab, tcell.KeyDown)
+	if tr.details == nil || tr.details.Issue.Title != "Slow sync" {
+		t.Fatalf("The details of the selected issue should be loaded, got %+v", tr.details)
+	}
+
+	press(tr, 'r', 'a', tcell.KeyCtrlU, "maria", tcell.KeyEnter, 'l', ",ui", tcell.KeyEnter, 'c', "Same here", tcell.KeyEnter)
+	expected := []string{
+		protocol.Request("resolve", "Mobile", "Slow sync"),
+		protocol.Request("assign", "Mobile", "Slow sync", "maria"),
+		protocol.Request("label", "Mobile", "Slow sync", ",ui"),
+		protocol.Request("comment", "Mobile", "Slow sync", "Same here", "ivan", ""),
+	}
+	if strings.Join(tracker.changes, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Invalid requests %q", tracker.changes)
+	}
+
+	press(tr, 'a', tcell.KeyEscape)
+	if len(tracker.changes) != len(expected) || tr.input != nil {
+		t.Errorf("A cancelled input should not send a request")
+	}
+}
+
+func TestTriageDraw(t *testing.T) {
+	tr, _, screen := newTestTriage(t)
+	tr.draw()
+
+	cells, width, _ := screen.GetContents()
+	var row strings.Builder
+	for _, cell := range cells[2*width : 3*width] {
+		row.WriteString(string(cell.Runes))
+	}
+
+	if !strings.Contains(row.String(), "Mobile") || !strings.Contains(row.String(), "Crash on start [bug]") {
+		t.Errorf("Invalid first row of the panes: %q", row.String())
+	}
+}
+
+func TestWrap(t *testing.T) {
+	lines := wrap("a stack trace\n  at main.go:12", 8)
+	expected := []string{"a stack", "trace", "  at", "main.go:", "12"}
+	if strings.Join(lines, "|") != strings.Join(expected, "|") {
+		t.Errorf("Invalid wrapped lines %q", lines)
+	}
+}
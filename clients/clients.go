@@ -0,0 +1,109 @@
+package clients
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Client is a connection of a client to the TCP server
+type Client struct {
+	ID         int       `json:"id"`
+	Username   string    `json:"username,omitempty"`
+	RemoteAddr string    `json:"remoteAddr"`
+	Connected  time.Time `json:"connected"`
+}
+
+// connection is a client together with the function which forcibly closes its connection
+type connection struct {
+	client Client
+	close  func()
+}
+
+var (
+	lock        sync.Mutex
+	connections = make(map[int]*connection)
+	lastID      int
+	// Started is when the server started, which is shown in its statistics
+	Started = time.Now()
+)
+
+// Add registers a new connection and returns its identifier. The close function is called when an administrator
+// disconnects the client
+func Add(remoteAddr string, close func()) int {
+	lock.Lock()
+	defer lock.Unlock()
+
+	lastID++
+	connections[lastID] = &connection{
+		client: Client{ID: lastID, RemoteAddr: remoteAddr, Connected: time.Now().UTC()},
+		close:  close}
+
+	return lastID
+}
+
+// SetUser records the user who is currently logged in through a connection, empty after logging out
+func SetUser(id int, username string) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if c, ok := connections[id]; ok {
+		c.client.Username = username
+	}
+}
+
+// Remove forgets a connection once it is closed
+func Remove(id int) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	delete(connections, id)
+}
+
+// List returns the connected clients in the order in which they connected
+func List() []Client {
+	lock.Lock()
+	defer lock.Unlock()
+
+	list := make([]Client, 0, len(connections))
+	for _, c := range connections {
+		list = append(list, c.client)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+	return list
+}
+
+// Disconnect forcibly closes the connection of a client and reports whether it was connected
+func Disconnect(id int) bool {
+	lock.Lock()
+	c, ok := connections[id]
+	delete(connections, id)
+	lock.Unlock()
+
+	// The connection is closed outside of the lock, because closing it may wait for a write to the client
+	if ok {
+		c.close()
+	}
+
+	return ok
+}
+
+// DisconnectUser forcibly closes all connections through which a user is logged in and returns how many were closed
+func DisconnectUser(username string) int {
+	lock.Lock()
+	var closing []*connection
+	for id, c := range connections {
+		if c.client.Username == username {
+			closing = append(closing, c)
+			delete(connections, id)
+		}
+	}
+	lock.Unlock()
+
+	for _, c := range closing {
+		c.close()
+	}
+
+	return len(closing)
+}
@@ -0,0 +1,58 @@
+package clients
+
+import "testing"
+
+func TestListInOrderOfConnecting(t *testing.T) {
+	first := Add("10.0.0.1:5000", func() {})
+	defer Remove(first)
+	second := Add("10.0.0.2:5000", func() {})
+	defer Remove(second)
+
+	SetUser(second, "ivan")
+
+	list := List()
+	if len(list) != 2 || list[0].ID != first || list[1].ID != second {
+		t.Fatalf("Expected the clients %d and %d in order, but got %+v", first, second, list)
+	}
+
+	if list[0].Username != "" || list[1].Username != "ivan" || list[1].RemoteAddr != "10.0.0.2:5000" {
+		t.Errorf("The clients were not recorded properly: %+v", list)
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	closed := false
+	id := Add("10.0.0.1:5000", func() { closed = true })
+
+	if !Disconnect(id) || !closed {
+		t.Fatalf("The client was not disconnected")
+	}
+
+	if Disconnect(id) {
+		t.Errorf("A client was disconnected twice")
+	}
+
+	if len(List()) != 0 {
+		t.Errorf("A disconnected client is still listed")
+	}
+}
+
+func TestDisconnectUser(t *testing.T) {
+	closed := 0
+	first := Add("10.0.0.1:5000", func() { closed++ })
+	second := Add("10.0.0.2:5000", func() { closed++ })
+	other := Add("10.0.0.3:5000", func() { t.Errorf("The connection of another user was closed") })
+	defer Remove(other)
+
+	SetUser(first, "ivan")
+	SetUser(second, "ivan")
+	SetUser(other, "maria")
+
+	if count := DisconnectUser("ivan"); count != 2 || closed != 2 {
+		t.Fatalf("Expected both connections of the user to be closed, but %d were", closed)
+	}
+
+	if list := List(); len(list) != 1 || list[0].ID != other {
+		t.Errorf("Only the connection of the other user should be left, but got %+v", list)
+	}
+}
@@ -1,26 +1,236 @@
 package command
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
 	"strings"
+	"time"
 
+	"go.fmi/issuetracker/audit"
+	"go.fmi/issuetracker/auth"
+	"go.fmi/issuetracker/clients"
 	"go.fmi/issuetracker/comment"
+	"go.fmi/issuetracker/config"
 	"go.fmi/issuetracker/db"
 	"go.fmi/issuetracker/issue"
+	"go.fmi/issuetracker/lockout"
+	"go.fmi/issuetracker/notification"
+	"go.fmi/issuetracker/notifier"
 	"go.fmi/issuetracker/project"
+	"go.fmi/issuetracker/protocol"
+	"go.fmi/issuetracker/push"
+	"go.fmi/issuetracker/stats"
+	"go.fmi/issuetracker/token"
 	"go.fmi/issuetracker/user"
+	"go.fmi/issuetracker/watch"
+	"go.fmi/issuetracker/webhook"
 )
 
-// Command is a common interface for all supported command types
+// Command is a common interface for all supported command types. A command returns its message to the client together
+// with a nil error when it succeeds, or with one of the errors below telling the kind of its failure
 type Command interface {
-	Execute() (string, bool)
+	Execute() (string, error)
+}
+
+// Kinds of failures of the commands, by which the HTTP API and the gRPC service choose their status codes
+var (
+	ErrInvalid         = errors.New("invalid request")
+	ErrNotFound        = errors.New("not found")
+	ErrUnauthenticated = errors.New("not logged in")
+	ErrForbidden       = errors.New("forbidden")
+	ErrExists          = errors.New("already exists")
+	ErrConflict        = errors.New("conflict")
+	ErrTooManyRequests = errors.New("too many requests")
+	ErrUnavailable     = errors.New("unavailable")
+	ErrInternal        = errors.New("internal error")
+)
+
+// Failure messages which are shared by several commands
+const (
+	ProjectNotFound         = "Could not find project \n"
+	IssueNotFound           = "Issue does not exist \n"
+	ResolvableIssueNotFound = "Could not resolve issue - issue does not exist \n"
+	CommentNotFound         = "Comment does not exist \n"
+	NotLoggedIn             = "You are not logged in\n"
+	InvalidCredentials      = "Login unsuccessful - inavlid username/password\n"
+	TooManyAttempts         = "Login unsuccessful - too many failed attempts, try again later\n"
+	AccountDeactivated      = "Login unsuccessful - the account is deactivated\n"
+	ProviderUnavailable     = "Login unsuccessful - the authentication provider is unavailable, try again later\n"
+	UserNotFound            = "User does not exist\n"
+	UsernameTaken           = "Registration unsuccessful - username is not unique\n"
+	ProjectNameTaken        = "Could not create new project - project name is not unique\n"
+	IssueTitleTaken         = "Could not create new issue - issue name is not unique for project\n"
+	IssueAlreadyResolved    = "Issue is already resolved \n"
+	NotAuthorOfEdited       = "Could not edit comment - only the author can edit it\n"
+	NotAuthorOfDeleted      = "Could not delete comment - only the author can delete it\n"
+	InvalidToken            = "Could not resume session - the token is invalid or expired\n"
+	WrongPassword           = "Could not change password - the current password is wrong\n"
+	InvalidResetToken       = "Could not set password - the reset token is invalid or expired\n"
+	MissingScope            = "Could not execute command - the access token lacks the scope it requires\n"
+	CredentialsWithToken    = "Could not execute command - credentials can't be managed with an access token\n"
+)
+
+// Session holds the state of a single client connection to the server
+type Session struct {
+	Username   string
+	RemoteAddr string
+	// TokenHash is the hash of the session token issued or resumed in the session, which is revoked on logout
+	TokenHash string
+	// ClientID identifies the connection of a TCP client, zero for the other front ends
+	ClientID int
+	// Scopes limit the commands of a session authenticated with a personal access token, empty for the other sessions
+	Scopes []token.Scope
+}
+
+// Authenticate logs the owner of a token in the session, limiting the session to the scopes of a personal access token
+func (s *Session) Authenticate(t token.Token) {
+	s.Username = t.Username
+	s.Scopes = t.Scopes
+}
+
+// SessionCommand is implemented by commands which depend on the session they are issued in
+type SessionCommand interface {
+	Command
+	ExecuteAs(session *Session) (string, error)
+}
+
+// ExecuteInSession executes a command on behalf of the user logged in a session
+func ExecuteInSession(c Command, session *Session) (string, error) {
+	if session.Username == "" && requiresLogin(c) {
+		return NotLoggedIn, ErrUnauthenticated
+	}
+
+	if len(session.Scopes) > 0 {
+		if message, err := checkScope(c, session.Scopes); err != nil {
+			return message, err
+		}
+	}
+
+	if sessionCommand, ok := c.(SessionCommand); ok {
+		return sessionCommand.ExecuteAs(session)
+	}
+
+	return c.Execute()
+}
+
+// DataCommand is implemented by commands whose result can also be returned as structured data
+type DataCommand interface {
+	Command
+	Data() (interface{}, string, error)
+}
+
+// SessionDataCommand is implemented by data commands whose result depends on the session they are issued in
+type SessionDataCommand interface {
+	DataCommand
+	DataAs(session *Session) (interface{}, string, error)
+}
+
+// DataRequest asks for the result of a command as structured data, which is sent to the client as JSON
+type DataRequest struct {
+	Command DataCommand
+}
+
+// Execute executes the command outside of a session and encodes its result as JSON
+func (dr DataRequest) Execute() (string, error) {
+	return dr.ExecuteAs(&Session{})
+}
+
+// ExecuteAs executes the command on behalf of the user logged in a session and encodes its result as JSON
+func (dr DataRequest) ExecuteAs(session *Session) (string, error) {
+	var data interface{}
+	var message string
+	var err error
+	if sessionCommand, isSessionCommand := dr.Command.(SessionDataCommand); isSessionCommand {
+		data, message, err = sessionCommand.DataAs(session)
+	} else {
+		data, message, err = dr.Command.Data()
+	}
+	if err != nil {
+		return message, err
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		log.Println(err)
+		return "Could not encode the result\n", ErrInternal
+	}
+
+	return protocol.DataPrefix + string(encoded) + "\n", nil
+}
+
+// fieldCounts are the numbers of fields which the requests of each command type must have after the type.
+// Requests with fewer fields, e.g. from a client sending a truncated line, are not parsed
+var fieldCounts = map[string]int{
+	"data":           1,
+	"register":       2,
+	"login":          2,
+	"token":          0,
+	"resume":         1,
+	"logout":         0,
+	"whoami":         0,
+	"user":           1,
+	"users":          0,
+	"profile":        3,
+	"deactivate":     1,
+	"activate":       1,
+	"grantadmin":     1,
+	"revokeadmin":    1,
+	"deleteproject":  1,
+	"stats":          0,
+	"clients":        0,
+	"kick":           1,
+	"createtoken":    4,
+	"tokens":         1,
+	"revoketoken":    1,
+	"serviceaccount": 1,
+	"unlock":         2,
+	"passwd":         2,
+	"resetpassword":  1,
+	"setpassword":    3,
+	"project":        1,
+	"projects":       0,
+	"issue":          3,
+	"resolve":        2,
+	"assign":         3,
+	"label":          3,
+	"watch":          2,
+	"unwatch":        2,
+	"list":           1,
+	"find":           2,
+	"comment":        4,
+	"editcomment":    2,
+	"deletecomment":  1,
+	"inbox":          0,
+	"markread":       1,
+	"email":          3,
+	"addwebhook":     3,
+	"removewebhook":  1,
+	"webhooks":       1,
+	"deliveries":     1,
+	"audit":          4,
 }
 
 // ParseCommand is a factory function that instantiates a Command using raw input
 func ParseCommand(rawCommand string) Command {
-	commandElements := strings.Split(rawCommand, "|-|")
+	commandElements := protocol.Fields(rawCommand)
 	commandType := commandElements[0]
 
+	if count, ok := fieldCounts[commandType]; !ok || len(commandElements) <= count {
+		return nil
+	}
+
 	switch commandType {
+	case "data":
+		// The wrapped request is parsed as it was sent, so that its fields are unescaped only once
+		wrapped := strings.TrimPrefix(strings.TrimPrefix(rawCommand, commandType), protocol.Separator)
+		dataCommand, ok := ParseCommand(wrapped).(DataCommand)
+		if !ok {
+			return nil
+		}
+		return DataRequest{dataCommand}
 	case "register":
 		return RegisterCommand{user.User{
 			Username: commandElements[1],
@@ -30,22 +240,116 @@ func ParseCommand(rawCommand string) Command {
 			user.User{
 				Username: commandElements[1],
 				Password: commandElements[2]}}
+	case "token":
+		return TokenCommand{}
+	case "resume":
+		return ResumeCommand{
+			Token: commandElements[1]}
+	case "logout":
+		return LogoutCommand{}
+	case "whoami":
+		return WhoamiCommand{}
+	case "user":
+		return ShowUserCommand{
+			Username: commandElements[1]}
+	case "users":
+		return UsersCommand{}
+	case "profile":
+		return ProfileCommand{
+			DisplayName: commandElements[1],
+			Email:       commandElements[2],
+			Timezone:    commandElements[3]}
+	case "deactivate":
+		return DeactivateCommand{
+			Username: commandElements[1]}
+	case "activate":
+		return ActivateCommand{
+			Username: commandElements[1]}
+	case "grantadmin":
+		return GrantAdminCommand{
+			Username: commandElements[1]}
+	case "revokeadmin":
+		return RevokeAdminCommand{
+			Username: commandElements[1]}
+	case "deleteproject":
+		return DeleteProjectCommand{
+			Name: commandElements[1]}
+	case "stats":
+		return StatsCommand{}
+	case "clients":
+		return ClientsCommand{}
+	case "kick":
+		id, _ := strconv.Atoi(commandElements[1])
+		return KickCommand{
+			ID: id}
+	case "createtoken":
+		return CreateAccessTokenCommand{
+			Username: commandElements[1],
+			Name:     commandElements[2],
+			Scopes:   commandElements[3],
+			Days:     commandElements[4]}
+	case "tokens":
+		return AccessTokensCommand{
+			Username: commandElements[1]}
+	case "revoketoken":
+		id, _ := strconv.Atoi(commandElements[1])
+		return RevokeAccessTokenCommand{
+			ID: id}
+	case "serviceaccount":
+		return ServiceAccountCommand{
+			Username: commandElements[1]}
+	case "unlock":
+		return UnlockCommand{
+			Username: commandElements[1],
+			Address:  commandElements[2]}
+	case "passwd":
+		return PasswdCommand{
+			OldPassword: commandElements[1],
+			NewPassword: commandElements[2]}
+	case "resetpassword":
+		return ResetPasswordCommand{
+			Username: commandElements[1]}
+	case "setpassword":
+		return SetPasswordCommand{
+			Username: commandElements[1],
+			Token:    commandElements[2],
+			Password: commandElements[3]}
 	case "project":
 		return ProjectCommand{
 			project.Project{
 				Name: commandElements[1]}}
+	case "projects":
+		return ProjectsCommand{}
 	case "issue":
+		// The last field, once the resolved flag, is still sent by the clients, but new issues are always open. The
+		// reporter is the logged in user
 		return IssueCommand{
 			issue.Issue{
 				Project:     commandElements[1],
-				Reporter:    commandElements[2],
-				Title:       commandElements[3],
-				Description: commandElements[4],
-				Resolved:    commandElements[5]}}
+				Title:       commandElements[2],
+				Description: commandElements[3]}}
 	case "resolve":
 		return ResolveCommand{
 			Project: commandElements[1],
 			Title:   commandElements[2]}
+	case "assign":
+		return AssignCommand{
+			Project:  commandElements[1],
+			Title:    commandElements[2],
+			Assignee: commandElements[3]}
+	case "label":
+		return LabelCommand{
+			Project: commandElements[1],
+			Title:   commandElements[2],
+			Labels:  commandElements[3]}
+	case "watch":
+		return WatchCommand{
+			Project: commandElements[1],
+			Title:   commandElements[2]}
+	case "unwatch":
+		return UnwatchCommand{
+			Project: commandElements[1],
+			Title:   commandElements[2]}
 	case "list":
 		return ListCommand{
 			Project: commandElements[1]}
@@ -54,12 +358,57 @@ func ParseCommand(rawCommand string) Command {
 			Project: commandElements[1],
 			Title:   commandElements[2]}
 	case "comment":
-		return CommentCommand{
-			comment.Comment{
-				Project:   commandElements[1],
-				Title:     commandElements[2],
-				Content:   commandElements[3],
-				Commenter: commandElements[4]}}
+		// The fourth field, once the commenter, is still sent by the clients, but the comment is by the logged in user
+		newComment := comment.Comment{
+			Project: commandElements[1],
+			Title:   commandElements[2],
+			Content: commandElements[3]}
+		if len(commandElements) > 5 {
+			newComment.Parent, _ = strconv.Atoi(commandElements[5])
+		}
+		return CommentCommand{newComment}
+	case "editcomment":
+		id, _ := strconv.Atoi(commandElements[1])
+		return EditCommentCommand{
+			ID:      id,
+			Content: commandElements[2]}
+	case "deletecomment":
+		id, _ := strconv.Atoi(commandElements[1])
+		return DeleteCommentCommand{
+			ID: id}
+	case "inbox":
+		return InboxCommand{
+			UnreadOnly: len(commandElements) > 1 && commandElements[1] == "unread"}
+	case "markread":
+		id, _ := strconv.Atoi(commandElements[1])
+		return MarkReadCommand{
+			ID: id}
+	case "email":
+		return EmailCommand{
+			Email: commandElements[1],
+			Mode:  commandElements[2],
+			Kinds: commandElements[3]}
+	case "addwebhook":
+		return AddWebhookCommand{
+			Project: commandElements[1],
+			URL:     commandElements[2],
+			Secret:  commandElements[3]}
+	case "removewebhook":
+		id, _ := strconv.Atoi(commandElements[1])
+		return RemoveWebhookCommand{
+			ID: id}
+	case "webhooks":
+		return ListWebhooksCommand{
+			Project: commandElements[1]}
+	case "deliveries":
+		return DeliveriesCommand{
+			Project: commandElements[1]}
+	case "audit":
+		return AuditCommand{
+			Actor:  commandElements[1],
+			Action: commandElements[2],
+			From:   commandElements[3],
+			To:     commandElements[4]}
 	default:
 		return nil
 	}
@@ -72,17 +421,52 @@ type RegisterCommand struct {
 	User user.User
 }
 
+// Scope is the scope of a personal access token needed to register a user
+func (rc RegisterCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// AllowsAnonymous is true, because the command registers the user
+func (rc RegisterCommand) AllowsAnonymous() bool {
+	return true
+}
+
 // Execute creates a new user
-func (rc RegisterCommand) Execute() (string, bool) {
+func (rc RegisterCommand) Execute() (string, error) {
+	return rc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs creates a new user and logs them in the session
+func (rc RegisterCommand) ExecuteAs(session *Session) (string, error) {
+	if !auth.LocalEnabled() {
+		return "Registration unsuccessful - the accounts are managed by an external authentication provider\n", ErrForbidden
+	}
+
+	if err := user.ValidateUsername(rc.User.Username); err != nil {
+		return "Registration unsuccessful - " + err.Error() + "\n", ErrInvalid
+	}
+
+	if _, err := db.FindRegisteredUser(rc.User.Username); err == nil {
+		return UsernameTaken, ErrExists
+	}
+
+	if err := user.ValidatePassword(rc.User.Username, rc.User.Password); err != nil {
+		return "Registration unsuccessful - " + err.Error() + "\n", ErrInvalid
+	}
+
 	newUser := user.User{
 		Username: rc.User.Username,
 		Password: user.HashAndSalt(rc.User.Password)}
 
-	if _, err := db.FindRegisteredUser(newUser.Username); err != nil {
-		db.InsertRegisteredUser(newUser)
-		return "Registration successful. You are now logged in as " + newUser.Username + "\n", true
+	// The unique index catches a username registered in the meantime or differing only in its case
+	if err := db.InsertRegisteredUser(newUser); err == db.ErrDuplicate {
+		return UsernameTaken, ErrExists
 	}
-	return "Registration unsuccessful - username is not unique\n", false
+
+	db.InsertAuditEntry(audit.NewEntry(newUser.Username, audit.Register, newUser.Username, session.RemoteAddr))
+	session.Username = newUser.Username
+	session.Scopes = nil
+	return "Registration successful. You are now logged in as " + newUser.Username + "\n", nil
 }
 
 // LOGIN
@@ -92,173 +476,2277 @@ type LoginCommand struct {
 	User user.User
 }
 
+// Scope is the scope of a personal access token needed to log in
+func (lc LoginCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// AllowsAnonymous is true, because the command logs the user in
+func (lc LoginCommand) AllowsAnonymous() bool {
+	return true
+}
+
 // Execute logs a user in to their account
-func (lc LoginCommand) Execute() (string, bool) {
+func (lc LoginCommand) Execute() (string, error) {
+	return lc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs logs a user in to their account in the session, checking the password with the configured providers.
+// Users authenticated by an external provider are created on their first login
+func (lc LoginCommand) ExecuteAs(session *Session) (string, error) {
 	loggingUser := user.User{
 		Username: lc.User.Username,
 		Password: lc.User.Password}
 
-	registeredUser, err := db.FindRegisteredUser(loggingUser.Username)
-	isPasswordCorrect := user.ComparePasswords(registeredUser.Password, loggingUser.Password)
+	// The password isn't even checked while the account or the address waits after failed attempts
+	accountKey := lockout.AccountKey(loggingUser.Username)
+	if lockout.Wait(accountKey) > 0 || lockout.Wait(lockout.AddressKey(session.RemoteAddr)) > 0 {
+		return TooManyAttempts, ErrTooManyRequests
+	}
+
+	identity, err := auth.Authenticate(auth.Configured(), loggingUser.Username, loggingUser.Password)
+	if err == auth.ErrUnavailable {
+		return ProviderUnavailable, ErrUnavailable
+	}
+
+	var registeredUser user.User
+	if err == nil {
+		registeredUser, err = db.FindRegisteredUser(identity.Username)
+		if err != nil && identity.Provider != "" {
+			registeredUser, err = provisionUser(identity, session)
+			if err != nil {
+				return "Login unsuccessful - " + err.Error() + "\n", ErrInternal
+			}
+		}
+	}
+
+	// A provider vouches only for the users it provisioned, so that it can't take over a local account
+	if err == nil && registeredUser.Provider != identity.Provider {
+		err = auth.ErrInvalidCredentials
+	}
+
+	if err != nil {
+		db.InsertAuditEntry(audit.NewEntry(loggingUser.Username, audit.LoginFailed, loggingUser.Username, session.RemoteAddr))
+		failLogin(loggingUser.Username, session)
+		return InvalidCredentials, ErrUnauthenticated
+	}
 
-	if err == nil && isPasswordCorrect {
-		return "Login successful as " + loggingUser.Username + "\n", true
+	// A deactivated account is revealed only to someone who knows its password
+	if registeredUser.Deactivated {
+		db.InsertAuditEntry(audit.NewEntry(registeredUser.Username, audit.LoginFailed, registeredUser.Username, session.RemoteAddr))
+		return AccountDeactivated, ErrForbidden
 	}
 
-	return "Login unsuccessful - inavlid username/password\n", false
+	lockout.Reset(accountKey)
+	db.InsertAuditEntry(audit.NewEntry(registeredUser.Username, audit.Login, registeredUser.Username, session.RemoteAddr))
+	session.Username = registeredUser.Username
+	session.Scopes = nil
+	return "Login successful as " + registeredUser.Username + "\n", nil
 }
 
-// PROJECT
+// errProvision is returned instead of the error of the database when a provisioned user can't be created or found
+var errProvision = errors.New("the user could not be created")
 
-// ProjectCommand is used to create a new project
-type ProjectCommand struct {
-	Project project.Project
+// provisionUser creates a user authenticated by an external provider for the first time, taking the parts
+// of their profile which are valid on the server
+func provisionUser(identity auth.Identity, session *Session) (user.User, error) {
+	if err := user.ValidateUsername(identity.Username); err != nil {
+		return user.User{}, err
+	}
+
+	newUser := user.User{Username: identity.Username, Provider: identity.Provider}
+	if user.ValidateProfile(identity.DisplayName, "", "") == nil {
+		newUser.DisplayName = identity.DisplayName
+	}
+	if user.ValidateProfile("", identity.Email, "") == nil {
+		newUser.Email = identity.Email
+	}
+
+	// Two logins at once may both provision the user, and the second one takes the user created by the first. The
+	// usernames are unique regardless of their case, so the user may also be one whose username differs in case
+	if err := db.InsertRegisteredUser(newUser); err == db.ErrDuplicate {
+		existingUser, err := db.FindUserIgnoringCase(identity.Username)
+		if err != nil {
+			log.Println(err)
+			return user.User{}, errProvision
+		}
+		return existingUser, nil
+	}
+
+	db.InsertAuditEntry(audit.NewEntry(newUser.Username, audit.Provision, newUser.Username+" ("+newUser.Provider+")", session.RemoteAddr))
+	return newUser, nil
 }
 
-// Execute creates a new project
-func (pc ProjectCommand) Execute() (string, bool) {
-	newProject := project.Project{
-		Name: pc.Project.Name}
+// failLogin counts a failed login against the account and the remote address, and records in the audit log
+// when either of them is locked out
+func failLogin(username string, session *Session) {
+	settings := config.LoginLockout()
+
+	if lockout.Fail(lockout.AccountKey(username), settings.AccountThreshold, settings.Duration) {
+		db.InsertAuditEntry(audit.NewEntry(username, audit.Lockout, username, session.RemoteAddr))
+	}
 
-	if _, err := db.FindExistingProject(newProject.Name); err != nil {
-		db.InsertNewProject(newProject)
-		return "Project created successfully\n", true
+	if lockout.Fail(lockout.AddressKey(session.RemoteAddr), settings.AddressThreshold, settings.Duration) {
+		db.InsertAuditEntry(audit.NewEntry(username, audit.Lockout, lockout.Host(session.RemoteAddr), session.RemoteAddr))
 	}
+}
 
-	return "Could not create new project - project name is not unique\n", false
+// UnlockCommand is used by an administrator to forget the failed logins to an account or from an address,
+// which ends their lockout
+type UnlockCommand struct {
+	Username string
+	Address  string
 }
 
-// ISSUE
+// Scope is the scope of a personal access token needed to unlock an account or an address
+func (uc UnlockCommand) Scope() token.Scope {
+	return token.ScopeAdmin
+}
 
-// IssueCommand is used to create a new issue in a project
-type IssueCommand struct {
-	Issue issue.Issue
+// Execute fails, because only an administrator can unlock
+func (uc UnlockCommand) Execute() (string, error) {
+	return uc.ExecuteAs(&Session{})
 }
 
-// Execute creates a new issue in a project
-func (ic IssueCommand) Execute() (string, bool) {
-	newIssue := issue.Issue{
-		Project:     ic.Issue.Project,
-		Reporter:    ic.Issue.Reporter,
-		Title:       ic.Issue.Title,
-		Description: ic.Issue.Description,
-		Resolved:    ic.Issue.Resolved}
+// ExecuteAs forgets the failed logins to the account and from the address given in the command
+func (uc UnlockCommand) ExecuteAs(session *Session) (string, error) {
+	if !isAdmin(session.Username) {
+		return "Could not unlock - administrator rights are required\n", ErrForbidden
+	}
 
-	if _, err := db.FindExistingProject(newIssue.Project); err != nil {
-		return "Could not find project \n", false
+	if uc.Username == "" && uc.Address == "" {
+		return "Could not unlock - a username or an address is required\n", ErrInvalid
 	}
 
-	if _, err := db.FindExistingIssue(newIssue.Project, newIssue.Title); err == nil {
-		return "Could not create new issue - issue name is not unique for project\n", false
+	var unlocked []string
+	if uc.Username != "" && lockout.Reset(lockout.AccountKey(uc.Username)) {
+		db.InsertAuditEntry(audit.NewEntry(session.Username, audit.Unlock, uc.Username, session.RemoteAddr))
+		unlocked = append(unlocked, "account "+uc.Username)
+	}
+
+	if uc.Address != "" && lockout.Reset(lockout.AddressKey(uc.Address)) {
+		db.InsertAuditEntry(audit.NewEntry(session.Username, audit.Unlock, lockout.Host(uc.Address), session.RemoteAddr))
+		unlocked = append(unlocked, "address "+lockout.Host(uc.Address))
 	}
 
-	db.InsertNewIssue(newIssue)
-	return "Issue created successfully\n", true
+	if len(unlocked) == 0 {
+		return "Could not unlock - there are no failed logins to forget\n", ErrConflict
+	}
+
+	return "Unlocked " + strings.Join(unlocked, " and ") + "\n", nil
 }
 
-// RESOLVE
+// TOKENS
 
-// ResolveCommand is use to resolve an issue
-type ResolveCommand struct {
-	Project string
-	Title   string
+// TokenCommand is used to get a session token, with which the user can resume their session in another connection
+type TokenCommand struct{}
+
+// Execute fails, because a token is issued only to a logged in user
+func (tc TokenCommand) Execute() (string, error) {
+	return tc.ExecuteAs(&Session{})
 }
 
-// Execute resolves an issue
-func (rc ResolveCommand) Execute() (string, bool) {
-	if _, err := db.FindExistingProject(rc.Project); err != nil {
-		return "Could not find project \n", false
+// ExecuteAs issues a session token to the user logged in the session
+func (tc TokenCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
 	}
 
-	resolvableIssue, err := db.FindExistingIssue(rc.Project, rc.Title)
+	newToken, secret, err := token.New(session.Username, token.SessionLifetime)
 	if err != nil {
-		return "Could not resolve issue - issue does not exist \n", false
+		log.Println(err)
+		return "Could not issue a token\n", ErrInternal
+	}
+
+	db.InsertToken(newToken)
+	session.TokenHash = newToken.Hash
+	return "Token: " + secret + "\n", nil
+}
+
+// ResumeCommand is used to log a user in with a session token instead of their password
+type ResumeCommand struct {
+	Token string
+}
+
+// Scope is the scope of a personal access token needed to resume a session
+func (rc ResumeCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// AllowsAnonymous is true, because the command logs the user in with a session token
+func (rc ResumeCommand) AllowsAnonymous() bool {
+	return true
+}
+
+// Execute checks a session token
+func (rc ResumeCommand) Execute() (string, error) {
+	return rc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs logs the owner of a session token in the session
+func (rc ResumeCommand) ExecuteAs(session *Session) (string, error) {
+	existingToken, err := db.FindToken(token.Hash(rc.Token))
+	if err != nil || existingToken.Expired() {
+		return InvalidToken, ErrUnauthenticated
+	}
+
+	session.Authenticate(existingToken)
+	session.TokenHash = existingToken.Hash
+	return "Login successful as " + existingToken.Username + "\n", nil
+}
+
+// LogoutCommand is used to log a user out and revoke the session token they are using
+type LogoutCommand struct{}
+
+// Scope is the scope of a personal access token needed to log out
+func (lc LogoutCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// Execute fails, because only a logged in user can log out
+func (lc LogoutCommand) Execute() (string, error) {
+	return lc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs logs the user out of the session and revokes the token of the session, so that it can't be resumed
+func (lc LogoutCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
 	}
 
-	if resolvableIssue.Resolved == "true" {
-		return "Issue is already resolved \n", false
+	// Personal access tokens outlive the sessions they open, until they expire or are revoked
+	if session.TokenHash != "" && len(session.Scopes) == 0 {
+		db.DeleteToken(session.TokenHash)
 	}
 
-	db.ResolveIssue(resolvableIssue.Project, resolvableIssue.Title)
-	return "Issue resolved successfully\n", true
+	session.Username = ""
+	session.TokenHash = ""
+	session.Scopes = nil
+	return "Successfully logged out\n", nil
 }
 
-// LIST
+// ScopedCommand is a command which declares the scope of a personal access token it needs. The commands which don't
+// declare one change the credentials of their user, so a token can't execute them and a leaked token can't be turned
+// into a password or into more tokens
+type ScopedCommand interface {
+	Command
+	Scope() token.Scope
+}
 
-// ListCommand is used to list all issues in a project
-type ListCommand struct {
-	Project string
+// AnonymousCommand is a command which changes data, but is executed before logging in, e.g. to log in
+type AnonymousCommand interface {
+	ScopedCommand
+	AllowsAnonymous() bool
 }
 
-// Execute lists all issues in a project
-func (lc ListCommand) Execute() (string, bool) {
-	if _, err := db.FindExistingProject(lc.Project); err != nil {
-		return "Could not find project \n", false
+// requiresLogin checks whether a command changes data, which only a logged in user can do, just as only a token
+// with more than the read scope can
+func requiresLogin(c Command) bool {
+	if request, ok := c.(DataRequest); ok {
+		return requiresLogin(request.Command)
 	}
 
-	issues := db.ListIssues(lc.Project)
-	if len(issues) == 0 {
-		return "There aren't any issues in this project\n", true
+	if anonymous, ok := c.(AnonymousCommand); ok && anonymous.AllowsAnonymous() {
+		return false
 	}
 
-	issuesTitles := "Issues in project: "
-	for _, issue := range issues {
-		issuesTitles += issue.Title + ", "
+	scoped, ok := c.(ScopedCommand)
+	return ok && scoped.Scope() != token.ScopeRead
+}
+
+// checkScope checks whether the scopes of a personal access token allow a command
+func checkScope(c Command, scopes []token.Scope) (string, error) {
+	if request, ok := c.(DataRequest); ok {
+		return checkScope(request.Command, scopes)
+	}
+
+	scoped, ok := c.(ScopedCommand)
+	if !ok {
+		return CredentialsWithToken, ErrForbidden
+	}
+
+	if !token.Allows(scopes, scoped.Scope()) {
+		return MissingScope, ErrForbidden
 	}
 
-	return issuesTitles[:len(issuesTitles)-2] + "\n", true
+	return "", nil
 }
 
-// FIND
+// CreateAccessTokenCommand is used to create a personal access token, with which scripts authenticate instead of a password
+type CreateAccessTokenCommand struct {
+	// Username is the owner of the token, the logged in user when empty. Only administrators create tokens for others,
+	// and only for service accounts
+	Username string
+	Name     string
+	Scopes   string
+	// Days is how many days the token is valid, token.AccessLifetime when empty
+	Days string
+}
 
-// FindCommand is used to find the details for an issue in a project
-type FindCommand struct {
-	Project string
-	Title   string
+// Execute fails, because only a logged in user can create a token
+func (cc CreateAccessTokenCommand) Execute() (string, error) {
+	return cc.ExecuteAs(&Session{})
 }
 
-// Execute finds the details for an issue in a project
-func (fc FindCommand) Execute() (string, bool) {
-	if _, err := db.FindExistingProject(fc.Project); err != nil {
-		return "Could not find project \n", false
+// ExecuteAs creates a personal access token and shows its secret, which is not stored and can't be shown again
+func (cc CreateAccessTokenCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
 	}
 
-	foundIssue, err := db.FindExistingIssue(fc.Project, fc.Title)
+	owner := cc.Username
+	if owner == "" {
+		owner = session.Username
+	}
+	if owner != session.Username && !isAdmin(session.Username) {
+		return "Could not create token - administrator rights are required to create tokens for other users\n", ErrForbidden
+	}
+
+	if strings.TrimSpace(cc.Name) == "" {
+		return "Could not create token - a name is required\n", ErrInvalid
+	}
+
+	scopes, err := token.ParseScopes(cc.Scopes)
 	if err != nil {
-		return "Issue does not exist \n", false
+		return "Could not create token - " + err.Error() + "\n", ErrInvalid
 	}
 
-	comments := db.FindComments(fc.Project, fc.Title)
+	lifetime := token.AccessLifetime
+	if cc.Days != "" {
+		days, err := strconv.Atoi(cc.Days)
+		lifetime = time.Duration(days) * 24 * time.Hour
+		if err != nil || days < 1 || lifetime > token.MaxAccessLifetime {
+			return "Could not create token - the days should be a number between 1 and " +
+				strconv.Itoa(int(token.MaxAccessLifetime/(24*time.Hour))) + "\n", ErrInvalid
+		}
+	}
 
-	foundIssueStr := "Project: " + foundIssue.Project + "; Reporter: " +
-		foundIssue.Reporter + "; Title: " + foundIssue.Title + "; Description: " +
-		foundIssue.Description + "; Resolved: " + foundIssue.Resolved + "; Comments: "
+	ownerUser, err := db.FindRegisteredUser(owner)
+	if err != nil {
+		return UserNotFound, ErrNotFound
+	}
+	if ownerUser.Deactivated {
+		return "Could not create token - the user is deactivated\n", ErrForbidden
+	}
+	if owner != session.Username && !ownerUser.Service {
+		return "Could not create token - tokens for other users can be created only for service accounts\n", ErrForbidden
+	}
 
-	for _, comment := range comments {
-		foundIssueStr += "\"" + comment.Content + "\" - " + comment.Commenter + ";"
+	newToken, secret, err := token.New(owner, lifetime)
+	if err != nil {
+		log.Println(err)
+		return "Could not issue a token\n", ErrInternal
 	}
+	newToken.Name = strings.TrimSpace(cc.Name)
+	newToken.Scopes = scopes
 
-	return foundIssueStr + "\n", true
+	id := db.InsertAccessToken(newToken)
+	db.InsertAuditEntry(audit.NewEntry(session.Username, audit.TokenCreate, owner+" #"+strconv.Itoa(id), session.RemoteAddr))
+	return "Token #" + strconv.Itoa(id) + " for " + owner + " (" + token.FormatScopes(scopes) + "), valid until " +
+		newToken.Expires.Format("2006-01-02") + ". It is shown only once: " + secret + "\n", nil
 }
 
-// COMMENT
+// AccessTokensCommand is used to list personal access tokens without their secrets
+type AccessTokensCommand struct {
+	// Username is the owner of the tokens, the logged in user when empty. Only administrators list the tokens of others
+	Username string
+}
 
-// CommentCommand is used to create a new comment for an issue
-type CommentCommand struct {
-	Comment comment.Comment
+// Scope is the scope of a personal access token needed to list tokens
+func (ac AccessTokensCommand) Scope() token.Scope {
+	return token.ScopeRead
 }
 
-// Execute creates a new comment comment for an issue
-func (cc CommentCommand) Execute() (string, bool) {
-	if _, err := db.FindExistingProject(cc.Comment.Project); err != nil {
-		return "Could not find project \n", false
+// Execute fails, because only a logged in user can list tokens
+func (ac AccessTokensCommand) Execute() (string, error) {
+	return ac.ExecuteAs(&Session{})
+}
+
+// ExecuteAs lists the personal access tokens of a user
+func (ac AccessTokensCommand) ExecuteAs(session *Session) (string, error) {
+	data, message, err := ac.DataAs(session)
+	if err != nil {
+		return message, err
 	}
 
-	_, err := db.FindExistingIssue(cc.Comment.Project, cc.Comment.Title)
+	tokens := data.([]token.Token)
+	if len(tokens) == 0 {
+		return "There aren't any access tokens\n", nil
+	}
+
+	descriptions := make([]string, len(tokens))
+	for i, t := range tokens {
+		descriptions[i] = "#" + strconv.Itoa(t.ID) + " " + t.Name + " for " + t.Username + " (" + token.FormatScopes(t.Scopes) +
+			"), valid until " + t.Expires.Format("2006-01-02")
+		if t.Expired() {
+			descriptions[i] += " [expired]"
+		}
+	}
+
+	return "Access tokens: " + strings.Join(descriptions, "; ") + "\n", nil
+}
+
+// Data fails, because only a logged in user can list tokens
+func (ac AccessTokensCommand) Data() (interface{}, string, error) {
+	return ac.DataAs(&Session{})
+}
+
+// DataAs returns the personal access tokens of a user
+func (ac AccessTokensCommand) DataAs(session *Session) (interface{}, string, error) {
+	if session.Username == "" {
+		return nil, NotLoggedIn, ErrUnauthenticated
+	}
+
+	owner := ac.Username
+	if owner == "" {
+		owner = session.Username
+	}
+	if owner != session.Username && !isAdmin(session.Username) {
+		return nil, "Could not list tokens - administrator rights are required to list the tokens of other users\n", ErrForbidden
+	}
+
+	tokens := db.FindAccessTokens(owner)
+	if tokens == nil {
+		tokens = []token.Token{}
+	}
+
+	return tokens, "", nil
+}
+
+// RevokeAccessTokenCommand is used to revoke a personal access token, e.g. when it leaks
+type RevokeAccessTokenCommand struct {
+	ID int
+}
+
+// Execute fails, because only a logged in user can revoke a token
+func (rc RevokeAccessTokenCommand) Execute() (string, error) {
+	return rc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs revokes a personal access token of the logged in user, or of any user if they are an administrator
+func (rc RevokeAccessTokenCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
+	}
+
+	existingToken, err := db.FindAccessToken(rc.ID)
 	if err != nil {
-		return "Issue does not exist \n", false
+		return "Could not revoke token - there is no token #" + strconv.Itoa(rc.ID) + "\n", ErrNotFound
+	}
+
+	if existingToken.Username != session.Username && !isAdmin(session.Username) {
+		return "Could not revoke token - only its owner or an administrator can revoke it\n", ErrForbidden
+	}
+
+	db.DeleteAccessToken(rc.ID)
+	db.InsertAuditEntry(audit.NewEntry(session.Username, audit.TokenRevoke, existingToken.Username+" #"+strconv.Itoa(rc.ID), session.RemoteAddr))
+	return "Token #" + strconv.Itoa(rc.ID) + " revoked successfully\n", nil
+}
+
+// ServiceAccountCommand is used by an administrator to create a user without a password, e.g. for CI, which
+// authenticates only with the personal access tokens the administrator creates for it
+type ServiceAccountCommand struct {
+	Username string
+}
+
+// Execute fails, because only an administrator can create a service account
+func (sc ServiceAccountCommand) Execute() (string, error) {
+	return sc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs creates a service account
+func (sc ServiceAccountCommand) ExecuteAs(session *Session) (string, error) {
+	if !isAdmin(session.Username) {
+		return "Could not create service account - administrator rights are required\n", ErrForbidden
+	}
+
+	if err := user.ValidateUsername(sc.Username); err != nil {
+		return "Could not create service account - " + err.Error() + "\n", ErrInvalid
+	}
+
+	if err := db.InsertRegisteredUser(user.User{Username: sc.Username, Service: true}); err == db.ErrDuplicate {
+		return UsernameTaken, ErrExists
+	}
+
+	db.InsertAuditEntry(audit.NewEntry(session.Username, audit.ServiceAccountCreate, sc.Username, session.RemoteAddr))
+	return "Service account " + sc.Username + " created successfully\n", nil
+}
+
+// PASSWORDS
+
+// PasswdCommand is used to change the password of the logged in user, confirming it with the current one
+type PasswdCommand struct {
+	OldPassword string
+	NewPassword string
+}
+
+// Execute fails, because only a logged in user can change their password
+func (pc PasswdCommand) Execute() (string, error) {
+	return pc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs changes the password of the user logged in the session, ends their other sessions and revokes their
+// personal access tokens, which may have leaked along with the old password
+func (pc PasswdCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
+	}
+
+	// Changing the password is as much a way to guess it as logging in, so it is limited the same way
+	if lockout.Wait(lockout.AccountKey(session.Username)) > 0 || lockout.Wait(lockout.AddressKey(session.RemoteAddr)) > 0 {
+		return "Could not change password - too many failed attempts, try again later\n", ErrTooManyRequests
+	}
+
+	registeredUser, err := db.FindRegisteredUser(session.Username)
+	if err == nil && registeredUser.Provider != "" {
+		return "Could not change password - it is managed by the " + registeredUser.Provider + " authentication provider\n", ErrForbidden
+	}
+
+	if err != nil || !user.ComparePasswords(registeredUser.Password, pc.OldPassword) {
+		failLogin(session.Username, session)
+		return WrongPassword, ErrForbidden
+	}
+
+	if err := user.ValidatePassword(session.Username, pc.NewPassword); err != nil {
+		return "Could not change password - " + err.Error() + "\n", ErrInvalid
+	}
+
+	db.UpdatePassword(session.Username, user.HashAndSalt(pc.NewPassword))
+	db.DeleteUserTokens(session.Username, session.TokenHash)
+	db.InsertAuditEntry(audit.NewEntry(session.Username, audit.PasswordChange, session.Username, session.RemoteAddr))
+	return "Password changed successfully\n", nil
+}
+
+// ResetPasswordCommand is used by an administrator to issue a one-time token, with which a user sets a new password
+type ResetPasswordCommand struct {
+	Username string
+}
+
+// Scope is the scope of a personal access token needed to issue a password reset token
+func (rc ResetPasswordCommand) Scope() token.Scope {
+	return token.ScopeAdmin
+}
+
+// Execute fails, because only an administrator can reset a password
+func (rc ResetPasswordCommand) Execute() (string, error) {
+	return rc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs issues a reset token for a user, replacing their earlier ones. The administrator passes it on to the user
+func (rc ResetPasswordCommand) ExecuteAs(session *Session) (string, error) {
+	if !isAdmin(session.Username) {
+		return "Could not reset password - administrator rights are required\n", ErrForbidden
+	}
+
+	resetUser, err := db.FindRegisteredUser(rc.Username)
+	if err != nil {
+		return "Could not reset password - user does not exist\n", ErrNotFound
+	}
+
+	if resetUser.Service {
+		return "Could not reset password - service accounts authenticate only with access tokens\n", ErrForbidden
+	}
+
+	if resetUser.Provider != "" {
+		return "Could not reset password - it is managed by the " + resetUser.Provider + " authentication provider\n", ErrForbidden
+	}
+
+	resetToken, secret, err := token.New(rc.Username, token.ResetLifetime)
+	if err != nil {
+		log.Println(err)
+		return "Could not issue a reset token\n", ErrInternal
+	}
+
+	db.InsertResetToken(resetToken)
+	db.InsertAuditEntry(audit.NewEntry(session.Username, audit.PasswordReset, rc.Username, session.RemoteAddr))
+	return "Reset token for " + rc.Username + " (valid for " + token.ResetLifetime.String() + "): " + secret + "\n", nil
+}
+
+// SetPasswordCommand is used to set a new password with a reset token issued by an administrator
+type SetPasswordCommand struct {
+	Username string
+	Token    string
+	Password string
+}
+
+// Scope is the scope of a personal access token needed to set a password with a reset token
+func (sc SetPasswordCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// AllowsAnonymous is true, because the command authenticates with a reset token instead
+func (sc SetPasswordCommand) AllowsAnonymous() bool {
+	return true
+}
+
+// Execute sets a new password of a user, using up the reset token
+func (sc SetPasswordCommand) Execute() (string, error) {
+	return sc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs sets a new password of a user, using up the reset token, ends all their sessions and revokes their
+// personal access tokens
+func (sc SetPasswordCommand) ExecuteAs(session *Session) (string, error) {
+	if err := user.ValidatePassword(sc.Username, sc.Password); err != nil {
+		return "Could not set password - " + err.Error() + "\n", ErrInvalid
+	}
+
+	resetToken, err := db.TakeResetToken(token.Hash(sc.Token))
+	if err != nil || resetToken.Expired() || resetToken.Username != sc.Username {
+		return InvalidResetToken, ErrForbidden
+	}
+
+	db.UpdatePassword(sc.Username, user.HashAndSalt(sc.Password))
+	db.DeleteUserTokens(sc.Username, "")
+	db.InsertAuditEntry(audit.NewEntry(sc.Username, audit.PasswordChange, sc.Username, session.RemoteAddr))
+	return "Password set successfully. You can now log in with it\n", nil
+}
+
+// USERS
+
+// describeUser formats the profile of a user
+func describeUser(u user.User) string {
+	description := "User: " + u.Username + "; "
+	if u.DisplayName != "" {
+		description += "Name: " + u.DisplayName + "; "
+	}
+	if u.Email != "" {
+		description += "Email: " + u.Email + "; "
+	}
+	if u.Timezone != "" {
+		description += "Timezone: " + u.Timezone + "; "
+	}
+
+	return description + "Role: " + u.Role() + "; Status: " + u.Status() + "\n"
+}
+
+// WhoamiCommand is used to show the profile of the logged in user
+type WhoamiCommand struct{}
+
+// Scope is the scope of a personal access token needed to show the own profile
+func (wc WhoamiCommand) Scope() token.Scope {
+	return token.ScopeRead
+}
+
+// Execute fails, because nobody is logged in outside of a session
+func (wc WhoamiCommand) Execute() (string, error) {
+	return wc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs shows the profile of the user logged in the session
+func (wc WhoamiCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
+	}
+
+	loggedUser, err := db.FindRegisteredUser(session.Username)
+	if err != nil {
+		return UserNotFound, ErrNotFound
+	}
+
+	return describeUser(withRole(loggedUser)), nil
+}
+
+// ShowUserCommand is used to show the profile of a user
+type ShowUserCommand struct {
+	Username string
+}
+
+// Scope is the scope of a personal access token needed to show the profile of a user
+func (sc ShowUserCommand) Scope() token.Scope {
+	return token.ScopeRead
+}
+
+// Execute fails, because only a logged in user can view the profiles of users
+func (sc ShowUserCommand) Execute() (string, error) {
+	return sc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs shows the profile of a user to the user logged in the session
+func (sc ShowUserCommand) ExecuteAs(session *Session) (string, error) {
+	data, message, err := sc.DataAs(session)
+	if err != nil {
+		return message, err
+	}
+
+	return describeUser(data.(user.User)), nil
+}
+
+// Data fails, because only a logged in user can view the profiles of users
+func (sc ShowUserCommand) Data() (interface{}, string, error) {
+	return sc.DataAs(&Session{})
+}
+
+// DataAs returns the profile of a user as the user logged in the session may see it
+func (sc ShowUserCommand) DataAs(session *Session) (interface{}, string, error) {
+	if session.Username == "" {
+		return nil, NotLoggedIn, ErrUnauthenticated
+	}
+
+	foundUser, err := db.FindRegisteredUser(sc.Username)
+	if err != nil {
+		return nil, UserNotFound, ErrNotFound
+	}
+
+	return visibleTo(withRole(foundUser), session.Username, isAdmin(session.Username)), "", nil
+}
+
+// UsersCommand is used to list all users
+type UsersCommand struct{}
+
+// Scope is the scope of a personal access token needed to list the users
+func (uc UsersCommand) Scope() token.Scope {
+	return token.ScopeRead
+}
+
+// Execute fails, because only a logged in user can list the users
+func (uc UsersCommand) Execute() (string, error) {
+	return uc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs lists the names of all users to the user logged in the session, marking the deactivated ones
+func (uc UsersCommand) ExecuteAs(session *Session) (string, error) {
+	data, message, err := uc.DataAs(session)
+	if err != nil {
+		return message, err
+	}
+
+	users := data.([]user.User)
+	if len(users) == 0 {
+		return "There aren't any users\n", nil
+	}
+
+	names := make([]string, len(users))
+	for i, u := range users {
+		names[i] = u.Name()
+		if u.Admin {
+			names[i] += " [admin]"
+		}
+		if u.Deactivated {
+			names[i] += " [deactivated]"
+		}
+	}
+
+	return "Users: " + strings.Join(names, ", ") + "\n", nil
+}
+
+// Data fails, because only a logged in user can list the users
+func (uc UsersCommand) Data() (interface{}, string, error) {
+	return uc.DataAs(&Session{})
+}
+
+// DataAs lists all users as the user logged in the session may see them
+func (uc UsersCommand) DataAs(session *Session) (interface{}, string, error) {
+	if session.Username == "" {
+		return nil, NotLoggedIn, ErrUnauthenticated
+	}
+
+	admin := isAdmin(session.Username)
+	users := db.ListUsers()
+	for i := range users {
+		users[i] = visibleTo(withRole(users[i]), session.Username, admin)
+	}
+	if users == nil {
+		users = []user.User{}
+	}
+
+	return users, "", nil
+}
+
+// visibleTo hides the email of a user from everyone except the user themselves and the administrators
+func visibleTo(u user.User, viewer string, viewerIsAdmin bool) user.User {
+	if u.Username != viewer && !viewerIsAdmin {
+		u.Email = ""
+	}
+
+	return u
+}
+
+// ProfileCommand is used to change the display name, the email and the timezone of the logged in user
+type ProfileCommand struct {
+	DisplayName string
+	Email       string
+	Timezone    string
+}
+
+// Scope is the scope of a personal access token needed to change the own profile
+func (pc ProfileCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// Execute fails, because only a logged in user can change their profile
+func (pc ProfileCommand) Execute() (string, error) {
+	return pc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs replaces the profile of the user logged in the session. Empty fields clear the ones in the profile
+func (pc ProfileCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
+	}
+
+	if err := user.ValidateProfile(pc.DisplayName, pc.Email, pc.Timezone); err != nil {
+		return "Could not update profile - " + err.Error() + "\n", ErrInvalid
+	}
+
+	db.UpdateProfile(session.Username, pc.DisplayName, pc.Email, pc.Timezone)
+	return "Profile updated successfully\n", nil
+}
+
+// DeactivateCommand is used by an administrator to keep a user from logging in, while keeping their issues and comments
+type DeactivateCommand struct {
+	Username string
+}
+
+// Scope is the scope of a personal access token needed to deactivate a user
+func (dc DeactivateCommand) Scope() token.Scope {
+	return token.ScopeAdmin
+}
+
+// Execute fails, because only an administrator can deactivate a user
+func (dc DeactivateCommand) Execute() (string, error) {
+	return dc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs deactivates a user, ends all their sessions, revokes their personal access tokens and disconnects their clients
+func (dc DeactivateCommand) ExecuteAs(session *Session) (string, error) {
+	if !isAdmin(session.Username) {
+		return "Could not deactivate user - administrator rights are required\n", ErrForbidden
+	}
+
+	if dc.Username == session.Username {
+		return "Could not deactivate user - you can't deactivate yourself\n", ErrInvalid
+	}
+
+	deactivatedUser, err := db.FindRegisteredUser(dc.Username)
+	if err != nil {
+		return UserNotFound, ErrNotFound
+	}
+
+	if deactivatedUser.Deactivated {
+		return "User is already deactivated\n", ErrConflict
+	}
+
+	db.SetDeactivated(dc.Username, true)
+	db.DeleteUserTokens(dc.Username, "")
+	// A TCP session stays logged in without its token, so the connections of the user are closed as well
+	clients.DisconnectUser(deactivatedUser.Username)
+	db.InsertAuditEntry(audit.NewEntry(session.Username, audit.Deactivate, dc.Username, session.RemoteAddr))
+	return "User " + dc.Username + " deactivated successfully\n", nil
+}
+
+// ActivateCommand is used by an administrator to let a deactivated user log in again
+type ActivateCommand struct {
+	Username string
+}
+
+// Scope is the scope of a personal access token needed to activate a user
+func (ac ActivateCommand) Scope() token.Scope {
+	return token.ScopeAdmin
+}
+
+// Execute fails, because only an administrator can activate a user
+func (ac ActivateCommand) Execute() (string, error) {
+	return ac.ExecuteAs(&Session{})
+}
+
+// ExecuteAs activates a deactivated user
+func (ac ActivateCommand) ExecuteAs(session *Session) (string, error) {
+	if !isAdmin(session.Username) {
+		return "Could not activate user - administrator rights are required\n", ErrForbidden
+	}
+
+	activatedUser, err := db.FindRegisteredUser(ac.Username)
+	if err != nil {
+		return UserNotFound, ErrNotFound
+	}
+
+	if !activatedUser.Deactivated {
+		return "User is already active\n", ErrConflict
+	}
+
+	db.SetDeactivated(ac.Username, false)
+	db.InsertAuditEntry(audit.NewEntry(session.Username, audit.Activate, ac.Username, session.RemoteAddr))
+	return "User " + ac.Username + " activated successfully\n", nil
+}
+
+// PROJECT
+
+// ProjectCommand is used to create a new project
+type ProjectCommand struct {
+	Project project.Project
+}
+
+// Scope is the scope of a personal access token needed to create a project
+func (pc ProjectCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// Execute fails, because only a logged in user can create a project
+func (pc ProjectCommand) Execute() (string, error) {
+	return pc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs creates a new project owned by the user logged in the session
+func (pc ProjectCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
+	}
+
+	newProject := project.Project{
+		Name:  pc.Project.Name,
+		Owner: session.Username}
+
+	if _, err := db.FindExistingProject(newProject.Name); err == nil {
+		return ProjectNameTaken, ErrExists
+	}
+
+	if err := db.InsertNewProject(newProject); err == db.ErrDuplicate {
+		return ProjectNameTaken, ErrExists
+	}
+
+	db.InsertAuditEntry(audit.NewEntry(session.Username, audit.ProjectCreate, newProject.Name, session.RemoteAddr))
+	return "Project created successfully\n", nil
+}
+
+// PROJECTS
+
+// ProjectsCommand is used to list all projects
+type ProjectsCommand struct{}
+
+// Scope is the scope of a personal access token needed to list the projects
+func (pc ProjectsCommand) Scope() token.Scope {
+	return token.ScopeRead
+}
+
+// Execute lists the names of all projects
+func (pc ProjectsCommand) Execute() (string, error) {
+	projects := db.ListProjects()
+	if len(projects) == 0 {
+		return "There aren't any projects\n", nil
+	}
+
+	names := make([]string, len(projects))
+	for i, p := range projects {
+		names[i] = p.Name
+	}
+
+	return "Projects: " + strings.Join(names, ", ") + "\n", nil
+}
+
+// Data lists all projects
+func (pc ProjectsCommand) Data() (interface{}, string, error) {
+	projects := db.ListProjects()
+	if projects == nil {
+		projects = []project.Project{}
+	}
+
+	return projects, "", nil
+}
+
+// ISSUE
+
+// IssueCommand is used to create a new issue in a project
+type IssueCommand struct {
+	Issue issue.Issue
+}
+
+// Scope is the scope of a personal access token needed to create an issue
+func (ic IssueCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// Execute fails, because only a logged in user can create an issue
+func (ic IssueCommand) Execute() (string, error) {
+	return ic.ExecuteAs(&Session{})
+}
+
+// ExecuteAs creates a new issue in a project, reported by the user logged in the session
+func (ic IssueCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
+	}
+
+	newIssue := issue.Issue{
+		Project:     ic.Issue.Project,
+		Reporter:    session.Username,
+		Title:       ic.Issue.Title,
+		Description: ic.Issue.Description,
+		Status:      issue.StatusOpen}
+
+	if _, err := db.FindExistingProject(newIssue.Project); err != nil {
+		return ProjectNotFound, ErrNotFound
+	}
+
+	if _, err := db.FindExistingIssue(newIssue.Project, newIssue.Title); err == nil {
+		return IssueTitleTaken, ErrExists
+	}
+
+	if err := db.InsertNewIssue(newIssue); err == db.ErrDuplicate {
+		return IssueTitleTaken, ErrExists
+	}
+
+	db.InsertWatch(watch.Watch{Username: session.Username, Project: newIssue.Project, Title: newIssue.Title})
+
+	mentioned := notifyMentions(session.Username, newIssue.Project, newIssue.Title, newIssue.Description, "the description", nil)
+	notifyWatchers(session.Username, newIssue.Project, newIssue.Title, notification.IssueCreated,
+		session.Username+" created issue '"+newIssue.Title+"' in project '"+newIssue.Project+"'", mentioned)
+	fireWebhooks(webhook.IssueCreated, session.Username, newIssue.Project, newIssue.Title, &newIssue, nil)
+	return "Issue created successfully\n", nil
+}
+
+// RESOLVE
+
+// ResolveCommand is use to resolve an issue
+type ResolveCommand struct {
+	Project string
+	Title   string
+}
+
+// Scope is the scope of a personal access token needed to resolve an issue
+func (rc ResolveCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// Execute fails, because only a logged in user can resolve an issue
+func (rc ResolveCommand) Execute() (string, error) {
+	return rc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs resolves an issue and notifies its watchers on behalf of the user logged in the session
+func (rc ResolveCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
+	}
+
+	if _, err := db.FindExistingProject(rc.Project); err != nil {
+		return ProjectNotFound, ErrNotFound
+	}
+
+	resolvableIssue, err := db.FindExistingIssue(rc.Project, rc.Title)
+	if err != nil {
+		return ResolvableIssueNotFound, ErrNotFound
+	}
+
+	if resolvableIssue.IsResolved() {
+		return IssueAlreadyResolved, ErrConflict
+	}
+
+	db.ResolveIssue(resolvableIssue.Project, resolvableIssue.Title)
+	notifyWatchers(session.Username, resolvableIssue.Project, resolvableIssue.Title, notification.IssueResolved,
+		session.Username+" resolved issue '"+resolvableIssue.Title+"' in project '"+resolvableIssue.Project+"'", nil)
+
+	resolvableIssue.Status = issue.StatusResolved
+	fireWebhooks(webhook.IssueResolved, session.Username, resolvableIssue.Project, resolvableIssue.Title, &resolvableIssue, nil)
+	return "Issue resolved successfully\n", nil
+}
+
+// ASSIGN
+
+// AssignCommand is used to assign an issue to a user
+type AssignCommand struct {
+	Project  string
+	Title    string
+	Assignee string
+}
+
+// Scope is the scope of a personal access token needed to assign an issue
+func (ac AssignCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// Execute fails, because only a logged in user can assign an issue
+func (ac AssignCommand) Execute() (string, error) {
+	return ac.ExecuteAs(&Session{})
+}
+
+// ExecuteAs assigns an issue to a user and notifies them and the watchers of the issue on behalf of the user logged in the session
+func (ac AssignCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
+	}
+
+	if _, err := db.FindExistingProject(ac.Project); err != nil {
+		return ProjectNotFound, ErrNotFound
+	}
+
+	assignableIssue, err := db.FindExistingIssue(ac.Project, ac.Title)
+	if err != nil {
+		return IssueNotFound, ErrNotFound
+	}
+
+	if _, err := db.FindRegisteredUser(ac.Assignee); err != nil {
+		return "Could not assign issue - user does not exist\n", ErrNotFound
+	}
+
+	if assignableIssue.Assignee == ac.Assignee {
+		return "Issue is already assigned to " + ac.Assignee + "\n", ErrConflict
+	}
+
+	db.AssignIssue(ac.Project, ac.Title, ac.Assignee)
+	db.InsertWatch(watch.Watch{Username: ac.Assignee, Project: ac.Project, Title: ac.Title})
+
+	assigned := []string{}
+	if ac.Assignee != session.Username {
+		message := session.Username + " assigned you issue '" + ac.Title + "' in project '" + ac.Project + "'"
+		deliver(notification.New(ac.Assignee, session.Username, notification.Assignment, ac.Project, ac.Title, message))
+		assigned = append(assigned, ac.Assignee)
+	}
+
+	notifyWatchers(session.Username, ac.Project, ac.Title, notification.IssueUpdated,
+		session.Username+" assigned issue '"+ac.Title+"' in project '"+ac.Project+"' to "+ac.Assignee, assigned)
+
+	assignableIssue.Assignee = ac.Assignee
+	fireWebhooks(webhook.IssueUpdated, session.Username, ac.Project, ac.Title, &assignableIssue, nil)
+	return "Issue assigned successfully\n", nil
+}
+
+// LABEL
+
+// LabelCommand is used to replace the labels of an issue with a comma separated list of labels
+type LabelCommand struct {
+	Project string
+	Title   string
+	Labels  string
+}
+
+// Scope is the scope of a personal access token needed to label an issue
+func (lc LabelCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// Execute fails, because only a logged in user can label an issue
+func (lc LabelCommand) Execute() (string, error) {
+	return lc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs replaces the labels of an issue and notifies its watchers on behalf of the user logged in the session
+func (lc LabelCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
+	}
+
+	if _, err := db.FindExistingProject(lc.Project); err != nil {
+		return ProjectNotFound, ErrNotFound
+	}
+
+	labelledIssue, err := db.FindExistingIssue(lc.Project, lc.Title)
+	if err != nil {
+		return IssueNotFound, ErrNotFound
+	}
+
+	labels := issue.NormalizeLabels(lc.Labels)
+	if labels == labelledIssue.Labels {
+		return "Issue already has these labels\n", ErrConflict
+	}
+
+	db.LabelIssue(lc.Project, lc.Title, labels)
+
+	change := "removed the labels of issue '" + lc.Title + "' in project '" + lc.Project + "'"
+	if labels != "" {
+		change = "labelled issue '" + lc.Title + "' in project '" + lc.Project + "' as " + labels
+	}
+	notifyWatchers(session.Username, lc.Project, lc.Title, notification.IssueUpdated, session.Username+" "+change, nil)
+
+	labelledIssue.Labels = labels
+	fireWebhooks(webhook.IssueUpdated, session.Username, lc.Project, lc.Title, &labelledIssue, nil)
+	return "Issue labels changed successfully\n", nil
+}
+
+// LIST
+
+// ListCommand is used to list all issues in a project
+type ListCommand struct {
+	Project string
+}
+
+// Scope is the scope of a personal access token needed to list the issues in a project
+func (lc ListCommand) Scope() token.Scope {
+	return token.ScopeRead
+}
+
+// Execute lists all issues in a project
+func (lc ListCommand) Execute() (string, error) {
+	issues, message, err := lc.Issues()
+	if err != nil {
+		return message, err
+	}
+
+	if len(issues) == 0 {
+		return "There aren't any issues in this project\n", nil
+	}
+
+	issuesTitles := "Issues in project: "
+	for _, issue := range issues {
+		issuesTitles += issue.Title + ", "
+	}
+
+	return issuesTitles[:len(issuesTitles)-2] + "\n", nil
+}
+
+// Data lists all issues in a project
+func (lc ListCommand) Data() (interface{}, string, error) {
+	issues, message, err := lc.Issues()
+	if issues == nil {
+		issues = []issue.Issue{}
+	}
+
+	return issues, message, err
+}
+
+// Issues finds all issues in a project, or a failure message if there is no such project
+func (lc ListCommand) Issues() ([]issue.Issue, string, error) {
+	if _, err := db.FindExistingProject(lc.Project); err != nil {
+		return nil, ProjectNotFound, ErrNotFound
+	}
+
+	return db.ListIssues(lc.Project), "", nil
+}
+
+// FIND
+
+// FindCommand is used to find the details for an issue in a project
+type FindCommand struct {
+	Project string
+	Title   string
+}
+
+// Scope is the scope of a personal access token needed to find an issue
+func (fc FindCommand) Scope() token.Scope {
+	return token.ScopeRead
+}
+
+// IssueDetails are an issue with its comments in thread order, as returned for a find command
+type IssueDetails struct {
+	Issue    issue.Issue           `json:"issue"`
+	Comments []comment.ThreadEntry `json:"comments"`
+}
+
+// Execute finds the details for an issue in a project
+func (fc FindCommand) Execute() (string, error) {
+	foundIssue, comments, message, err := fc.Details()
+	if err != nil {
+		return message, err
+	}
+
+	foundIssueStr := "Project: " + foundIssue.Project + "; Reporter: " +
+		foundIssue.Reporter + "; Title: " + foundIssue.Title + "; Description: " +
+		foundIssue.Description + "; Status: " + string(foundIssue.Status) + "; "
+
+	if foundIssue.Assignee != "" {
+		foundIssueStr += "Assignee: " + foundIssue.Assignee + "; "
+	}
+
+	foundIssueStr += "Comments: "
+
+	for _, entry := range comment.Thread(comments) {
+		foundIssueStr += formatThreadEntry(entry) + ";"
+	}
+
+	return foundIssueStr + "\n", nil
+}
+
+// Data finds the details for an issue in a project
+func (fc FindCommand) Data() (interface{}, string, error) {
+	foundIssue, comments, message, err := fc.Details()
+	if err != nil {
+		return nil, message, err
+	}
+
+	thread := comment.Thread(comments)
+	if thread == nil {
+		thread = []comment.ThreadEntry{}
+	}
+
+	return IssueDetails{Issue: foundIssue, Comments: thread}, "", nil
+}
+
+// Details finds an issue in a project together with its comments, or a failure message if there is no such issue
+func (fc FindCommand) Details() (issue.Issue, []comment.Comment, string, error) {
+	if _, err := db.FindExistingProject(fc.Project); err != nil {
+		return issue.Issue{}, nil, ProjectNotFound, ErrNotFound
+	}
+
+	foundIssue, err := db.FindExistingIssue(fc.Project, fc.Title)
+	if err != nil {
+		return issue.Issue{}, nil, IssueNotFound, ErrNotFound
+	}
+
+	return foundIssue, db.FindComments(fc.Project, fc.Title), "", nil
+}
+
+// formatThreadEntry formats a comment as a part of a thread, where every level of replies is marked with '>'
+func formatThreadEntry(entry comment.ThreadEntry) string {
+	c := entry.Comment
+	entryStr := strings.Repeat(">", entry.Depth)
+	if entry.Depth > 0 {
+		entryStr += " "
+	}
+
+	entryStr += "#" + strconv.Itoa(c.ID) + " " + c.Created.Format("2006-01-02 15:04") + " "
+	if c.Deleted {
+		return entryStr + "[deleted]"
+	}
+
+	entryStr += "\"" + c.Content + "\" - " + c.Commenter
+	if !c.Edited.IsZero() {
+		entryStr += " (edited)"
+	}
+
+	return entryStr
+}
+
+// COMMENT
+
+// CommentCommand is used to create a new comment for an issue
+type CommentCommand struct {
+	Comment comment.Comment
+}
+
+// Scope is the scope of a personal access token needed to comment an issue
+func (cc CommentCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// Execute fails, because only a logged in user can comment
+func (cc CommentCommand) Execute() (string, error) {
+	return cc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs creates a new comment for an issue on behalf of the user logged in the session
+func (cc CommentCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
+	}
+
+	if _, err := db.FindExistingProject(cc.Comment.Project); err != nil {
+		return ProjectNotFound, ErrNotFound
+	}
+
+	_, err := db.FindExistingIssue(cc.Comment.Project, cc.Comment.Title)
+	if err != nil {
+		return IssueNotFound, ErrNotFound
+	}
+
+	newComment := cc.Comment
+	newComment.Commenter = session.Username
+	newComment.Created = time.Now().UTC()
+
+	if newComment.Parent != 0 {
+		parent, err := db.FindComment(newComment.Parent)
+		if err != nil || parent.Project != newComment.Project || parent.Title != newComment.Title {
+			return "Could not reply - comment does not exist for this issue\n", ErrNotFound
+		}
+	}
+
+	db.InsertComment(newComment)
+	db.InsertWatch(watch.Watch{Username: newComment.Commenter, Project: newComment.Project, Title: newComment.Title})
+
+	mentioned := notifyMentions(newComment.Commenter, newComment.Project, newComment.Title, newComment.Content, "a comment", nil)
+	notifyWatchers(newComment.Commenter, newComment.Project, newComment.Title, notification.CommentAdded,
+		newComment.Commenter+" commented on issue '"+newComment.Title+"' in project '"+newComment.Project+"'", mentioned)
+	fireWebhooks(webhook.CommentAdded, newComment.Commenter, newComment.Project, newComment.Title, nil, &newComment)
+	return "Comment added successfully\n", nil
+}
+
+// EDIT COMMENT
+
+// EditCommentCommand is used to change the content of a comment
+type EditCommentCommand struct {
+	ID      int
+	Content string
+}
+
+// Scope is the scope of a personal access token needed to edit a comment
+func (ec EditCommentCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// Execute refuses to edit a comment, because only its author can do it
+func (ec EditCommentCommand) Execute() (string, error) {
+	return ec.ExecuteAs(&Session{})
+}
+
+// ExecuteAs edits a comment if the user logged in the session is its author
+func (ec EditCommentCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
+	}
+
+	existingComment, err := db.FindComment(ec.ID)
+	if err != nil || existingComment.Deleted {
+		return CommentNotFound, ErrNotFound
+	}
+
+	if existingComment.Commenter != session.Username {
+		return NotAuthorOfEdited, ErrForbidden
+	}
+
+	db.EditComment(existingComment.ID, ec.Content, time.Now().UTC())
+
+	mentioned := notifyMentions(existingComment.Commenter, existingComment.Project, existingComment.Title, ec.Content, "a comment",
+		notification.Mentions(existingComment.Content))
+	notifyWatchers(existingComment.Commenter, existingComment.Project, existingComment.Title, notification.IssueUpdated,
+		existingComment.Commenter+" edited a comment on issue '"+existingComment.Title+"' in project '"+existingComment.Project+"'", mentioned)
+
+	existingComment.Content = ec.Content
+	fireWebhooks(webhook.IssueUpdated, existingComment.Commenter, existingComment.Project, existingComment.Title, nil, &existingComment)
+	return "Comment edited successfully\n", nil
+}
+
+// DELETE COMMENT
+
+// DeleteCommentCommand is used to delete a comment
+type DeleteCommentCommand struct {
+	ID int
+}
+
+// Scope is the scope of a personal access token needed to delete a comment
+func (dc DeleteCommentCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// Execute refuses to delete a comment, because only its author can do it
+func (dc DeleteCommentCommand) Execute() (string, error) {
+	return dc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs deletes a comment if the user logged in the session is its author
+func (dc DeleteCommentCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
+	}
+
+	existingComment, err := db.FindComment(dc.ID)
+	if err != nil || existingComment.Deleted {
+		return CommentNotFound, ErrNotFound
+	}
+
+	if existingComment.Commenter != session.Username {
+		return NotAuthorOfDeleted, ErrForbidden
+	}
+
+	db.DeleteComment(existingComment.ID)
+	notifyWatchers(existingComment.Commenter, existingComment.Project, existingComment.Title, notification.IssueUpdated,
+		existingComment.Commenter+" deleted a comment on issue '"+existingComment.Title+"' in project '"+existingComment.Project+"'", nil)
+
+	existingComment.Content, existingComment.Deleted = "", true
+	fireWebhooks(webhook.IssueUpdated, existingComment.Commenter, existingComment.Project, existingComment.Title, nil, &existingComment)
+	return "Comment deleted successfully\n", nil
+}
+
+// NOTIFICATIONS
+
+// deliver puts a notification in the inbox of its recipient, pushes it to their connected clients
+// and emails it to them, if they want it
+func deliver(n notification.Notification) {
+	db.InsertNotification(n)
+	push.Publish(n)
+	email(n)
+}
+
+// email sends a notification by email right away or queues it for the next digest, according to the preferences of its recipient
+func email(n notification.Notification) {
+	if notifier.Default == nil {
+		return
+	}
+
+	preferences, err := db.FindPreferences(n.Recipient)
+	if err != nil || !preferences.WantsEmail(n) {
+		return
+	}
+
+	if preferences.Mode == notification.EmailDigest {
+		db.InsertDigestNotification(n)
+		return
+	}
+
+	go func() {
+		if err := notifier.Default.Notify(preferences.Email, []notification.Notification{n}); err != nil {
+			log.Println(err)
+		}
+	}()
+}
+
+// SendDigests emails every user the notifications queued for them since their last digest
+func SendDigests() {
+	if notifier.Default == nil {
+		return
+	}
+
+	for _, username := range db.FindDigestRecipients() {
+		notifications := db.TakeDigestNotifications(username)
+		preferences, err := db.FindPreferences(username)
+		if err != nil || len(notifications) == 0 || preferences.Email == "" {
+			continue
+		}
+
+		if err := notifier.Default.Notify(preferences.Email, notifications); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// EMAIL
+
+// EmailCommand is used to choose which notifications are sent by email and how.
+// Kinds is a comma-separated list of 'mentions', 'assignments' and 'status', where an empty list means all of them
+type EmailCommand struct {
+	Email string
+	Mode  string
+	Kinds string
+}
+
+// Scope is the scope of a personal access token needed to change the email preferences
+func (ec EmailCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// Execute refuses to change email preferences, because they belong to a logged in user
+func (ec EmailCommand) Execute() (string, error) {
+	return ec.ExecuteAs(&Session{})
+}
+
+// ExecuteAs changes the email preferences of the user logged in the session
+func (ec EmailCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
+	}
+
+	preferences := notification.Preferences{
+		Username: session.Username,
+		Email:    ec.Email,
+		Mode:     ec.Mode}
+
+	switch ec.Mode {
+	case notification.EmailOff:
+	case notification.EmailInstant, notification.EmailDigest:
+		if ec.Email == "" || user.ValidateProfile("", ec.Email, "") != nil {
+			return "Could not change email preferences - invalid email address\n", ErrInvalid
+		}
+	default:
+		return "Could not change email preferences - mode should be off, instant or digest\n", ErrInvalid
+	}
+
+	if strings.TrimSpace(ec.Kinds) == "" {
+		preferences.Mentions, preferences.Assignments, preferences.StatusChanges = true, true, true
+	}
+	for _, kind := range strings.Split(ec.Kinds, ",") {
+		switch strings.TrimSpace(kind) {
+		case "":
+		case "mentions":
+			preferences.Mentions = true
+		case "assignments":
+			preferences.Assignments = true
+		case "status":
+			preferences.StatusChanges = true
+		default:
+			return "Could not change email preferences - unknown kind of notification " + kind + "\n", ErrInvalid
+		}
+	}
+
+	db.SavePreferences(preferences)
+	return "Email preferences changed successfully\n", nil
+}
+
+// MENTIONS
+
+// notifyMentions delivers a notification to every registered user mentioned in a text, except for its author
+// and the users in alreadyNotified. It returns the users who were notified
+func notifyMentions(actor string, project string, title string, text string, where string, alreadyNotified []string) []string {
+	skipped := map[string]bool{actor: true}
+	for _, username := range alreadyNotified {
+		skipped[username] = true
+	}
+
+	var notified []string
+	for _, username := range notification.Mentions(text) {
+		if skipped[username] {
+			continue
+		}
+
+		if _, err := db.FindRegisteredUser(username); err != nil {
+			continue
+		}
+
+		message := actor + " mentioned you in " + where + " of issue '" + title + "' in project '" + project + "'"
+		deliver(notification.New(username, actor, notification.Mention, project, title, message))
+		notified = append(notified, username)
+	}
+
+	return notified
+}
+
+// WATCH
+
+// notifyWatchers delivers a notification about a change of an issue to everyone watching it, except for the user
+// who made the change and the users in alreadyNotified, who have been notified about the change in another way
+func notifyWatchers(actor string, project string, title string, kind string, message string, alreadyNotified []string) {
+	skipped := map[string]bool{actor: true}
+	for _, username := range alreadyNotified {
+		skipped[username] = true
+	}
+
+	for _, username := range db.FindWatchers(project, title) {
+		if !skipped[username] {
+			deliver(notification.New(username, actor, kind, project, title, message))
+		}
+	}
+}
+
+// WatchCommand is used to follow the changes of an issue or, when the title is empty, of all issues in a project
+type WatchCommand struct {
+	Project string
+	Title   string
+}
+
+// Scope is the scope of a personal access token needed to watch a project or an issue
+func (wc WatchCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// Execute refuses to watch, because watches belong to a logged in user
+func (wc WatchCommand) Execute() (string, error) {
+	return wc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs starts watching an issue or a project for the user logged in the session
+func (wc WatchCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
+	}
+
+	if _, err := db.FindExistingProject(wc.Project); err != nil {
+		return ProjectNotFound, ErrNotFound
+	}
+
+	if wc.Title != "" {
+		if _, err := db.FindExistingIssue(wc.Project, wc.Title); err != nil {
+			return IssueNotFound, ErrNotFound
+		}
+	}
+
+	db.InsertWatch(watch.Watch{Username: session.Username, Project: wc.Project, Title: wc.Title})
+	return "You are now watching " + describeWatched(wc.Project, wc.Title) + "\n", nil
+}
+
+// UnwatchCommand is used to stop following an issue or a project
+type UnwatchCommand struct {
+	Project string
+	Title   string
+}
+
+// Scope is the scope of a personal access token needed to stop watching a project or an issue
+func (uc UnwatchCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// Execute refuses to unwatch, because watches belong to a logged in user
+func (uc UnwatchCommand) Execute() (string, error) {
+	return uc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs stops watching an issue or a project for the user logged in the session
+func (uc UnwatchCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
+	}
+
+	if !db.DeleteWatch(watch.Watch{Username: session.Username, Project: uc.Project, Title: uc.Title}) {
+		return "You are not watching " + describeWatched(uc.Project, uc.Title) + "\n", ErrNotFound
+	}
+
+	return "You are no longer watching " + describeWatched(uc.Project, uc.Title) + "\n", nil
+}
+
+// describeWatched names the issue or the whole project which is the subject of a watch
+func describeWatched(project string, title string) string {
+	if title == "" {
+		return "project '" + project + "'"
+	}
+
+	return "issue '" + title + "' in project '" + project + "'"
+}
+
+// INBOX
+
+// InboxCommand is used to list the notifications delivered to a user
+type InboxCommand struct {
+	UnreadOnly bool
+}
+
+// Scope is the scope of a personal access token needed to list the notifications
+func (ic InboxCommand) Scope() token.Scope {
+	return token.ScopeRead
+}
+
+// Execute refuses to list notifications, because an inbox belongs to a logged in user
+func (ic InboxCommand) Execute() (string, error) {
+	return ic.ExecuteAs(&Session{})
+}
+
+// ExecuteAs lists the notifications delivered to the user logged in the session
+func (ic InboxCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
+	}
+
+	notifications := db.FindNotifications(session.Username, ic.UnreadOnly)
+	if len(notifications) == 0 {
+		return "There aren't any notifications in your inbox\n", nil
+	}
+
+	inboxStr := "Inbox: "
+	for _, n := range notifications {
+		inboxStr += n.String() + "; "
+	}
+
+	return inboxStr[:len(inboxStr)-2] + "\n", nil
+}
+
+// MARK READ
+
+// MarkReadCommand is used to mark a notification as read. An ID of 0 marks all notifications as read
+type MarkReadCommand struct {
+	ID int
+}
+
+// Scope is the scope of a personal access token needed to mark notifications as read
+func (mc MarkReadCommand) Scope() token.Scope {
+	return token.ScopeRead
+}
+
+// Execute refuses to mark notifications as read, because an inbox belongs to a logged in user
+func (mc MarkReadCommand) Execute() (string, error) {
+	return mc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs marks notifications in the inbox of the user logged in the session as read
+func (mc MarkReadCommand) ExecuteAs(session *Session) (string, error) {
+	if session.Username == "" {
+		return NotLoggedIn, ErrUnauthenticated
+	}
+
+	if db.MarkNotificationsRead(session.Username, mc.ID) == 0 {
+		return "There aren't any matching unread notifications\n", ErrNotFound
+	}
+
+	return "Notifications marked as read\n", nil
+}
+
+// WEBHOOKS
+
+// fireWebhooks streams an event about an issue to the subscribers of its project and posts it to all webhooks
+// registered for the project in the background
+func fireWebhooks(event string, actor string, project string, title string, changedIssue *issue.Issue, changedComment *comment.Comment) {
+	payload := webhook.Payload{
+		Event:   event,
+		Project: project,
+		Title:   title,
+		Actor:   actor,
+		Time:    time.Now().UTC(),
+		Issue:   changedIssue,
+		Comment: changedComment}
+	push.PublishEvent(payload)
+
+	for _, hook := range db.FindWebhooks(project) {
+		go webhook.Deliver(hook, payload, db.InsertDelivery)
+	}
+}
+
+// managesProject checks whether the user logged in a session may change the settings of a project,
+// which is allowed for its owner and the administrators
+func managesProject(session *Session, existingProject project.Project) bool {
+	if session.Username == "" {
+		return false
+	}
+
+	return existingProject.Owner == session.Username || isAdmin(session.Username)
+}
+
+// AddWebhookCommand is used to register an URL which is notified about the events in a project
+type AddWebhookCommand struct {
+	Project string
+	URL     string
+	Secret  string
+}
+
+// Scope is the scope of a personal access token needed to add a webhook
+func (ac AddWebhookCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// Execute refuses to add a webhook, because only the owner of the project can do it
+func (ac AddWebhookCommand) Execute() (string, error) {
+	return ac.ExecuteAs(&Session{})
+}
+
+// ExecuteAs adds a webhook to a project if it is owned by the user logged in the session
+func (ac AddWebhookCommand) ExecuteAs(session *Session) (string, error) {
+	existingProject, err := db.FindExistingProject(ac.Project)
+	if err != nil {
+		return ProjectNotFound, ErrNotFound
+	}
+
+	if !managesProject(session, existingProject) {
+		return "Could not add webhook - only the owner of the project can manage its webhooks\n", ErrForbidden
+	}
+
+	if !webhook.ValidURL(ac.URL) {
+		return "Could not add webhook - URL should start with http:// or https://\n", ErrInvalid
+	}
+
+	if webhook.CheckHost(ac.URL) != nil {
+		return "Could not add webhook - the URL points to a private network\n", ErrInvalid
+	}
+
+	if ac.Secret == "" {
+		return "Could not add webhook - a secret for signing the payloads is required\n", ErrInvalid
+	}
+
+	id := db.InsertWebhook(webhook.Webhook{
+		Project: ac.Project,
+		URL:     ac.URL,
+		Secret:  ac.Secret,
+		Creator: session.Username})
+	return "Webhook #" + strconv.Itoa(id) + " added successfully\n", nil
+}
+
+// RemoveWebhookCommand is used to stop notifying an URL about the events in a project
+type RemoveWebhookCommand struct {
+	ID int
+}
+
+// Scope is the scope of a personal access token needed to remove a webhook
+func (rc RemoveWebhookCommand) Scope() token.Scope {
+	return token.ScopeWrite
+}
+
+// Execute refuses to remove a webhook, because only the owner of the project can do it
+func (rc RemoveWebhookCommand) Execute() (string, error) {
+	return rc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs removes a webhook if its project is owned by the user logged in the session
+func (rc RemoveWebhookCommand) ExecuteAs(session *Session) (string, error) {
+	existingWebhook, err := db.FindWebhook(rc.ID)
+	if err != nil {
+		return "Webhook does not exist \n", ErrNotFound
+	}
+
+	existingProject, err := db.FindExistingProject(existingWebhook.Project)
+	if err != nil || !managesProject(session, existingProject) {
+		return "Could not remove webhook - only the owner of the project can manage its webhooks\n", ErrForbidden
+	}
+
+	db.DeleteWebhook(existingWebhook.ID)
+	return "Webhook removed successfully\n", nil
+}
+
+// ListWebhooksCommand is used to list the webhooks registered for a project
+type ListWebhooksCommand struct {
+	Project string
+}
+
+// Scope is the scope of a personal access token needed to list the webhooks of a project
+func (lc ListWebhooksCommand) Scope() token.Scope {
+	return token.ScopeRead
+}
+
+// Execute refuses to list webhooks, because only the owner of the project can see them
+func (lc ListWebhooksCommand) Execute() (string, error) {
+	return lc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs lists the webhooks of a project if it is owned by the user logged in the session
+func (lc ListWebhooksCommand) ExecuteAs(session *Session) (string, error) {
+	existingProject, err := db.FindExistingProject(lc.Project)
+	if err != nil {
+		return ProjectNotFound, ErrNotFound
+	}
+
+	if !managesProject(session, existingProject) {
+		return "Could not list webhooks - only the owner of the project can manage its webhooks\n", ErrForbidden
+	}
+
+	hooks := db.FindWebhooks(lc.Project)
+	if len(hooks) == 0 {
+		return "There aren't any webhooks in this project\n", nil
+	}
+
+	hooksStr := "Webhooks in project: "
+	for _, hook := range hooks {
+		hooksStr += "#" + strconv.Itoa(hook.ID) + " " + hook.URL + ", "
+	}
+
+	return hooksStr[:len(hooksStr)-2] + "\n", nil
+}
+
+// deliveriesLimit is the number of latest deliveries which are shown
+const deliveriesLimit = 20
+
+// DeliveriesCommand is used to see the latest attempts to deliver the events of a project to its webhooks
+type DeliveriesCommand struct {
+	Project string
+}
+
+// Scope is the scope of a personal access token needed to list the webhook deliveries of a project
+func (dc DeliveriesCommand) Scope() token.Scope {
+	return token.ScopeRead
+}
+
+// Execute refuses to list deliveries, because only the owner of the project can see them
+func (dc DeliveriesCommand) Execute() (string, error) {
+	return dc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs lists the latest deliveries of a project if it is owned by the user logged in the session
+func (dc DeliveriesCommand) ExecuteAs(session *Session) (string, error) {
+	existingProject, err := db.FindExistingProject(dc.Project)
+	if err != nil {
+		return ProjectNotFound, ErrNotFound
+	}
+
+	if !managesProject(session, existingProject) {
+		return "Could not list deliveries - only the owner of the project can manage its webhooks\n", ErrForbidden
+	}
+
+	deliveries := db.FindDeliveries(dc.Project, deliveriesLimit)
+	if len(deliveries) == 0 {
+		return "There aren't any webhook deliveries in this project\n", nil
+	}
+
+	deliveriesStr := "Webhook deliveries: "
+	for _, delivery := range deliveries {
+		deliveriesStr += delivery.String() + "; "
+	}
+
+	return deliveriesStr[:len(deliveriesStr)-2] + "\n", nil
+}
+
+// AUDIT
+
+// AuditCommand is used by administrators to query the audit log
+type AuditCommand struct {
+	Actor  string
+	Action string
+	From   string
+	To     string
+}
+
+// Scope is the scope of a personal access token needed to query the audit log
+func (ac AuditCommand) Scope() token.Scope {
+	return token.ScopeAdmin
+}
+
+// Execute refuses to query the audit log, because it requires an administrator session
+func (ac AuditCommand) Execute() (string, error) {
+	return ac.ExecuteAs(&Session{})
+}
+
+// ExecuteAs queries the audit log if the user logged in the session is an administrator
+func (ac AuditCommand) ExecuteAs(session *Session) (string, error) {
+	if !isAdmin(session.Username) {
+		return "Could not query audit log - administrator rights are required\n", ErrForbidden
+	}
+
+	from, err := parseDate(ac.From)
+	if err != nil {
+		return "Could not query audit log - invalid start date, expected YYYY-MM-DD\n", ErrInvalid
+	}
+
+	to, err := parseDate(ac.To)
+	if err != nil {
+		return "Could not query audit log - invalid end date, expected YYYY-MM-DD\n", ErrInvalid
+	}
+	if !to.IsZero() {
+		// The end date is inclusive, so the range ends at the start of the next day
+		to = to.AddDate(0, 0, 1)
+	}
+
+	entries := db.FindAuditEntries(audit.Filter{
+		Actor:  ac.Actor,
+		Action: ac.Action,
+		From:   from,
+		To:     to})
+	if len(entries) == 0 {
+		return "There aren't any matching audit entries\n", nil
+	}
+
+	entriesStr := "Audit log: "
+	for _, entry := range entries {
+		entriesStr += entry.String() + "; "
+	}
+
+	return entriesStr[:len(entriesStr)-2] + "\n", nil
+}
+
+// parseDate parses an optional YYYY-MM-DD date in UTC. An empty string results in the zero time
+func parseDate(date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse("2006-01-02", date)
+}
+
+// ADMIN
+
+// isAdmin checks whether a user has administrator rights, either from the configuration, which names the administrators
+// who can grant the role to others, or from the role stored with the user. Either way the user has to exist and be active
+func isAdmin(username string) bool {
+	if username == "" {
+		return false
+	}
+
+	registeredUser, err := db.FindRegisteredUser(username)
+	return err == nil && !registeredUser.Deactivated && (registeredUser.Admin || config.IsAdmin(username))
+}
+
+// withRole marks the administrators named in the configuration, whose role is not stored with them
+func withRole(u user.User) user.User {
+	u.Admin = u.Admin || config.IsAdmin(u.Username)
+	return u
+}
+
+// GrantAdminCommand is used by an administrator to give another user administrator rights
+type GrantAdminCommand struct {
+	Username string
+}
+
+// Scope is the scope of a personal access token needed to grant administrator rights
+func (gc GrantAdminCommand) Scope() token.Scope {
+	return token.ScopeAdmin
+}
+
+// Execute fails, because only an administrator can grant administrator rights
+func (gc GrantAdminCommand) Execute() (string, error) {
+	return gc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs stores the administrator role of a user
+func (gc GrantAdminCommand) ExecuteAs(session *Session) (string, error) {
+	if !isAdmin(session.Username) {
+		return "Could not grant administrator rights - administrator rights are required\n", ErrForbidden
+	}
+
+	grantedUser, err := db.FindRegisteredUser(gc.Username)
+	if err != nil {
+		return UserNotFound, ErrNotFound
+	}
+
+	if withRole(grantedUser).Admin {
+		return "User is already an administrator\n", ErrConflict
+	}
+
+	db.SetAdmin(gc.Username, true)
+	db.InsertAuditEntry(audit.NewEntry(session.Username, audit.PermissionChange, gc.Username+" (admin)", session.RemoteAddr))
+	return "User " + gc.Username + " is now an administrator\n", nil
+}
+
+// RevokeAdminCommand is used by an administrator to take the administrator rights of another user
+type RevokeAdminCommand struct {
+	Username string
+}
+
+// Scope is the scope of a personal access token needed to revoke administrator rights
+func (rc RevokeAdminCommand) Scope() token.Scope {
+	return token.ScopeAdmin
+}
+
+// Execute fails, because only an administrator can revoke administrator rights
+func (rc RevokeAdminCommand) Execute() (string, error) {
+	return rc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs removes the stored administrator role of a user. The administrators named in the configuration keep their rights
+func (rc RevokeAdminCommand) ExecuteAs(session *Session) (string, error) {
+	if !isAdmin(session.Username) {
+		return "Could not revoke administrator rights - administrator rights are required\n", ErrForbidden
+	}
+
+	if rc.Username == session.Username {
+		return "Could not revoke administrator rights - you can't revoke your own rights\n", ErrInvalid
+	}
+
+	if config.IsAdmin(rc.Username) {
+		return "Could not revoke administrator rights - the user is an administrator by configuration\n", ErrConflict
+	}
+
+	revokedUser, err := db.FindRegisteredUser(rc.Username)
+	if err != nil {
+		return UserNotFound, ErrNotFound
+	}
+
+	if !revokedUser.Admin {
+		return "User is not an administrator\n", ErrConflict
+	}
+
+	db.SetAdmin(rc.Username, false)
+	db.InsertAuditEntry(audit.NewEntry(session.Username, audit.PermissionChange, rc.Username+" (user)", session.RemoteAddr))
+	return "User " + rc.Username + " is no longer an administrator\n", nil
+}
+
+// DeleteProjectCommand is used by an administrator to delete a project with all of its issues, comments and webhooks
+type DeleteProjectCommand struct {
+	Name string
+}
+
+// Scope is the scope of a personal access token needed to delete a project
+func (dc DeleteProjectCommand) Scope() token.Scope {
+	return token.ScopeAdmin
+}
+
+// Execute fails, because only an administrator can delete a project
+func (dc DeleteProjectCommand) Execute() (string, error) {
+	return dc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs deletes a project
+func (dc DeleteProjectCommand) ExecuteAs(session *Session) (string, error) {
+	if !isAdmin(session.Username) {
+		return "Could not delete project - administrator rights are required\n", ErrForbidden
+	}
+
+	if _, err := db.FindExistingProject(dc.Name); err != nil {
+		return ProjectNotFound, ErrNotFound
+	}
+
+	db.DeleteProject(dc.Name)
+	db.InsertAuditEntry(audit.NewEntry(session.Username, audit.ProjectDelete, dc.Name, session.RemoteAddr))
+	return "Project " + dc.Name + " deleted successfully\n", nil
+}
+
+// StatsCommand is used by an administrator to view the statistics of the server
+type StatsCommand struct{}
+
+// Scope is the scope of a personal access token needed to show the statistics
+func (sc StatsCommand) Scope() token.Scope {
+	return token.ScopeAdmin
+}
+
+// Execute fails, because only an administrator can view the statistics
+func (sc StatsCommand) Execute() (string, error) {
+	return sc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs shows the statistics of the server
+func (sc StatsCommand) ExecuteAs(session *Session) (string, error) {
+	data, message, err := sc.DataAs(session)
+	if err != nil {
+		return message, err
+	}
+
+	s := data.(stats.Stats)
+	return fmt.Sprintf("Users: %d (%d deactivated); Projects: %d; Issues: %d open, %d resolved; Comments: %d; "+
+		"Sessions: %d; Connected clients: %d; Running since: %s\n",
+		s.Users, s.DeactivatedUsers, s.Projects, s.OpenIssues, s.ResolvedIssues, s.Comments,
+		s.Sessions, s.Clients, s.Started.UTC().Format("2006-01-02 15:04:05")), nil
+}
+
+// Data fails, because only an administrator can view the statistics
+func (sc StatsCommand) Data() (interface{}, string, error) {
+	return sc.DataAs(&Session{})
+}
+
+// DataAs returns the statistics of the server
+func (sc StatsCommand) DataAs(session *Session) (interface{}, string, error) {
+	if !isAdmin(session.Username) {
+		return nil, "Could not show statistics - administrator rights are required\n", ErrForbidden
+	}
+
+	s := db.CountDocuments()
+	s.Clients = len(clients.List())
+	s.Started = clients.Started
+	return s, "", nil
+}
+
+// ClientsCommand is used by an administrator to list the clients connected to the TCP server
+type ClientsCommand struct{}
+
+// Scope is the scope of a personal access token needed to list the connected clients
+func (cc ClientsCommand) Scope() token.Scope {
+	return token.ScopeAdmin
+}
+
+// Execute fails, because only an administrator can list the clients
+func (cc ClientsCommand) Execute() (string, error) {
+	return cc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs lists the connected clients with the users logged in through them
+func (cc ClientsCommand) ExecuteAs(session *Session) (string, error) {
+	data, message, err := cc.DataAs(session)
+	if err != nil {
+		return message, err
+	}
+
+	connected := data.([]clients.Client)
+	if len(connected) == 0 {
+		return "There aren't any connected clients\n", nil
+	}
+
+	descriptions := make([]string, len(connected))
+	for i, c := range connected {
+		username := c.Username
+		if username == "" {
+			username = "(not logged in)"
+		}
+
+		descriptions[i] = "#" + strconv.Itoa(c.ID) + " " + username + " from " + c.RemoteAddr +
+			" since " + c.Connected.Format("2006-01-02 15:04:05")
+		if c.ID == session.ClientID {
+			descriptions[i] += " (this client)"
+		}
+	}
+
+	return "Clients: " + strings.Join(descriptions, "; ") + "\n", nil
+}
+
+// Data fails, because only an administrator can list the clients
+func (cc ClientsCommand) Data() (interface{}, string, error) {
+	return cc.DataAs(&Session{})
+}
+
+// DataAs returns the connected clients
+func (cc ClientsCommand) DataAs(session *Session) (interface{}, string, error) {
+	if !isAdmin(session.Username) {
+		return nil, "Could not list clients - administrator rights are required\n", ErrForbidden
+	}
+
+	return clients.List(), "", nil
+}
+
+// KickCommand is used by an administrator to forcibly disconnect a client from the TCP server
+type KickCommand struct {
+	ID int
+}
+
+// Scope is the scope of a personal access token needed to disconnect a client
+func (kc KickCommand) Scope() token.Scope {
+	return token.ScopeAdmin
+}
+
+// Execute fails, because only an administrator can disconnect a client
+func (kc KickCommand) Execute() (string, error) {
+	return kc.ExecuteAs(&Session{})
+}
+
+// ExecuteAs closes the connection of a client. Its stored session stays valid, so the user has to be deactivated
+// to keep them from connecting again
+func (kc KickCommand) ExecuteAs(session *Session) (string, error) {
+	if !isAdmin(session.Username) {
+		return "Could not disconnect client - administrator rights are required\n", ErrForbidden
+	}
+
+	if kc.ID == session.ClientID {
+		return "Could not disconnect client - use disconnect to close your own connection\n", ErrInvalid
+	}
+
+	if !clients.Disconnect(kc.ID) {
+		return "Could not disconnect client - there is no client #" + strconv.Itoa(kc.ID) + "\n", ErrNotFound
 	}
 
-	db.InsertComment(cc.Comment)
-	return "Comment added successfully\n", true
+	db.InsertAuditEntry(audit.NewEntry(session.Username, audit.ClientDisconnect, "#"+strconv.Itoa(kc.ID), session.RemoteAddr))
+	return "Client #" + strconv.Itoa(kc.ID) + " disconnected successfully\n", nil
 }
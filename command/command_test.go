@@ -1,18 +1,89 @@
 package command
 
 import (
+	"encoding/json"
 	"errors"
+	"os"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"go.fmi/issuetracker/audit"
+	"go.fmi/issuetracker/auth"
+	"go.fmi/issuetracker/clients"
 	"go.fmi/issuetracker/comment"
+	"go.fmi/issuetracker/config"
 	"go.fmi/issuetracker/db"
 	"go.fmi/issuetracker/issue"
+	"go.fmi/issuetracker/lockout"
+	"go.fmi/issuetracker/notification"
+	"go.fmi/issuetracker/notifier"
 	"go.fmi/issuetracker/project"
+	"go.fmi/issuetracker/protocol"
+	"go.fmi/issuetracker/stats"
+	"go.fmi/issuetracker/token"
 	"go.fmi/issuetracker/user"
+	"go.fmi/issuetracker/watch"
+	"go.fmi/issuetracker/webhook"
 
 	"bou.ke/monkey"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// recordingNotifier keeps the notifications it is asked to deliver instead of sending them
+type recordingNotifier struct {
+	sent map[string][]notification.Notification
+}
+
+func (rn *recordingNotifier) Notify(address string, notifications []notification.Notification) error {
+	rn.sent[address] = append(rn.sent[address], notifications...)
+	return nil
+}
+
+// stubProvider answers every login with the same identity or error, in place of an external directory
+type stubProvider struct {
+	identity auth.Identity
+	err      error
+}
+
+func (sp stubProvider) Name() string {
+	return "stub"
+}
+
+func (sp stubProvider) Authenticate(string, string) (auth.Identity, error) {
+	return sp.identity, sp.err
+}
+
+// patchProviders replaces the configured authentication providers
+func patchProviders(providers ...auth.Provider) {
+	monkey.Patch(auth.Configured, func() []auth.Provider {
+		return providers
+	})
+}
+
+// patchUsers makes every user exist and be active, so that the administrators named in the configuration have their rights
+func patchUsers() {
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		return user.User{Username: username}, nil
+	})
+}
+
+// patchObservers replaces the database operations on watches and webhooks, which every change of an issue goes through
+func patchObservers() {
+	monkey.Patch(db.InsertWatch, func(watch.Watch) {
+		return
+	})
+
+	monkey.Patch(db.FindWatchers, func(string, string) []string {
+		return nil
+	})
+
+	monkey.Patch(db.FindWebhooks, func(string) []webhook.Webhook {
+		return nil
+	})
+}
+
 func TestParserRegisterCommand(t *testing.T) {
 	rawCommand := "register|-|user|-|password"
 	parsedCommand := ParseCommand(rawCommand)
@@ -49,6 +120,86 @@ func TestParseLoginCommand(t *testing.T) {
 	}
 }
 
+func TestParseUnlockCommand(t *testing.T) {
+	if parsed := ParseCommand("unlock|-|user|-|10.0.0.1"); parsed != (UnlockCommand{Username: "user", Address: "10.0.0.1"}) {
+		t.Errorf("Invalid parsing: command parameters were not properly assigned")
+	}
+}
+
+func TestParsePasswordCommands(t *testing.T) {
+	if parsed := ParseCommand("passwd|-|old|-|new"); parsed != (PasswdCommand{OldPassword: "old", NewPassword: "new"}) {
+		t.Errorf("Invalid parsing: command parameters were not properly assigned")
+	}
+
+	if parsed := ParseCommand("resetpassword|-|user"); parsed != (ResetPasswordCommand{Username: "user"}) {
+		t.Errorf("Invalid parsing: command parameters were not properly assigned")
+	}
+
+	if parsed := ParseCommand("setpassword|-|user|-|secret|-|new"); parsed != (SetPasswordCommand{Username: "user", Token: "secret", Password: "new"}) {
+		t.Errorf("Invalid parsing: command parameters were not properly assigned")
+	}
+}
+
+func TestParseUserCommands(t *testing.T) {
+	if parsed := ParseCommand("user|-|ivan"); parsed != (ShowUserCommand{Username: "ivan"}) {
+		t.Errorf("Invalid parsing: command parameters were not properly assigned")
+	}
+
+	if parsed := ParseCommand("profile|-|Ivan Petrov|-|ivan@example.com|-|Europe/Sofia"); parsed != (ProfileCommand{
+		DisplayName: "Ivan Petrov", Email: "ivan@example.com", Timezone: "Europe/Sofia"}) {
+		t.Errorf("Invalid parsing: command parameters were not properly assigned")
+	}
+
+	if parsed := ParseCommand("deactivate|-|ivan"); parsed != (DeactivateCommand{Username: "ivan"}) {
+		t.Errorf("Invalid parsing: command parameters were not properly assigned")
+	}
+}
+
+func TestParseAdminCommands(t *testing.T) {
+	if parsed := ParseCommand("grantadmin|-|ivan"); parsed != (GrantAdminCommand{Username: "ivan"}) {
+		t.Errorf("Invalid parsing: command parameters were not properly assigned")
+	}
+
+	if parsed := ParseCommand("deleteproject|-|name"); parsed != (DeleteProjectCommand{Name: "name"}) {
+		t.Errorf("Invalid parsing: command parameters were not properly assigned")
+	}
+
+	if parsed := ParseCommand("kick|-|3"); parsed != (KickCommand{ID: 3}) {
+		t.Errorf("Invalid parsing: command parameters were not properly assigned")
+	}
+
+	if parsed := ParseCommand("data|-|stats"); parsed != (DataRequest{StatsCommand{}}) {
+		t.Errorf("Invalid parsing: command parameters were not properly assigned")
+	}
+}
+
+func TestParseAccessTokenCommands(t *testing.T) {
+	if parsed := ParseCommand("createtoken|-|ci|-|deploy|-|read,write|-|30"); parsed != (CreateAccessTokenCommand{
+		Username: "ci", Name: "deploy", Scopes: "read,write", Days: "30"}) {
+		t.Errorf("Invalid parsing: command parameters were not properly assigned")
+	}
+
+	if parsed := ParseCommand("revoketoken|-|7"); parsed != (RevokeAccessTokenCommand{ID: 7}) {
+		t.Errorf("Invalid parsing: command parameters were not properly assigned")
+	}
+}
+
+func TestParseResumeCommand(t *testing.T) {
+	rawCommand := "resume|-|secret"
+	parsedCommand := ParseCommand(rawCommand)
+	expectedCommand := ResumeCommand{
+		Token: "secret"}
+
+	switch parsedCommand.(type) {
+	case ResumeCommand:
+		if parsedCommand != expectedCommand {
+			t.Errorf("Invalid parsing: command parameters were not properly assigned")
+		}
+	default:
+		t.Errorf("Invalid command type: expected ResumeCommand")
+	}
+}
+
 func TestParseProjectCommand(t *testing.T) {
 	rawCommand := "project|-|name"
 	parsedCommand := ParseCommand(rawCommand)
@@ -67,15 +218,13 @@ func TestParseProjectCommand(t *testing.T) {
 }
 
 func TestParseIssueCommand(t *testing.T) {
-	rawCommand := "issue|-|name|-|user|-|title|-|description|-|false"
+	rawCommand := "issue|-|name|-|title|-|description|-|false"
 	parsedCommand := ParseCommand(rawCommand)
 	expectedCommand := IssueCommand{
 		issue.Issue{
 			Project:     "name",
-			Reporter:    "user",
 			Title:       "title",
-			Description: "description",
-			Resolved:    "false"}}
+			Description: "description"}}
 
 	switch parsedCommand.(type) {
 	case IssueCommand:
@@ -87,6 +236,20 @@ func TestParseIssueCommand(t *testing.T) {
 	}
 }
 
+func TestParseMultiLineIssueCommand(t *testing.T) {
+	description := "## Stack trace\n\n    panic: index out of range | C:\\temp"
+	parsedCommand := ParseCommand(protocol.Request("issue", "name", "title", description, "false"))
+	expectedCommand := IssueCommand{
+		issue.Issue{
+			Project:     "name",
+			Title:       "title",
+			Description: description}}
+
+	if parsedCommand != expectedCommand {
+		t.Errorf("Invalid parsing: the description was not unescaped, got %+v", parsedCommand)
+	}
+}
+
 func TestParseResolveCommand(t *testing.T) {
 	rawCommand := "resolve|-|name|-|title"
 	parsedCommand := ParseCommand(rawCommand)
@@ -137,15 +300,59 @@ func TestParseFindCommand(t *testing.T) {
 	}
 }
 
+func TestParseDataCommand(t *testing.T) {
+	rawCommand := "data|-|find|-|name|-|title"
+	parsedCommand := ParseCommand(rawCommand)
+	expectedCommand := DataRequest{
+		FindCommand{
+			Project: "name",
+			Title:   "title"}}
+
+	switch parsedCommand.(type) {
+	case DataRequest:
+		if parsedCommand != expectedCommand {
+			t.Errorf("Invalid parsing: command parameters were not properly assigned")
+		}
+	default:
+		t.Errorf("Invalid command type: expected DataRequest")
+	}
+
+	if ParseCommand("data|-|project|-|name") != nil {
+		t.Errorf("Invalid parsing: a command without structured data was accepted")
+	}
+	if ParseCommand("data") != nil {
+		t.Errorf("Invalid parsing: a data request without a command was accepted")
+	}
+}
+
 func TestParseCommentCommand(t *testing.T) {
 	rawCommand := "comment|-|name|-|title|-|content|-|commenter"
 	parsedCommand := ParseCommand(rawCommand)
 	expectedCommand := CommentCommand{
 		comment.Comment{
-			Project:   "name",
-			Title:     "title",
-			Content:   "content",
-			Commenter: "commenter"}}
+			Project: "name",
+			Title:   "title",
+			Content: "content"}}
+
+	switch parsedCommand.(type) {
+	case CommentCommand:
+		if parsedCommand != expectedCommand {
+			t.Errorf("Invalid parsing: command parameters were not properly assigned")
+		}
+	default:
+		t.Errorf("Invalid command type: expected CommentCommand")
+	}
+}
+
+func TestParseReplyCommand(t *testing.T) {
+	rawCommand := "comment|-|name|-|title|-|content|-|commenter|-|3"
+	parsedCommand := ParseCommand(rawCommand)
+	expectedCommand := CommentCommand{
+		comment.Comment{
+			Parent:  3,
+			Project: "name",
+			Title:   "title",
+			Content: "content"}}
 
 	switch parsedCommand.(type) {
 	case CommentCommand:
@@ -157,6 +364,214 @@ func TestParseCommentCommand(t *testing.T) {
 	}
 }
 
+func TestParseEditCommentCommand(t *testing.T) {
+	rawCommand := "editcomment|-|3|-|content"
+	parsedCommand := ParseCommand(rawCommand)
+	expectedCommand := EditCommentCommand{
+		ID:      3,
+		Content: "content"}
+
+	switch parsedCommand.(type) {
+	case EditCommentCommand:
+		if parsedCommand != expectedCommand {
+			t.Errorf("Invalid parsing: command parameters were not properly assigned")
+		}
+	default:
+		t.Errorf("Invalid command type: expected EditCommentCommand")
+	}
+}
+
+func TestParseDeleteCommentCommand(t *testing.T) {
+	rawCommand := "deletecomment|-|3"
+	parsedCommand := ParseCommand(rawCommand)
+	expectedCommand := DeleteCommentCommand{
+		ID: 3}
+
+	switch parsedCommand.(type) {
+	case DeleteCommentCommand:
+		if parsedCommand != expectedCommand {
+			t.Errorf("Invalid parsing: command parameters were not properly assigned")
+		}
+	default:
+		t.Errorf("Invalid command type: expected DeleteCommentCommand")
+	}
+}
+
+func TestParseInboxCommand(t *testing.T) {
+	rawCommand := "inbox|-|unread"
+	parsedCommand := ParseCommand(rawCommand)
+	expectedCommand := InboxCommand{
+		UnreadOnly: true}
+
+	switch parsedCommand.(type) {
+	case InboxCommand:
+		if parsedCommand != expectedCommand {
+			t.Errorf("Invalid parsing: command parameters were not properly assigned")
+		}
+	default:
+		t.Errorf("Invalid command type: expected InboxCommand")
+	}
+}
+
+func TestParseMarkReadCommand(t *testing.T) {
+	rawCommand := "markread|-|"
+	parsedCommand := ParseCommand(rawCommand)
+	expectedCommand := MarkReadCommand{
+		ID: 0}
+
+	switch parsedCommand.(type) {
+	case MarkReadCommand:
+		if parsedCommand != expectedCommand {
+			t.Errorf("Invalid parsing: command parameters were not properly assigned")
+		}
+	default:
+		t.Errorf("Invalid command type: expected MarkReadCommand")
+	}
+}
+
+func TestParseAssignCommand(t *testing.T) {
+	rawCommand := "assign|-|name|-|title|-|user"
+	parsedCommand := ParseCommand(rawCommand)
+	expectedCommand := AssignCommand{
+		Project:  "name",
+		Title:    "title",
+		Assignee: "user"}
+
+	switch parsedCommand.(type) {
+	case AssignCommand:
+		if parsedCommand != expectedCommand {
+			t.Errorf("Invalid parsing: command parameters were not properly assigned")
+		}
+	default:
+		t.Errorf("Invalid command type: expected AssignCommand")
+	}
+}
+
+func TestParseLabelCommand(t *testing.T) {
+	rawCommand := "label|-|name|-|title|-|bug,ui"
+	parsedCommand := ParseCommand(rawCommand)
+	expectedCommand := LabelCommand{
+		Project: "name",
+		Title:   "title",
+		Labels:  "bug,ui"}
+
+	switch parsedCommand.(type) {
+	case LabelCommand:
+		if parsedCommand != expectedCommand {
+			t.Errorf("Invalid parsing: command parameters were not properly assigned")
+		}
+	default:
+		t.Errorf("Invalid command type: expected LabelCommand")
+	}
+}
+
+func TestParseWatchCommand(t *testing.T) {
+	rawCommand := "watch|-|name|-|"
+	parsedCommand := ParseCommand(rawCommand)
+	expectedCommand := WatchCommand{
+		Project: "name",
+		Title:   ""}
+
+	switch parsedCommand.(type) {
+	case WatchCommand:
+		if parsedCommand != expectedCommand {
+			t.Errorf("Invalid parsing: command parameters were not properly assigned")
+		}
+	default:
+		t.Errorf("Invalid command type: expected WatchCommand")
+	}
+}
+
+func TestParseUnwatchCommand(t *testing.T) {
+	rawCommand := "unwatch|-|name|-|title"
+	parsedCommand := ParseCommand(rawCommand)
+	expectedCommand := UnwatchCommand{
+		Project: "name",
+		Title:   "title"}
+
+	switch parsedCommand.(type) {
+	case UnwatchCommand:
+		if parsedCommand != expectedCommand {
+			t.Errorf("Invalid parsing: command parameters were not properly assigned")
+		}
+	default:
+		t.Errorf("Invalid command type: expected UnwatchCommand")
+	}
+}
+
+func TestParseEmailCommand(t *testing.T) {
+	rawCommand := "email|-|user@example.com|-|digest|-|mentions"
+	parsedCommand := ParseCommand(rawCommand)
+	expectedCommand := EmailCommand{
+		Email: "user@example.com",
+		Mode:  "digest",
+		Kinds: "mentions"}
+
+	switch parsedCommand.(type) {
+	case EmailCommand:
+		if parsedCommand != expectedCommand {
+			t.Errorf("Invalid parsing: command parameters were not properly assigned")
+		}
+	default:
+		t.Errorf("Invalid command type: expected EmailCommand")
+	}
+}
+
+func TestParseAddWebhookCommand(t *testing.T) {
+	rawCommand := "addwebhook|-|name|-|https://example.com/hook|-|secret"
+	parsedCommand := ParseCommand(rawCommand)
+	expectedCommand := AddWebhookCommand{
+		Project: "name",
+		URL:     "https://example.com/hook",
+		Secret:  "secret"}
+
+	switch parsedCommand.(type) {
+	case AddWebhookCommand:
+		if parsedCommand != expectedCommand {
+			t.Errorf("Invalid parsing: command parameters were not properly assigned")
+		}
+	default:
+		t.Errorf("Invalid command type: expected AddWebhookCommand")
+	}
+}
+
+func TestParseAuditCommand(t *testing.T) {
+	rawCommand := "audit|-|user|-|login|-|2021-01-01|-|2021-01-31"
+	parsedCommand := ParseCommand(rawCommand)
+	expectedCommand := AuditCommand{
+		Actor:  "user",
+		Action: "login",
+		From:   "2021-01-01",
+		To:     "2021-01-31"}
+
+	switch parsedCommand.(type) {
+	case AuditCommand:
+		if parsedCommand != expectedCommand {
+			t.Errorf("Invalid parsing: command parameters were not properly assigned")
+		}
+	default:
+		t.Errorf("Invalid command type: expected AuditCommand")
+	}
+}
+
+func TestParseTruncatedCommands(t *testing.T) {
+	for commandType, count := range fieldCounts {
+		if count == 0 {
+			continue
+		}
+
+		fields := make([]string, count-1)
+		if parsedCommand := ParseCommand(protocol.Request(commandType, fields...)); parsedCommand != nil {
+			t.Errorf("Invalid parsing: %s with %d fields was accepted, but it needs %d", commandType, count-1, count)
+		}
+
+		fields = append(fields, "1")
+		if parsedCommand := ParseCommand(protocol.Request(commandType, fields...)); parsedCommand == nil && commandType != "data" {
+			t.Errorf("Invalid parsing: %s with %d fields was rejected", commandType, count)
+		}
+	}
+}
+
 func TestRegisterUserExisting(t *testing.T) {
 	defer monkey.UnpatchAll()
 	userMock := user.User{
@@ -168,8 +583,8 @@ func TestRegisterUserExisting(t *testing.T) {
 	})
 
 	registerCommand := RegisterCommand{userMock}
-	message, ok := registerCommand.Execute()
-	if ok {
+	message, err := registerCommand.Execute()
+	if err == nil {
 		t.Errorf("Command execution completed with OK, but shouldn't have")
 	}
 
@@ -188,13 +603,17 @@ func TestRegisterUserUnique(t *testing.T) {
 		return user.User{}, errors.New("User not found")
 	})
 
-	monkey.Patch(db.InsertRegisteredUser, func(user.User) {
+	monkey.Patch(db.InsertRegisteredUser, func(user.User) error {
+		return nil
+	})
+
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
 		return
 	})
 
 	registerCommand := RegisterCommand{userMock}
-	message, ok := registerCommand.Execute()
-	if !ok {
+	message, err := registerCommand.Execute()
+	if err != nil {
 		t.Errorf("Command execution did not complet with OK, but should have")
 	}
 
@@ -217,9 +636,13 @@ func TestLoginExisting(t *testing.T) {
 		return true
 	})
 
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		return
+	})
+
 	loginCommand := LoginCommand{userMock}
-	message, ok := loginCommand.Execute()
-	if !ok {
+	message, err := loginCommand.Execute()
+	if err != nil {
 		t.Errorf("Command execution did not complete with OK, but should have")
 	}
 
@@ -228,48 +651,508 @@ func TestLoginExisting(t *testing.T) {
 	}
 }
 
-func TestLoginMissingUser(t *testing.T) {
+func TestTokenNotLoggedIn(t *testing.T) {
+	message, err := TokenCommand{}.Execute()
+	if err != ErrUnauthenticated || message != NotLoggedIn {
+		t.Errorf("Invalid command execution message. Expected: " + NotLoggedIn + ", but got " + message)
+	}
+}
+
+func TestTokenThenResume(t *testing.T) {
 	defer monkey.UnpatchAll()
-	userMock := user.User{
-		Username: "user",
-		Password: "password1234"}
 
-	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
-		return user.User{}, errors.New("User doesn't exist")
+	tokens := map[string]token.Token{}
+	monkey.Patch(db.InsertToken, func(newToken token.Token) {
+		tokens[newToken.Hash] = newToken
 	})
-
-	monkey.Patch(user.ComparePasswords, func(string, string) bool {
-		return false
+	monkey.Patch(db.FindToken, func(hash string) (token.Token, error) {
+		if existingToken, ok := tokens[hash]; ok {
+			return existingToken, nil
+		}
+		return token.Token{}, errors.New("Token doesn't exist")
 	})
 
-	loginCommand := LoginCommand{userMock}
-	message, ok := loginCommand.Execute()
-	if ok {
-		t.Errorf("Command execution completed with OK, but shouldn't have")
+	message, err := TokenCommand{}.ExecuteAs(&Session{Username: "user"})
+	if err != nil || !strings.HasPrefix(message, "Token: ") {
+		t.Fatalf("Invalid command execution message. Expected: Token: <token>, but got " + message)
 	}
 
-	if message != "Login unsuccessful - inavlid username/password\n" {
-		t.Errorf("Invalid command execution message. Expected: Login unsuccessful - inavlid username/password\n, but got " + message)
+	session := &Session{}
+	message, err = ResumeCommand{Token: strings.TrimSpace(strings.TrimPrefix(message, "Token: "))}.ExecuteAs(session)
+	if err != nil || session.Username != "user" {
+		t.Errorf("Invalid command execution message. Expected: Login successful as user\n, but got " + message)
+	}
+
+	message, err = ResumeCommand{Token: "forged"}.ExecuteAs(&Session{})
+	if err == nil || message != InvalidToken {
+		t.Errorf("Invalid command execution message. Expected: " + InvalidToken + ", but got " + message)
 	}
 }
 
-func TestLoginWrongPassword(t *testing.T) {
+func TestLogoutRevokesToken(t *testing.T) {
 	defer monkey.UnpatchAll()
-	userMock := user.User{
-		Username: "user",
-		Password: "password1234"}
 
-	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
-		return userMock, nil
+	revoked := ""
+	monkey.Patch(db.DeleteToken, func(hash string) {
+		revoked = hash
 	})
 
-	monkey.Patch(user.ComparePasswords, func(string, string) bool {
-		return false
+	session := &Session{Username: "user", TokenHash: "hash"}
+	message, err := LogoutCommand{}.ExecuteAs(session)
+	if err != nil || message != "Successfully logged out\n" {
+		t.Errorf("Invalid command execution message. Expected: Successfully logged out\n, but got " + message)
+	}
+
+	if revoked != "hash" || session.Username != "" || session.TokenHash != "" {
+		t.Errorf("The session was not ended and its token was not revoked")
+	}
+}
+
+func TestLogoutNotLoggedIn(t *testing.T) {
+	message, err := LogoutCommand{}.Execute()
+	if err == nil || message != NotLoggedIn {
+		t.Errorf("Invalid command execution message. Expected: " + NotLoggedIn + ", but got " + message)
+	}
+}
+
+func TestRegisterInvalidUsername(t *testing.T) {
+	registerCommand := RegisterCommand{user.User{Username: " ", Password: "password1234"}}
+	message, err := registerCommand.Execute()
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Registration unsuccessful - the username should be between 3 and 32 characters long\n" {
+		t.Errorf("Invalid command execution message. Expected: Registration unsuccessful - the username should be between 3 and 32 characters long\n, but got " + message)
+	}
+}
+
+func TestRegisterUsernameTakenConcurrently(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return user.User{}, errors.New("User not found")
+	})
+
+	monkey.Patch(user.HashAndSalt, func(password string) string {
+		return "hash"
+	})
+
+	// The unique index rejects the user registered by another client after the check
+	monkey.Patch(db.InsertRegisteredUser, func(user.User) error {
+		return db.ErrDuplicate
+	})
+
+	session := &Session{}
+	message, err := RegisterCommand{user.User{Username: "User", Password: "password1234"}}.ExecuteAs(session)
+	if err != ErrExists || message != UsernameTaken {
+		t.Errorf("Invalid command execution message. Expected: " + UsernameTaken + ", but got " + message)
+	}
+
+	if session.Username != "" {
+		t.Errorf("Session was logged in after a failed registration")
+	}
+}
+
+func TestRegisterWeakPassword(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return user.User{}, errors.New("User not found")
+	})
+
+	registerCommand := RegisterCommand{user.User{Username: "user", Password: "pass"}}
+	message, err := registerCommand.Execute()
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Registration unsuccessful - the password should be at least 8 characters long\n" {
+		t.Errorf("Invalid command execution message. Expected: Registration unsuccessful - the password should be at least 8 characters long\n, but got " + message)
+	}
+}
+
+func TestLoginRehashesPassword(t *testing.T) {
+	defer monkey.UnpatchAll()
+	userMock := user.User{
+		Username: "user",
+		Password: "$2a$04$1ORhJ3MFDnqPHdIPsUgzOuGNT/Vt8DVt4UhISBg1oYLlxtPTd0WXy"}
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return userMock, nil
+	})
+
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		return
+	})
+
+	rehashed := ""
+	monkey.Patch(db.UpdatePassword, func(username string, hashedPassword string) {
+		rehashed = hashedPassword
+	})
+
+	message, err := LoginCommand{user.User{Username: "user", Password: "password1234"}}.Execute()
+	if err != nil || message != "Login successful as user\n" {
+		t.Errorf("Invalid command execution message. Expected: Login successful as user\n, but got " + message)
+	}
+
+	if cost, err := bcrypt.Cost([]byte(rehashed)); err != nil || cost != config.PasswordPolicy().BcryptCost {
+		t.Errorf("The password was not hashed again with the configured cost")
+	}
+}
+
+func TestPasswdNotLoggedIn(t *testing.T) {
+	message, err := PasswdCommand{OldPassword: "old", NewPassword: "password1234"}.Execute()
+	if err == nil || message != NotLoggedIn {
+		t.Errorf("Invalid command execution message. Expected: " + NotLoggedIn + ", but got " + message)
+	}
+}
+
+func TestPasswdWrongPassword(t *testing.T) {
+	defer monkey.UnpatchAll()
+	forgetFailedLogins(t, "user", "")
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return user.User{Username: "user", Password: "hash"}, nil
+	})
+
+	monkey.Patch(user.ComparePasswords, func(string, string) bool {
+		return false
+	})
+
+	message, err := PasswdCommand{OldPassword: "wrong", NewPassword: "password1234"}.ExecuteAs(&Session{Username: "user"})
+	if err != ErrForbidden || message != WrongPassword {
+		t.Errorf("Invalid command execution message. Expected: " + WrongPassword + ", but got " + message)
+	}
+}
+
+func TestPasswd(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return user.User{Username: "user", Password: "hash"}, nil
+	})
+
+	monkey.Patch(user.ComparePasswords, func(string, string) bool {
+		return true
+	})
+
+	monkey.Patch(user.HashAndSalt, func(password string) string {
+		return "hashed " + password
+	})
+
+	updated := ""
+	monkey.Patch(db.UpdatePassword, func(username string, hashedPassword string) {
+		updated = hashedPassword
+	})
+
+	kept := ""
+	monkey.Patch(db.DeleteUserTokens, func(username string, exceptHash string) {
+		kept = exceptHash
+	})
+
+	var entry audit.Entry
+	monkey.Patch(db.InsertAuditEntry, func(e audit.Entry) {
+		entry = e
+	})
+
+	session := &Session{Username: "user", TokenHash: "current"}
+	message, err := PasswdCommand{OldPassword: "old", NewPassword: "password1234"}.ExecuteAs(session)
+	if err != nil || message != "Password changed successfully\n" {
+		t.Errorf("Invalid command execution message. Expected: Password changed successfully\n, but got " + message)
+	}
+
+	if updated != "hashed password1234" || kept != "current" || entry.Action != audit.PasswordChange {
+		t.Errorf("The password was not changed with the other sessions ended and recorded in the audit log")
+	}
+}
+
+func TestPasswdRevokesAccessTokens(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return user.User{Username: "user", Password: "hash"}, nil
+	})
+
+	monkey.Patch(user.ComparePasswords, func(string, string) bool {
+		return true
+	})
+
+	monkey.Patch(db.UpdatePassword, func(string, string) {
+		return
+	})
+
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		return
+	})
+
+	tokens := []token.Token{
+		{Username: "user", Hash: "current"},
+		{Username: "user", Hash: "other"},
+		{Username: "user", Hash: "deploy", Name: "deploy", Scopes: []token.Scope{token.ScopeWrite}}}
+	monkey.Patch(db.DeleteUserTokens, func(username string, exceptHash string) {
+		var kept []token.Token
+		for _, existing := range tokens {
+			if existing.Username != username || existing.Hash == exceptHash {
+				kept = append(kept, existing)
+			}
+		}
+		tokens = kept
+	})
+
+	session := &Session{Username: "user", TokenHash: "current"}
+	if message, err := (PasswdCommand{OldPassword: "old", NewPassword: "password1234"}).ExecuteAs(session); err != nil {
+		t.Fatalf("Invalid command execution message. Expected: Password changed successfully\n, but got " + message)
+	}
+
+	if len(tokens) != 1 || tokens[0].Hash != "current" {
+		t.Errorf("The personal access tokens should be revoked along with the other sessions, but %v are left", tokens)
+	}
+}
+
+func TestResetPasswordNotAdmin(t *testing.T) {
+	os.Setenv(config.AdminsVariable, "admin")
+	defer os.Unsetenv(config.AdminsVariable)
+
+	message, err := ResetPasswordCommand{Username: "user"}.ExecuteAs(&Session{Username: "user"})
+	if err == nil || message != "Could not reset password - administrator rights are required\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not reset password - administrator rights are required\n, but got " + message)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	defer monkey.UnpatchAll()
+	os.Setenv(config.AdminsVariable, "admin")
+	defer os.Unsetenv(config.AdminsVariable)
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return user.User{Username: "user"}, nil
+	})
+
+	var issued token.Token
+	monkey.Patch(db.InsertResetToken, func(newToken token.Token) {
+		issued = newToken
+	})
+
+	var entry audit.Entry
+	monkey.Patch(db.InsertAuditEntry, func(e audit.Entry) {
+		entry = e
+	})
+
+	message, err := ResetPasswordCommand{Username: "user"}.ExecuteAs(&Session{Username: "admin"})
+	if err != nil {
+		t.Errorf("Command execution didn't complete with OK, but should have")
+	}
+
+	prefix := "Reset token for user (valid for 24h0m0s): "
+	secret := strings.TrimSpace(strings.TrimPrefix(message, prefix))
+	if !strings.HasPrefix(message, prefix) || token.Hash(secret) != issued.Hash || issued.Username != "user" {
+		t.Errorf("The reset token was not issued for the user, got " + message)
+	}
+
+	if entry.Actor != "admin" || entry.Action != audit.PasswordReset || entry.Target != "user" {
+		t.Errorf("The reset was not recorded in the audit log")
+	}
+}
+
+func TestSetPasswordInvalidToken(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.TakeResetToken, func(string) (token.Token, error) {
+		return token.Token{Username: "other", Expires: time.Now().Add(time.Hour)}, nil
+	})
+
+	message, err := SetPasswordCommand{Username: "user", Token: "secret", Password: "password1234"}.Execute()
+	if err == nil || message != InvalidResetToken {
+		t.Errorf("Invalid command execution message. Expected: " + InvalidResetToken + ", but got " + message)
+	}
+}
+
+func TestSetPasswordExpiredToken(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.TakeResetToken, func(string) (token.Token, error) {
+		return token.Token{Username: "user", Expires: time.Now().Add(-time.Hour)}, nil
+	})
+
+	message, err := SetPasswordCommand{Username: "user", Token: "secret", Password: "password1234"}.Execute()
+	if err == nil || message != InvalidResetToken {
+		t.Errorf("Invalid command execution message. Expected: " + InvalidResetToken + ", but got " + message)
+	}
+}
+
+func TestSetPassword(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	taken := ""
+	monkey.Patch(db.TakeResetToken, func(hash string) (token.Token, error) {
+		taken = hash
+		return token.Token{Username: "user", Expires: time.Now().Add(time.Hour)}, nil
+	})
+
+	monkey.Patch(user.HashAndSalt, func(password string) string {
+		return "hashed " + password
+	})
+
+	updated := ""
+	monkey.Patch(db.UpdatePassword, func(username string, hashedPassword string) {
+		updated = hashedPassword
+	})
+
+	revokedAll := false
+	monkey.Patch(db.DeleteUserTokens, func(username string, exceptHash string) {
+		revokedAll = username == "user" && exceptHash == ""
+	})
+
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		return
+	})
+
+	message, err := SetPasswordCommand{Username: "user", Token: "secret", Password: "password1234"}.Execute()
+	if err != nil || message != "Password set successfully. You can now log in with it\n" {
+		t.Errorf("Invalid command execution message. Expected: Password set successfully. You can now log in with it\n, but got " + message)
+	}
+
+	if taken != token.Hash("secret") || updated != "hashed password1234" || !revokedAll {
+		t.Errorf("The reset token was not used up, or the password was not set with all sessions ended")
+	}
+}
+
+// forgetFailedLogins unlocks an account and an address once a test is over, so that its failed logins
+// don't slow down the next tests
+func forgetFailedLogins(t *testing.T, username string, remoteAddr string) {
+	t.Cleanup(func() {
+		lockout.Reset(lockout.AccountKey(username))
+		lockout.Reset(lockout.AddressKey(remoteAddr))
+	})
+}
+
+func TestLoginLockout(t *testing.T) {
+	defer monkey.UnpatchAll()
+	forgetFailedLogins(t, "victim", "10.0.0.1:5555")
+	os.Setenv(config.LockoutThresholdVariable, "1")
+	defer os.Unsetenv(config.LockoutThresholdVariable)
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return user.User{Username: "victim", Password: "hash"}, nil
+	})
+
+	correct := false
+	monkey.Patch(user.ComparePasswords, func(string, string) bool {
+		return correct
+	})
+
+	var entries []audit.Entry
+	monkey.Patch(db.InsertAuditEntry, func(e audit.Entry) {
+		entries = append(entries, e)
+	})
+
+	session := &Session{RemoteAddr: "10.0.0.1:5555"}
+	LoginCommand{user.User{Username: "victim", Password: "guess"}}.ExecuteAs(session)
+
+	if len(entries) != 2 || entries[1].Action != audit.Lockout || entries[1].Target != "victim" {
+		t.Fatalf("The lockout of the account was not recorded in the audit log")
+	}
+
+	// Even the right password is rejected while the account is locked out
+	correct = true
+	message, err := LoginCommand{user.User{Username: "victim", Password: "password1234"}}.ExecuteAs(session)
+	if err == nil || message != TooManyAttempts {
+		t.Errorf("Invalid command execution message. Expected: " + TooManyAttempts + ", but got " + message)
+	}
+}
+
+func TestUnlockNotAdmin(t *testing.T) {
+	os.Setenv(config.AdminsVariable, "admin")
+	defer os.Unsetenv(config.AdminsVariable)
+
+	message, err := UnlockCommand{Username: "user"}.ExecuteAs(&Session{Username: "user"})
+	if err == nil || message != "Could not unlock - administrator rights are required\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not unlock - administrator rights are required\n, but got " + message)
+	}
+}
+
+func TestUnlock(t *testing.T) {
+	defer monkey.UnpatchAll()
+	forgetFailedLogins(t, "locked", "10.0.0.2:5555")
+	os.Setenv(config.AdminsVariable, "admin")
+	defer os.Unsetenv(config.AdminsVariable)
+	patchUsers()
+
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		return
+	})
+
+	lockout.Fail(lockout.AccountKey("locked"), 1, time.Hour)
+	lockout.Fail(lockout.AddressKey("10.0.0.2:5555"), 1, time.Hour)
+
+	message, err := UnlockCommand{Username: "locked", Address: "10.0.0.2"}.ExecuteAs(&Session{Username: "admin"})
+	if err != nil || message != "Unlocked account locked and address 10.0.0.2\n" {
+		t.Errorf("Invalid command execution message. Expected: Unlocked account locked and address 10.0.0.2\n, but got " + message)
+	}
+
+	if lockout.Wait(lockout.AccountKey("locked")) != 0 || lockout.Wait(lockout.AddressKey("10.0.0.2:5555")) != 0 {
+		t.Errorf("The account and the address were not unlocked")
+	}
+
+	message, err = UnlockCommand{Username: "locked"}.ExecuteAs(&Session{Username: "admin"})
+	if err == nil || message != "Could not unlock - there are no failed logins to forget\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not unlock - there are no failed logins to forget\n, but got " + message)
+	}
+}
+
+func TestLoginMissingUser(t *testing.T) {
+	defer monkey.UnpatchAll()
+	forgetFailedLogins(t, "user", "")
+	userMock := user.User{
+		Username: "user",
+		Password: "password1234"}
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return user.User{}, errors.New("User doesn't exist")
+	})
+
+	monkey.Patch(user.ComparePasswords, func(string, string) bool {
+		return false
+	})
+
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		return
 	})
 
 	loginCommand := LoginCommand{userMock}
-	message, ok := loginCommand.Execute()
-	if ok {
+	message, err := loginCommand.Execute()
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Login unsuccessful - inavlid username/password\n" {
+		t.Errorf("Invalid command execution message. Expected: Login unsuccessful - inavlid username/password\n, but got " + message)
+	}
+}
+
+func TestLoginWrongPassword(t *testing.T) {
+	defer monkey.UnpatchAll()
+	forgetFailedLogins(t, "user", "")
+	userMock := user.User{
+		Username: "user",
+		Password: "password1234"}
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return userMock, nil
+	})
+
+	monkey.Patch(user.ComparePasswords, func(string, string) bool {
+		return false
+	})
+
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		return
+	})
+
+	loginCommand := LoginCommand{userMock}
+	message, err := loginCommand.Execute()
+	if err == nil {
 		t.Errorf("Command execution completed with OK, but shouldn't have")
 	}
 
@@ -288,8 +1171,8 @@ func TestCreateExistingProject(t *testing.T) {
 	})
 
 	projectCommand := ProjectCommand{projectMock}
-	message, ok := projectCommand.Execute()
-	if ok {
+	message, err := projectCommand.ExecuteAs(&Session{Username: "owner"})
+	if err == nil {
 		t.Errorf("Command execution completed with OK, but shouldn't have")
 	}
 
@@ -307,29 +1190,55 @@ func TestCreateUniqueProject(t *testing.T) {
 		return project.Project{}, errors.New("Project does not exist")
 	})
 
-	monkey.Patch(db.InsertNewProject, func(project.Project) {
-		return
+	var inserted project.Project
+	monkey.Patch(db.InsertNewProject, func(newProject project.Project) error {
+		inserted = newProject
+		return nil
+	})
+
+	var audited audit.Entry
+	monkey.Patch(db.InsertAuditEntry, func(entry audit.Entry) {
+		audited = entry
 	})
 
 	projectCommand := ProjectCommand{projectMock}
-	message, ok := projectCommand.Execute()
-	if !ok {
+	message, err := projectCommand.ExecuteAs(&Session{Username: "owner"})
+	if err != nil {
 		t.Errorf("Command execution didn't complete with OK, but should have")
 	}
 
 	if message != "Project created successfully\n" {
 		t.Errorf("Invalid command execution message. Expected: Project created successfully\n, but got " + message)
 	}
+
+	if inserted.Owner != "owner" || audited.Actor != "owner" {
+		t.Errorf("The project should be owned and audited by the logged in user: %+v, %+v", inserted, audited)
+	}
 }
 
-func TestCreateUniqueIssue(t *testing.T) {
+func TestCreateProjectNotLoggedIn(t *testing.T) {
 	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.InsertNewProject, func(project.Project) error {
+		t.Errorf("A project was created without a login")
+		return nil
+	})
+
+	message, err := ProjectCommand{project.Project{Name: "project"}}.Execute()
+	if err != ErrUnauthenticated || message != NotLoggedIn {
+		t.Errorf("Invalid command execution message. Expected: " + NotLoggedIn + ", but got " + message)
+	}
+}
+
+func TestCreateUniqueIssue(t *testing.T) {
+	defer monkey.UnpatchAll()
+	patchObservers()
 	issueMock := issue.Issue{
 		Project:     "project",
 		Reporter:    "reporter",
 		Title:       "title",
 		Description: "description",
-		Resolved:    "false"}
+		Status:      issue.StatusOpen}
 
 	projectMock := project.Project{
 		Name: "project"}
@@ -342,389 +1251,2170 @@ func TestCreateUniqueIssue(t *testing.T) {
 		return issue.Issue{}, errors.New("Issue not found")
 	})
 
-	monkey.Patch(db.InsertNewIssue, func(issue.Issue) {
-		return
+	monkey.Patch(db.InsertNewIssue, func(issue.Issue) error {
+		return nil
+	})
+
+	issueCommand := IssueCommand{issueMock}
+	message, err := issueCommand.ExecuteAs(&Session{Username: "reporter"})
+	if err != nil {
+		t.Errorf("Command execution didn't complete with OK, but should have")
+	}
+
+	if message != "Issue created successfully\n" {
+		t.Errorf("Invalid command execution message. Expected: Issue created successfully\n, but got " + message)
+	}
+}
+
+func TestCreateNonUniqueIssue(t *testing.T) {
+	defer monkey.UnpatchAll()
+	issueMock := issue.Issue{
+		Project:     "project",
+		Reporter:    "reporter",
+		Title:       "title",
+		Description: "description",
+		Status:      issue.StatusOpen}
+
+	projectMock := project.Project{
+		Name: "project"}
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return projectMock, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issueMock, nil
 	})
 
 	issueCommand := IssueCommand{issueMock}
-	message, ok := issueCommand.Execute()
-	if !ok {
+	message, err := issueCommand.ExecuteAs(&Session{Username: "reporter"})
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Could not create new issue - issue name is not unique for project\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not create new issue - issue name is not unique for project\n, but got " + message)
+	}
+}
+
+func TestCreateProjectTakenConcurrently(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{}, errors.New("Project does not exist")
+	})
+
+	monkey.Patch(db.InsertNewProject, func(project.Project) error {
+		return db.ErrDuplicate
+	})
+
+	message, err := ProjectCommand{project.Project{Name: "project"}}.ExecuteAs(&Session{Username: "owner"})
+	if err == nil || message != ProjectNameTaken {
+		t.Errorf("Invalid command execution message. Expected: " + ProjectNameTaken + ", but got " + message)
+	}
+}
+
+func TestIssueIsReportedByLoggedUser(t *testing.T) {
+	defer monkey.UnpatchAll()
+	patchObservers()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issue.Issue{}, errors.New("Issue not found")
+	})
+
+	var inserted issue.Issue
+	monkey.Patch(db.InsertNewIssue, func(newIssue issue.Issue) error {
+		inserted = newIssue
+		return nil
+	})
+
+	var watcher string
+	monkey.Patch(db.InsertWatch, func(newWatch watch.Watch) {
+		watcher = newWatch.Username
+	})
+
+	spoofed := issue.Issue{Project: "project", Reporter: "victim", Title: "title"}
+	if _, err := (IssueCommand{spoofed}).ExecuteAs(&Session{Username: "user"}); err != nil || inserted.Reporter != "user" || watcher != "user" {
+		t.Errorf("The issue should be reported and watched by the logged in user, but was by " + inserted.Reporter)
+	}
+}
+
+func TestCreateIssueTakenConcurrently(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issue.Issue{}, errors.New("Issue not found")
+	})
+
+	monkey.Patch(db.InsertNewIssue, func(issue.Issue) error {
+		return db.ErrDuplicate
+	})
+
+	message, err := IssueCommand{issue.Issue{Project: "project", Title: "title"}}.ExecuteAs(&Session{Username: "reporter"})
+	if err == nil || message != IssueTitleTaken {
+		t.Errorf("Invalid command execution message. Expected: " + IssueTitleTaken + ", but got " + message)
+	}
+}
+
+func TestCreateIssueMissingProject(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{}, errors.New("Project not found")
+	})
+
+	issueCommand := IssueCommand{issue.Issue{}}
+	message, err := issueCommand.ExecuteAs(&Session{Username: "reporter"})
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Could not find project \n" {
+		t.Errorf("Invalid command execution message. Expected: Could not find project \n, but got " + message)
+	}
+}
+
+func TestResolveIssue(t *testing.T) {
+	defer monkey.UnpatchAll()
+	patchObservers()
+	issueMock := issue.Issue{
+		Project:     "project",
+		Reporter:    "reporter",
+		Title:       "title",
+		Description: "description",
+		Status:      issue.StatusOpen}
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issueMock, nil
+	})
+
+	monkey.Patch(db.ResolveIssue, func(string, string) {
+		return
+	})
+
+	resolveCommand := ResolveCommand{Project: "project", Title: "title"}
+	message, err := resolveCommand.ExecuteAs(&Session{Username: "resolver"})
+
+	if err != nil {
+		t.Errorf("Command execution didn't complete with OK, but should have")
+	}
+
+	if message != "Issue resolved successfully\n" {
+		t.Errorf("Invalid command execution message. Expected: Issue resolved successfully\n, but got " + message)
+	}
+}
+
+func TestResolveMissingIssue(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issue.Issue{}, errors.New("Issue not found")
+	})
+
+	resolveCommand := ResolveCommand{Project: "project", Title: "title"}
+	message, err := resolveCommand.ExecuteAs(&Session{Username: "resolver"})
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Could not resolve issue - issue does not exist \n" {
+		t.Errorf("Invalid command execution message. Expected: Could not resolve issue - issue does not exist \n, but got " + message)
+	}
+}
+
+func TestResolveIssueMissingProject(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{}, errors.New("Project not found")
+	})
+
+	resolveCommand := ResolveCommand{Project: "project", Title: "title"}
+	message, err := resolveCommand.ExecuteAs(&Session{Username: "resolver"})
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Could not find project \n" {
+		t.Errorf("Invalid command execution message. Expected: Could not find project \n, but got " + message)
+	}
+}
+
+func TestResolveResolvedIssue(t *testing.T) {
+	defer monkey.UnpatchAll()
+	issueMock := issue.Issue{
+		Project:     "project",
+		Reporter:    "reporter",
+		Title:       "title",
+		Description: "description",
+		Status:      issue.StatusResolved}
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issueMock, nil
+	})
+
+	resolveCommand := ResolveCommand{Project: "project", Title: "title"}
+	message, err := resolveCommand.ExecuteAs(&Session{Username: "resolver"})
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Issue is already resolved \n" {
+		t.Errorf("Invalid command execution message. Expected: Issue is already resolved \n, but got " + message)
+	}
+}
+
+func TestParseProjectsCommand(t *testing.T) {
+	if parsedCommand := ParseCommand("data|-|projects"); parsedCommand != (DataRequest{ProjectsCommand{}}) {
+		t.Errorf("Invalid parsing: expected a data request for ProjectsCommand")
+	}
+}
+
+func TestProjectsCommand(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.ListProjects, func() []project.Project {
+		return []project.Project{{Name: "first"}, {Name: "second"}}
+	})
+
+	message, err := ProjectsCommand{}.Execute()
+	if err != nil || message != "Projects: first, second\n" {
+		t.Errorf("Invalid command execution message. Expected: Projects: first, second\n, but got " + message)
+	}
+}
+
+func TestProjectsDataRequestNoProjects(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.ListProjects, func() []project.Project {
+		return nil
+	})
+
+	message, err := DataRequest{ProjectsCommand{}}.Execute()
+	if err != nil || message != protocol.DataPrefix+"[]\n" {
+		t.Errorf("Invalid command execution message. Expected: " + protocol.DataPrefix + "[]\n, but got " + message)
+	}
+}
+
+func TestListCommand(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{}, nil
+	})
+
+	monkey.Patch(db.ListIssues, func(string) []issue.Issue {
+		return []issue.Issue{issue.Issue{Title: "first issue"}, issue.Issue{Title: "second issue"}}
+	})
+
+	listCommand := ListCommand{Project: "project"}
+	message, err := listCommand.Execute()
+
+	if err != nil {
+		t.Errorf("Command execution didn't complete with OK, but should have")
+	}
+
+	if message != "Issues in project: first issue, second issue\n" {
+		t.Errorf("Invalid command execution message. Expected: Issues in project: first issue, second issue\n, but got " + message)
+	}
+}
+
+func TestListCommandNoIssues(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{}, nil
+	})
+
+	monkey.Patch(db.ListIssues, func(string) []issue.Issue {
+		return []issue.Issue{}
+	})
+
+	listCommand := ListCommand{Project: "project"}
+	message, err := listCommand.Execute()
+
+	if err != nil {
 		t.Errorf("Command execution didn't complete with OK, but should have")
 	}
 
-	if message != "Issue created successfully\n" {
-		t.Errorf("Invalid command execution message. Expected: Issue created successfully\n, but got " + message)
+	if message != "There aren't any issues in this project\n" {
+		t.Errorf("Invalid command execution message. Expected: There aren't any issues in this project\n, but got " + message)
+	}
+}
+
+func TestListCommandMissingProject(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{}, errors.New("Project not found")
+	})
+
+	listCommand := ListCommand{Project: "project"}
+	message, err := listCommand.Execute()
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Could not find project \n" {
+		t.Errorf("Invalid command execution message. Expected: Could not find project \n, but got " + message)
+	}
+}
+
+func TestFindCommand(t *testing.T) {
+	defer monkey.UnpatchAll()
+	commentMock := comment.Comment{
+		ID:        1,
+		Project:   "project",
+		Title:     "title",
+		Content:   "content",
+		Commenter: "commenter",
+		Created:   time.Date(2021, 1, 5, 10, 30, 0, 0, time.UTC)}
+	replyMock := comment.Comment{
+		ID:        2,
+		Parent:    1,
+		Project:   "project",
+		Title:     "title",
+		Content:   "reply",
+		Commenter: "reporter",
+		Created:   time.Date(2021, 1, 5, 11, 0, 0, 0, time.UTC),
+		Edited:    time.Date(2021, 1, 5, 11, 5, 0, 0, time.UTC)}
+	issueMock := issue.Issue{
+		Project:     "project",
+		Reporter:    "reporter",
+		Title:       "title",
+		Description: "description",
+		Status:      issue.StatusResolved}
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issueMock, nil
+	})
+
+	monkey.Patch(db.FindComments, func(string, string) []comment.Comment {
+		return []comment.Comment{replyMock, commentMock}
+	})
+
+	findCommand := FindCommand{Project: "project", Title: "title"}
+	message, err := findCommand.Execute()
+
+	if err != nil {
+		t.Errorf("Command execution didn't complete with OK, but should have")
+	}
+
+	expected := "Project: project; Reporter: reporter; Title: title; Description: description; Status: resolved; Comments: " +
+		"#1 2021-01-05 10:30 \"content\" - commenter;> #2 2021-01-05 11:00 \"reply\" - reporter (edited);\n"
+	if message != expected {
+		t.Errorf("Invalid command execution message. Expected: " + expected + ", but got " + message)
+	}
+}
+
+func TestFindDataRequest(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issue.Issue{Project: "project", Title: "title", Status: issue.StatusOpen}, nil
+	})
+
+	monkey.Patch(db.FindComments, func(string, string) []comment.Comment {
+		return []comment.Comment{
+			{ID: 2, Parent: 1, Content: "reply"},
+			{ID: 1, Content: "content"}}
+	})
+
+	message, err := DataRequest{FindCommand{Project: "project", Title: "title"}}.Execute()
+	if err != nil || !strings.HasPrefix(message, "data|-|") {
+		t.Fatalf("Invalid command execution message. Expected: data|-|<json>, but got " + message)
+	}
+
+	var details IssueDetails
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(message, "data|-|")), &details); err != nil {
+		t.Fatal(err)
+	}
+
+	if details.Issue.Title != "title" || len(details.Comments) != 2 ||
+		details.Comments[0].Comment.ID != 1 || details.Comments[1].Depth != 1 {
+		t.Errorf("Invalid issue details: %+v", details)
+	}
+}
+
+func TestListDataRequestMissingProject(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{}, errors.New("Missing project")
+	})
+
+	message, err := DataRequest{ListCommand{Project: "project"}}.Execute()
+	if err != ErrNotFound || message != ProjectNotFound {
+		t.Errorf("Invalid command execution message. Expected: " + ProjectNotFound + ", but got " + message)
+	}
+}
+
+func TestFindCommandMissingIssue(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issue.Issue{}, errors.New("Missing issue")
+	})
+
+	findCommand := FindCommand{Project: "project", Title: "title"}
+	message, err := findCommand.Execute()
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Issue does not exist \n" {
+		t.Errorf("Invalid command execution message. Expected: Issue does not exist \n, but got " + message)
+	}
+}
+
+func TestFindCommandMissingProject(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{}, errors.New("Missing project")
+	})
+
+	findCommand := FindCommand{Project: "project", Title: "title"}
+	message, err := findCommand.Execute()
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Could not find project \n" {
+		t.Errorf("Invalid command execution message. Expected: Could not find project \n, but got " + message)
+	}
+}
+
+func TestCommentCommand(t *testing.T) {
+	defer monkey.UnpatchAll()
+	patchObservers()
+	commentMock := comment.Comment{
+		Project:   "project",
+		Title:     "title",
+		Content:   "content",
+		Commenter: "commenter"}
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issue.Issue{}, nil
+	})
+
+	monkey.Patch(db.InsertComment, func(comment.Comment) {
+		return
+	})
+
+	commentCommand := CommentCommand{commentMock}
+	message, err := commentCommand.ExecuteAs(&Session{Username: "commenter"})
+
+	if err != nil {
+		t.Errorf("Command execution didn't complete with OK, but should have")
+	}
+
+	if message != "Comment added successfully\n" {
+		t.Errorf("Invalid command execution message. Expected: Comment added successfully\n, but got " + message)
+	}
+}
+
+func TestCommentCommandNotLoggedIn(t *testing.T) {
+	message, err := CommentCommand{comment.Comment{Project: "project", Title: "title", Content: "content"}}.Execute()
+	if err == nil || message != NotLoggedIn {
+		t.Errorf("Invalid command execution message. Expected: " + NotLoggedIn + ", but got " + message)
+	}
+}
+
+func TestCommentCommandIsByLoggedUser(t *testing.T) {
+	defer monkey.UnpatchAll()
+	patchObservers()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issue.Issue{}, nil
+	})
+
+	var inserted comment.Comment
+	monkey.Patch(db.InsertComment, func(newComment comment.Comment) {
+		inserted = newComment
+	})
+
+	spoofed := comment.Comment{Project: "project", Title: "title", Content: "content", Commenter: "victim"}
+	if _, err := (CommentCommand{spoofed}).ExecuteAs(&Session{Username: "user"}); err != nil || inserted.Commenter != "user" {
+		t.Errorf("The comment should be by the logged in user, but was by " + inserted.Commenter)
+	}
+}
+
+func TestCommentCommandMissingIssue(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issue.Issue{}, errors.New("Issue not found")
+	})
+
+	commentCommand := CommentCommand{comment.Comment{}}
+	message, err := commentCommand.ExecuteAs(&Session{Username: "commenter"})
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Issue does not exist \n" {
+		t.Errorf("Invalid command execution message. Expected: Issue does not exist \n, but got " + message)
+	}
+}
+
+func TestCommentCommandMissingProject(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{}, errors.New("Project not found")
+	})
+
+	commentCommand := CommentCommand{comment.Comment{}}
+	message, err := commentCommand.ExecuteAs(&Session{Username: "commenter"})
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Could not find project \n" {
+		t.Errorf("Invalid command execution message. Expected: Could not find project \n, but got " + message)
+	}
+}
+
+func TestReplyToMissingComment(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issue.Issue{}, nil
+	})
+
+	monkey.Patch(db.FindComment, func(int) (comment.Comment, error) {
+		return comment.Comment{ID: 3, Project: "project", Title: "another title"}, nil
+	})
+
+	commentCommand := CommentCommand{comment.Comment{Project: "project", Title: "title", Parent: 3}}
+	message, err := commentCommand.ExecuteAs(&Session{Username: "commenter"})
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Could not reply - comment does not exist for this issue\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not reply - comment does not exist for this issue\n, but got " + message)
+	}
+}
+
+func TestEditComment(t *testing.T) {
+	defer monkey.UnpatchAll()
+	patchObservers()
+
+	monkey.Patch(db.FindComment, func(int) (comment.Comment, error) {
+		return comment.Comment{ID: 3, Commenter: "commenter"}, nil
+	})
+
+	var editedContent string
+	monkey.Patch(db.EditComment, func(id int, content string, edited time.Time) {
+		editedContent = content
+	})
+
+	editCommand := EditCommentCommand{ID: 3, Content: "new content"}
+	message, err := editCommand.ExecuteAs(&Session{Username: "commenter"})
+
+	if err != nil {
+		t.Errorf("Command execution didn't complete with OK, but should have")
+	}
+
+	if editedContent != "new content" {
+		t.Errorf("Comment content was not updated")
+	}
+
+	if message != "Comment edited successfully\n" {
+		t.Errorf("Invalid command execution message. Expected: Comment edited successfully\n, but got " + message)
+	}
+}
+
+func TestEditCommentNotAuthor(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindComment, func(int) (comment.Comment, error) {
+		return comment.Comment{ID: 3, Commenter: "commenter"}, nil
+	})
+
+	editCommand := EditCommentCommand{ID: 3, Content: "new content"}
+	message, err := editCommand.ExecuteAs(&Session{Username: "someone else"})
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Could not edit comment - only the author can edit it\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not edit comment - only the author can edit it\n, but got " + message)
+	}
+}
+
+func TestDeleteComment(t *testing.T) {
+	defer monkey.UnpatchAll()
+	patchObservers()
+
+	monkey.Patch(db.FindComment, func(int) (comment.Comment, error) {
+		return comment.Comment{ID: 3, Commenter: "commenter"}, nil
+	})
+
+	monkey.Patch(db.DeleteComment, func(int) {
+		return
+	})
+
+	deleteCommand := DeleteCommentCommand{ID: 3}
+	message, err := deleteCommand.ExecuteAs(&Session{Username: "commenter"})
+
+	if err != nil {
+		t.Errorf("Command execution didn't complete with OK, but should have")
+	}
+
+	if message != "Comment deleted successfully\n" {
+		t.Errorf("Invalid command execution message. Expected: Comment deleted successfully\n, but got " + message)
+	}
+}
+
+func TestDeleteDeletedComment(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindComment, func(int) (comment.Comment, error) {
+		return comment.Comment{ID: 3, Commenter: "commenter", Deleted: true}, nil
+	})
+
+	deleteCommand := DeleteCommentCommand{ID: 3}
+	message, err := deleteCommand.ExecuteAs(&Session{Username: "commenter"})
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Comment does not exist \n" {
+		t.Errorf("Invalid command execution message. Expected: Comment does not exist \n, but got " + message)
+	}
+}
+
+func TestCommentMentionsRegisteredUsers(t *testing.T) {
+	defer monkey.UnpatchAll()
+	patchObservers()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issue.Issue{}, nil
+	})
+
+	monkey.Patch(db.InsertComment, func(comment.Comment) {
+		return
+	})
+
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		if username == "alice" {
+			return user.User{Username: "alice"}, nil
+		}
+		return user.User{}, errors.New("User not found")
+	})
+
+	var delivered []notification.Notification
+	monkey.Patch(db.InsertNotification, func(n notification.Notification) {
+		delivered = append(delivered, n)
+	})
+
+	commentCommand := CommentCommand{comment.Comment{
+		Project:   "project",
+		Title:     "title",
+		Content:   "@alice @ghost @commenter have a look",
+		Commenter: "commenter"}}
+	commentCommand.ExecuteAs(&Session{Username: "commenter"})
+
+	if len(delivered) != 1 {
+		t.Fatalf("Expected 1 notification, but got %d", len(delivered))
+	}
+
+	if delivered[0].Recipient != "alice" || delivered[0].Kind != notification.Mention {
+		t.Errorf("Mention notification was not delivered to the mentioned user")
+	}
+
+	if delivered[0].Message != "commenter mentioned you in a comment of issue 'title' in project 'project'" {
+		t.Errorf("Invalid notification message: " + delivered[0].Message)
+	}
+}
+
+func TestIssueMentionsAreByLoggedUser(t *testing.T) {
+	defer monkey.UnpatchAll()
+	patchObservers()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issue.Issue{}, errors.New("Issue not found")
+	})
+
+	monkey.Patch(db.InsertNewIssue, func(issue.Issue) error {
+		return nil
+	})
+
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		return user.User{Username: username}, nil
+	})
+
+	var delivered []notification.Notification
+	monkey.Patch(db.InsertNotification, func(n notification.Notification) {
+		delivered = append(delivered, n)
+	})
+
+	spoofed := IssueCommand{issue.Issue{Project: "project", Reporter: "admin", Title: "title", Description: "@alice urgent"}}
+	if message, err := spoofed.Execute(); err != ErrUnauthenticated || len(delivered) != 0 {
+		t.Errorf("An anonymous issue should be rejected without notifications, but got %d and "+message, len(delivered))
+	}
+
+	spoofed.ExecuteAs(&Session{Username: "user"})
+	if len(delivered) != 1 || delivered[0].Message != "user mentioned you in the description of issue 'title' in project 'project'" {
+		t.Errorf("The mention should be by the logged in user, but got %+v", delivered)
+	}
+}
+
+func TestInboxCommand(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindNotifications, func(recipient string, unreadOnly bool) []notification.Notification {
+		return []notification.Notification{
+			notification.Notification{ID: 2, Message: "second", Created: time.Date(2021, 1, 5, 11, 0, 0, 0, time.UTC)},
+			notification.Notification{ID: 1, Message: "first", Created: time.Date(2021, 1, 5, 10, 0, 0, 0, time.UTC), Read: true}}
+	})
+
+	inboxCommand := InboxCommand{}
+	message, err := inboxCommand.ExecuteAs(&Session{Username: "user"})
+
+	if err != nil {
+		t.Errorf("Command execution didn't complete with OK, but should have")
+	}
+
+	if message != "Inbox: #2 [unread] 2021-01-05 11:00 second; #1 2021-01-05 10:00 first\n" {
+		t.Errorf("Invalid command execution message. Expected: Inbox: #2 [unread] 2021-01-05 11:00 second; #1 2021-01-05 10:00 first\n, but got " + message)
+	}
+}
+
+func TestInboxCommandNotLogged(t *testing.T) {
+	inboxCommand := InboxCommand{}
+	message, err := inboxCommand.Execute()
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "You are not logged in\n" {
+		t.Errorf("Invalid command execution message. Expected: You are not logged in\n, but got " + message)
+	}
+}
+
+func TestMarkReadCommandNothingUnread(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.MarkNotificationsRead, func(string, int) int {
+		return 0
+	})
+
+	markReadCommand := MarkReadCommand{ID: 5}
+	message, err := markReadCommand.ExecuteAs(&Session{Username: "user"})
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "There aren't any matching unread notifications\n" {
+		t.Errorf("Invalid command execution message. Expected: There aren't any matching unread notifications\n, but got " + message)
+	}
+}
+
+func TestResolveIssueNotifiesWatchers(t *testing.T) {
+	defer monkey.UnpatchAll()
+	patchObservers()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issue.Issue{Project: "project", Title: "title", Status: issue.StatusOpen}, nil
+	})
+
+	monkey.Patch(db.ResolveIssue, func(string, string) {
+		return
+	})
+
+	monkey.Patch(db.FindWatchers, func(string, string) []string {
+		return []string{"reporter", "resolver"}
+	})
+
+	var delivered []notification.Notification
+	monkey.Patch(db.InsertNotification, func(n notification.Notification) {
+		delivered = append(delivered, n)
+	})
+
+	resolveCommand := ResolveCommand{Project: "project", Title: "title"}
+	resolveCommand.ExecuteAs(&Session{Username: "resolver"})
+
+	if len(delivered) != 1 || delivered[0].Recipient != "reporter" || delivered[0].Kind != notification.IssueResolved {
+		t.Fatalf("Only the watchers who didn't resolve the issue should be notified, but got %v", delivered)
+	}
+
+	if delivered[0].Message != "resolver resolved issue 'title' in project 'project'" {
+		t.Errorf("Invalid notification message: " + delivered[0].Message)
+	}
+}
+
+func TestIssueChangesNotLoggedIn(t *testing.T) {
+	for _, c := range []Command{
+		IssueCommand{issue.Issue{Project: "project", Reporter: "reporter", Title: "title"}},
+		ResolveCommand{Project: "project", Title: "title"},
+		AssignCommand{Project: "project", Title: "title", Assignee: "assignee"},
+		LabelCommand{Project: "project", Title: "title", Labels: "bug"}} {
+		if message, err := c.Execute(); err != ErrUnauthenticated || message != NotLoggedIn {
+			t.Errorf("Invalid command execution message. Expected: " + NotLoggedIn + ", but got " + message)
+		}
+	}
+}
+
+func TestAssignIssue(t *testing.T) {
+	defer monkey.UnpatchAll()
+	patchObservers()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issue.Issue{Project: "project", Title: "title"}, nil
+	})
+
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		return user.User{Username: username}, nil
+	})
+
+	monkey.Patch(db.AssignIssue, func(string, string, string) {
+		return
+	})
+
+	var watches []watch.Watch
+	monkey.Patch(db.InsertWatch, func(w watch.Watch) {
+		watches = append(watches, w)
+	})
+
+	monkey.Patch(db.FindWatchers, func(string, string) []string {
+		return []string{"reporter", "assignee"}
+	})
+
+	delivered := make(map[string]string)
+	monkey.Patch(db.InsertNotification, func(n notification.Notification) {
+		delivered[n.Recipient] = n.Kind
+	})
+
+	assignCommand := AssignCommand{Project: "project", Title: "title", Assignee: "assignee"}
+	message, err := assignCommand.ExecuteAs(&Session{Username: "manager"})
+
+	if err != nil {
+		t.Errorf("Command execution didn't complete with OK, but should have")
+	}
+
+	if message != "Issue assigned successfully\n" {
+		t.Errorf("Invalid command execution message. Expected: Issue assigned successfully\n, but got " + message)
+	}
+
+	if len(watches) != 1 || watches[0].Username != "assignee" {
+		t.Errorf("Assignee should start watching the issue")
+	}
+
+	if len(delivered) != 2 || delivered["assignee"] != notification.Assignment || delivered["reporter"] != notification.IssueUpdated {
+		t.Errorf("Assignee and watchers were not notified properly, got %v", delivered)
+	}
+}
+
+func TestAssignIssueMissingUser(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issue.Issue{Project: "project", Title: "title"}, nil
+	})
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return user.User{}, errors.New("User not found")
+	})
+
+	assignCommand := AssignCommand{Project: "project", Title: "title", Assignee: "ghost"}
+	message, err := assignCommand.ExecuteAs(&Session{Username: "manager"})
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Could not assign issue - user does not exist\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not assign issue - user does not exist\n, but got " + message)
+	}
+}
+
+func TestLabelIssue(t *testing.T) {
+	defer monkey.UnpatchAll()
+	patchObservers()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issue.Issue{Project: "project", Title: "title", Labels: "bug"}, nil
+	})
+
+	var stored string
+	monkey.Patch(db.LabelIssue, func(_ string, _ string, labels string) {
+		stored = labels
+	})
+
+	monkey.Patch(db.FindWatchers, func(string, string) []string {
+		return []string{"reporter", "manager"}
+	})
+
+	delivered := make(map[string]string)
+	monkey.Patch(db.InsertNotification, func(n notification.Notification) {
+		delivered[n.Recipient] = n.Message
+	})
+
+	labelCommand := LabelCommand{Project: "project", Title: "title", Labels: "ui, bug"}
+	message, err := labelCommand.ExecuteAs(&Session{Username: "manager"})
+
+	if err != nil || message != "Issue labels changed successfully\n" {
+		t.Errorf("Invalid command execution message. Expected: Issue labels changed successfully\n, but got " + message)
+	}
+
+	if stored != "bug,ui" {
+		t.Errorf("Invalid stored labels. Expected: bug,ui, but got " + stored)
+	}
+
+	if len(delivered) != 1 || delivered["reporter"] != "manager labelled issue 'title' in project 'project' as bug,ui" {
+		t.Errorf("Watchers were not notified properly, got %v", delivered)
+	}
+}
+
+func TestLabelIssueUnchanged(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issue.Issue{Project: "project", Title: "title", Labels: "bug,ui"}, nil
+	})
+
+	labelCommand := LabelCommand{Project: "project", Title: "title", Labels: "ui,bug"}
+	message, err := labelCommand.ExecuteAs(&Session{Username: "manager"})
+
+	if err == nil || message != "Issue already has these labels\n" {
+		t.Errorf("Invalid command execution message. Expected: Issue already has these labels\n, but got " + message)
+	}
+}
+
+func TestWatchMissingIssue(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issue.Issue{}, errors.New("Issue not found")
+	})
+
+	watchCommand := WatchCommand{Project: "project", Title: "title"}
+	message, err := watchCommand.ExecuteAs(&Session{Username: "user"})
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Issue does not exist \n" {
+		t.Errorf("Invalid command execution message. Expected: Issue does not exist \n, but got " + message)
+	}
+}
+
+func TestWatchProject(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	var inserted watch.Watch
+	monkey.Patch(db.InsertWatch, func(w watch.Watch) {
+		inserted = w
+	})
+
+	watchCommand := WatchCommand{Project: "project"}
+	message, err := watchCommand.ExecuteAs(&Session{Username: "user"})
+
+	if err != nil {
+		t.Errorf("Command execution didn't complete with OK, but should have")
+	}
+
+	if inserted != (watch.Watch{Username: "user", Project: "project"}) {
+		t.Errorf("Project watch was not stored properly")
+	}
+
+	if message != "You are now watching project 'project'\n" {
+		t.Errorf("Invalid command execution message. Expected: You are now watching project 'project'\n, but got " + message)
+	}
+}
+
+func TestUnwatchNotWatching(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.DeleteWatch, func(watch.Watch) bool {
+		return false
+	})
+
+	unwatchCommand := UnwatchCommand{Project: "project", Title: "title"}
+	message, err := unwatchCommand.ExecuteAs(&Session{Username: "user"})
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "You are not watching issue 'title' in project 'project'\n" {
+		t.Errorf("Invalid command execution message. Expected: You are not watching issue 'title' in project 'project'\n, but got " + message)
+	}
+}
+
+func TestEmailCommand(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	var saved notification.Preferences
+	monkey.Patch(db.SavePreferences, func(preferences notification.Preferences) {
+		saved = preferences
+	})
+
+	emailCommand := EmailCommand{Email: "user@example.com", Mode: "instant", Kinds: "mentions, status"}
+	message, err := emailCommand.ExecuteAs(&Session{Username: "user"})
+
+	if err != nil {
+		t.Errorf("Command execution didn't complete with OK, but should have")
+	}
+
+	expected := notification.Preferences{
+		Username:      "user",
+		Email:         "user@example.com",
+		Mode:          "instant",
+		Mentions:      true,
+		StatusChanges: true}
+	if saved != expected {
+		t.Errorf("Email preferences were not saved properly")
+	}
+
+	if message != "Email preferences changed successfully\n" {
+		t.Errorf("Invalid command execution message. Expected: Email preferences changed successfully\n, but got " + message)
+	}
+}
+
+func TestEmailCommandInvalidMode(t *testing.T) {
+	emailCommand := EmailCommand{Email: "user@example.com", Mode: "hourly"}
+	message, err := emailCommand.ExecuteAs(&Session{Username: "user"})
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Could not change email preferences - mode should be off, instant or digest\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not change email preferences - mode should be off, instant or digest\n, but got " + message)
+	}
+}
+
+func TestEmailCommandInvalidAddress(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.SavePreferences, func(notification.Preferences) {
+		t.Errorf("Preferences with an invalid address were saved")
+	})
+
+	expected := "Could not change email preferences - invalid email address\n"
+	for _, address := range []string{"", "user@example.com\r\nBcc: victim@example.com", "User <user@example.com>", "@"} {
+		message, err := EmailCommand{Email: address, Mode: "instant"}.ExecuteAs(&Session{Username: "user"})
+		if err != ErrInvalid || message != expected {
+			t.Errorf("Invalid command execution message for %q. Expected: "+expected+", but got "+message, address)
+		}
+	}
+}
+
+func TestDigestNotifications(t *testing.T) {
+	defer monkey.UnpatchAll()
+	recorder := &recordingNotifier{sent: make(map[string][]notification.Notification)}
+	notifier.Default = recorder
+	defer func() {
+		notifier.Default = nil
+	}()
+
+	monkey.Patch(db.InsertNotification, func(notification.Notification) {
+		return
+	})
+
+	monkey.Patch(db.FindPreferences, func(username string) (notification.Preferences, error) {
+		return notification.Preferences{
+			Username: username,
+			Email:    username + "@example.com",
+			Mode:     notification.EmailDigest,
+			Mentions: true}, nil
+	})
+
+	var queue []notification.Notification
+	monkey.Patch(db.InsertDigestNotification, func(n notification.Notification) {
+		queue = append(queue, n)
+	})
+
+	monkey.Patch(db.FindDigestRecipients, func() []string {
+		return []string{"user"}
+	})
+
+	monkey.Patch(db.TakeDigestNotifications, func(string) []notification.Notification {
+		return queue
+	})
+
+	deliver(notification.New("user", "alice", notification.Mention, "project", "title", "first"))
+	deliver(notification.New("user", "alice", notification.CommentAdded, "project", "title", "not emailed"))
+	deliver(notification.New("user", "alice", notification.Mention, "project", "title", "second"))
+
+	if len(recorder.sent) != 0 {
+		t.Errorf("Notifications in digest mode should not be sent right away")
+	}
+
+	SendDigests()
+
+	sent := recorder.sent["user@example.com"]
+	if len(sent) != 2 || sent[0].Message != "first" || sent[1].Message != "second" {
+		t.Errorf("Digest should contain the queued mentions, but got %v", sent)
+	}
+}
+
+func TestAddWebhook(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project", Owner: "owner"}, nil
+	})
+
+	var inserted webhook.Webhook
+	monkey.Patch(db.InsertWebhook, func(w webhook.Webhook) int {
+		inserted = w
+		return 7
+	})
+
+	addWebhookCommand := AddWebhookCommand{Project: "project", URL: "https://example.com/hook", Secret: "secret"}
+	message, err := addWebhookCommand.ExecuteAs(&Session{Username: "owner"})
+
+	if err != nil {
+		t.Errorf("Command execution didn't complete with OK, but should have")
+	}
+
+	if inserted.Project != "project" || inserted.Secret != "secret" || inserted.Creator != "owner" {
+		t.Errorf("Webhook was not stored properly")
+	}
+
+	if message != "Webhook #7 added successfully\n" {
+		t.Errorf("Invalid command execution message. Expected: Webhook #7 added successfully\n, but got " + message)
+	}
+}
+
+func TestAddWebhookNotOwner(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project", Owner: "owner"}, nil
+	})
+
+	addWebhookCommand := AddWebhookCommand{Project: "project", URL: "https://example.com/hook", Secret: "secret"}
+	message, err := addWebhookCommand.ExecuteAs(&Session{Username: "someone else"})
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Could not add webhook - only the owner of the project can manage its webhooks\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not add webhook - only the owner of the project can manage its webhooks\n, but got " + message)
+	}
+}
+
+func TestAddWebhookPrivateAddress(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project", Owner: "owner"}, nil
+	})
+
+	monkey.Patch(db.InsertWebhook, func(webhook.Webhook) int {
+		t.Errorf("A webhook posting to the internal network was added")
+		return 0
+	})
+
+	addWebhookCommand := AddWebhookCommand{Project: "project", URL: "http://169.254.169.254/latest/meta-data", Secret: "secret"}
+	message, err := addWebhookCommand.ExecuteAs(&Session{Username: "owner"})
+
+	if err == nil || message != "Could not add webhook - the URL points to a private network\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not add webhook - the URL points to a private network\n, but got " + message)
+	}
+}
+
+func TestCreateIssueFiresWebhooks(t *testing.T) {
+	defer monkey.UnpatchAll()
+	patchObservers()
+
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{Name: "project"}, nil
+	})
+
+	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
+		return issue.Issue{}, errors.New("Issue not found")
+	})
+
+	monkey.Patch(db.InsertNewIssue, func(issue.Issue) error {
+		return nil
+	})
+
+	monkey.Patch(db.FindWebhooks, func(string) []webhook.Webhook {
+		return []webhook.Webhook{webhook.Webhook{ID: 1, Project: "project"}}
+	})
+
+	delivered := make(chan webhook.Payload, 1)
+	monkey.Patch(webhook.Deliver, func(hook webhook.Webhook, payload webhook.Payload, record func(webhook.Delivery)) bool {
+		delivered <- payload
+		return true
+	})
+
+	issueCommand := IssueCommand{issue.Issue{Project: "project", Title: "title", Status: issue.StatusOpen}}
+	issueCommand.ExecuteAs(&Session{Username: "reporter"})
+
+	select {
+	case payload := <-delivered:
+		if payload.Event != webhook.IssueCreated || payload.Actor != "reporter" || payload.Issue == nil || payload.Issue.Title != "title" {
+			t.Errorf("Invalid webhook payload %v", payload)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Webhook was not fired")
+	}
+}
+
+func TestLoginFailureIsAudited(t *testing.T) {
+	defer monkey.UnpatchAll()
+	forgetFailedLogins(t, "user", "127.0.0.1:5555")
+	userMock := user.User{
+		Username: "user",
+		Password: "password1234"}
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return userMock, nil
+	})
+
+	monkey.Patch(user.ComparePasswords, func(string, string) bool {
+		return false
+	})
+
+	var recorded audit.Entry
+	monkey.Patch(db.InsertAuditEntry, func(entry audit.Entry) {
+		recorded = entry
+	})
+
+	session := Session{RemoteAddr: "127.0.0.1:5555"}
+	loginCommand := LoginCommand{userMock}
+	loginCommand.ExecuteAs(&session)
+
+	if recorded.Action != audit.LoginFailed || recorded.Actor != "user" || recorded.RemoteAddr != "127.0.0.1:5555" {
+		t.Errorf("Failed login was not audited properly. Got: " + recorded.String())
+	}
+
+	if session.Username != "" {
+		t.Errorf("Session was logged in after a failed login")
+	}
+}
+
+func TestAuditCommandNotAdmin(t *testing.T) {
+	os.Setenv(config.AdminsVariable, "admin")
+	defer os.Unsetenv(config.AdminsVariable)
+
+	auditCommand := AuditCommand{}
+	message, err := auditCommand.ExecuteAs(&Session{Username: "user"})
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Could not query audit log - administrator rights are required\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not query audit log - administrator rights are required\n, but got " + message)
+	}
+}
+
+func TestAuditCommandInvalidDate(t *testing.T) {
+	defer monkey.UnpatchAll()
+	os.Setenv(config.AdminsVariable, "admin")
+	defer os.Unsetenv(config.AdminsVariable)
+	patchUsers()
+
+	auditCommand := AuditCommand{From: "yesterday"}
+	message, err := auditCommand.ExecuteAs(&Session{Username: "admin"})
+
+	if err == nil {
+		t.Errorf("Command execution completed with OK, but shouldn't have")
+	}
+
+	if message != "Could not query audit log - invalid start date, expected YYYY-MM-DD\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not query audit log - invalid start date, expected YYYY-MM-DD\n, but got " + message)
+	}
+}
+
+func TestAuditCommand(t *testing.T) {
+	defer monkey.UnpatchAll()
+	os.Setenv(config.AdminsVariable, "admin")
+	defer os.Unsetenv(config.AdminsVariable)
+	patchUsers()
+
+	var receivedFilter audit.Filter
+	monkey.Patch(db.FindAuditEntries, func(filter audit.Filter) []audit.Entry {
+		receivedFilter = filter
+		return []audit.Entry{
+			audit.Entry{
+				Time:       time.Date(2021, 1, 5, 10, 30, 0, 0, time.UTC),
+				Actor:      "user",
+				Action:     audit.Login,
+				Target:     "user",
+				RemoteAddr: "127.0.0.1:5555"}}
+	})
+
+	auditCommand := AuditCommand{Actor: "user", From: "2021-01-01", To: "2021-01-31"}
+	message, err := auditCommand.ExecuteAs(&Session{Username: "admin"})
+
+	if err != nil {
+		t.Errorf("Command execution didn't complete with OK, but should have")
+	}
+
+	if !receivedFilter.To.Equal(time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("End date of the time range should be inclusive")
+	}
+
+	if message != "Audit log: 2021-01-05 10:30:00 login by user on user from 127.0.0.1:5555\n" {
+		t.Errorf("Invalid command execution message. Expected: Audit log: 2021-01-05 10:30:00 login by user on user from 127.0.0.1:5555\n, but got " + message)
+	}
+}
+
+func TestLoginDeactivatedUser(t *testing.T) {
+	defer monkey.UnpatchAll()
+	forgetFailedLogins(t, "user", "")
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return user.User{Username: "user", Password: "hash", Deactivated: true}, nil
+	})
+
+	monkey.Patch(user.ComparePasswords, func(string, string) bool {
+		return true
+	})
+
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		return
+	})
+
+	monkey.Patch(db.InsertToken, func(token.Token) {
+		t.Errorf("A session was started for a deactivated user")
+	})
+
+	message, err := LoginCommand{user.User{Username: "user", Password: "password1234"}}.Execute()
+	if err == nil || message != AccountDeactivated {
+		t.Errorf("Invalid command execution message. Expected: " + AccountDeactivated + ", but got " + message)
+	}
+}
+
+func TestLoginProvisionsUser(t *testing.T) {
+	defer monkey.UnpatchAll()
+	patchProviders(stubProvider{identity: auth.Identity{
+		Username:    "ivan",
+		DisplayName: "Ivan Petrov",
+		Email:       "not an address",
+		Provider:    auth.LDAPName}})
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return user.User{}, errors.New("User doesn't exist")
+	})
+
+	var inserted user.User
+	monkey.Patch(db.InsertRegisteredUser, func(newUser user.User) error {
+		inserted = newUser
+		return nil
+	})
+
+	var actions []string
+	monkey.Patch(db.InsertAuditEntry, func(entry audit.Entry) {
+		actions = append(actions, entry.Action)
+	})
+
+	session := &Session{}
+	message, err := LoginCommand{user.User{Username: "ivan", Password: "directory-password"}}.ExecuteAs(session)
+	if err != nil || message != "Login successful as ivan\n" || session.Username != "ivan" {
+		t.Errorf("Invalid command execution message. Expected: Login successful as ivan\n, but got " + message)
+	}
+
+	expected := user.User{Username: "ivan", DisplayName: "Ivan Petrov", Provider: auth.LDAPName}
+	if inserted != expected {
+		t.Errorf("The user was not provisioned with the valid parts of their profile, got %+v", inserted)
+	}
+
+	if len(actions) != 2 || actions[0] != audit.Provision || actions[1] != audit.Login {
+		t.Errorf("The provisioning and the login were not recorded in the audit log, got %v", actions)
+	}
+}
+
+func TestLoginProvisionedUserDiffersInCase(t *testing.T) {
+	defer monkey.UnpatchAll()
+	patchProviders(stubProvider{identity: auth.Identity{Username: "ivan", Provider: auth.LDAPName}})
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return user.User{}, errors.New("mongo: no documents in result")
+	})
+
+	monkey.Patch(db.InsertRegisteredUser, func(user.User) error {
+		return db.ErrDuplicate
+	})
+
+	monkey.Patch(db.FindUserIgnoringCase, func(string) (user.User, error) {
+		return user.User{Username: "Ivan", Provider: auth.LDAPName}, nil
+	})
+
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		return
+	})
+
+	message, err := LoginCommand{user.User{Username: "ivan", Password: "directory-password"}}.Execute()
+	if err != nil || message != "Login successful as Ivan\n" {
+		t.Errorf("Invalid command execution message. Expected: Login successful as Ivan\n, but got " + message)
+	}
+
+	monkey.Patch(db.FindUserIgnoringCase, func(string) (user.User, error) {
+		return user.User{}, errors.New("mongo: no documents in result")
+	})
+
+	expected := "Login unsuccessful - the user could not be created\n"
+	message, err = LoginCommand{user.User{Username: "ivan", Password: "directory-password"}}.Execute()
+	if err == nil || message != expected {
+		t.Errorf("Invalid command execution message. Expected: " + expected + ", but got " + message)
+	}
+}
+
+func TestLoginProviderCantTakeOverLocalUser(t *testing.T) {
+	defer monkey.UnpatchAll()
+	forgetFailedLogins(t, "user", "")
+	patchProviders(stubProvider{identity: auth.Identity{Username: "user", Provider: auth.LDAPName}})
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return user.User{Username: "user", Password: "hash"}, nil
+	})
+
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		return
+	})
+
+	message, err := LoginCommand{user.User{Username: "user", Password: "directory-password"}}.Execute()
+	if err == nil || message != InvalidCredentials {
+		t.Errorf("Invalid command execution message. Expected: " + InvalidCredentials + ", but got " + message)
+	}
+}
+
+func TestLoginProviderUnavailable(t *testing.T) {
+	defer monkey.UnpatchAll()
+	patchProviders(stubProvider{err: errors.New("connection refused")})
+
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		t.Errorf("A login which couldn't be checked should not be recorded as failed")
+	})
+
+	message, err := LoginCommand{user.User{Username: "user", Password: "password1234"}}.Execute()
+	if err == nil || message != ProviderUnavailable {
+		t.Errorf("Invalid command execution message. Expected: " + ProviderUnavailable + ", but got " + message)
+	}
+}
+
+func TestRegisterWithoutLocalProvider(t *testing.T) {
+	os.Setenv(config.AuthProvidersVariable, "ldap")
+	defer os.Unsetenv(config.AuthProvidersVariable)
+
+	message, err := RegisterCommand{user.User{Username: "user", Password: "password1234"}}.Execute()
+	expected := "Registration unsuccessful - the accounts are managed by an external authentication provider\n"
+	if err == nil || message != expected {
+		t.Errorf("Invalid command execution message. Expected: " + expected + ", but got " + message)
+	}
+}
+
+func TestPasswdProvisionedUser(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return user.User{Username: "ivan", Provider: auth.LDAPName}, nil
+	})
+
+	monkey.Patch(db.UpdatePassword, func(string, string) {
+		t.Errorf("The password of a provisioned user should not be stored on the server")
+	})
+
+	message, err := PasswdCommand{OldPassword: "old", NewPassword: "new password 1234"}.ExecuteAs(&Session{Username: "ivan"})
+	expected := "Could not change password - it is managed by the ldap authentication provider\n"
+	if err == nil || message != expected {
+		t.Errorf("Invalid command execution message. Expected: " + expected + ", but got " + message)
+	}
+}
+
+func TestWhoamiCommand(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return user.User{Username: "ivan", DisplayName: "Ivan Petrov", Timezone: "Europe/Sofia"}, nil
+	})
+
+	message, err := WhoamiCommand{}.ExecuteAs(&Session{Username: "ivan"})
+	expected := "User: ivan; Name: Ivan Petrov; Timezone: Europe/Sofia; Role: user; Status: active\n"
+	if err != nil || message != expected {
+		t.Errorf("Invalid command execution message. Expected: " + expected + ", but got " + message)
+	}
+
+	message, err = WhoamiCommand{}.Execute()
+	if err == nil || message != NotLoggedIn {
+		t.Errorf("Invalid command execution message. Expected: " + NotLoggedIn + ", but got " + message)
+	}
+}
+
+func TestShowMissingUser(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindRegisteredUser, func(string) (user.User, error) {
+		return user.User{}, errors.New("User doesn't exist")
+	})
+
+	message, err := ShowUserCommand{Username: "ghost"}.ExecuteAs(&Session{Username: "ivan"})
+	if err == nil || message != UserNotFound {
+		t.Errorf("Invalid command execution message. Expected: " + UserNotFound + ", but got " + message)
+	}
+}
+
+func TestUsersCommand(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.ListUsers, func() []user.User {
+		return []user.User{
+			{Username: "ivan", DisplayName: "Ivan Petrov"},
+			{Username: "maria"},
+			{Username: "old", Deactivated: true}}
+	})
+
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		return user.User{Username: username}, nil
+	})
+
+	message, err := UsersCommand{}.ExecuteAs(&Session{Username: "maria"})
+	expected := "Users: Ivan Petrov (ivan), maria, old [deactivated]\n"
+	if err != nil || message != expected {
+		t.Errorf("Invalid command execution message. Expected: " + expected + ", but got " + message)
+	}
+
+	message, err = UsersCommand{}.Execute()
+	if err != ErrUnauthenticated || message != NotLoggedIn {
+		t.Errorf("Invalid command execution message. Expected: " + NotLoggedIn + ", but got " + message)
 	}
 }
 
-func TestCreateNonUniqueIssue(t *testing.T) {
+func TestUserEmailIsPrivate(t *testing.T) {
 	defer monkey.UnpatchAll()
-	issueMock := issue.Issue{
-		Project:     "project",
-		Reporter:    "reporter",
-		Title:       "title",
-		Description: "description",
-		Resolved:    "false"}
-
-	projectMock := project.Project{
-		Name: "project"}
 
-	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
-		return projectMock, nil
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		return user.User{Username: username, Email: username + "@example.com", Admin: username == "admin"}, nil
 	})
 
-	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
-		return issueMock, nil
+	monkey.Patch(db.ListUsers, func() []user.User {
+		return []user.User{{Username: "ivan", Email: "ivan@example.com"}}
 	})
 
-	issueCommand := IssueCommand{issueMock}
-	message, ok := issueCommand.Execute()
-	if ok {
-		t.Errorf("Command execution completed with OK, but shouldn't have")
+	for viewer, email := range map[string]string{"maria": "", "ivan": "ivan@example.com", "admin": "ivan@example.com"} {
+		data, _, err := ShowUserCommand{Username: "ivan"}.DataAs(&Session{Username: viewer})
+		if err != nil || data.(user.User).Email != email {
+			t.Errorf("The email of ivan shown to %s should be '%s', but got %v", viewer, email, data)
+		}
+
+		data, _, err = UsersCommand{}.DataAs(&Session{Username: viewer})
+		if err != nil || data.([]user.User)[0].Email != email {
+			t.Errorf("The email of ivan listed to %s should be '%s', but got %v", viewer, email, data)
+		}
 	}
 
-	if message != "Could not create new issue - issue name is not unique for project\n" {
-		t.Errorf("Invalid command execution message. Expected: Could not create new issue - issue name is not unique for project\n, but got " + message)
+	if _, message, err := (ShowUserCommand{Username: "ivan"}).Data(); err != ErrUnauthenticated {
+		t.Errorf("Invalid command execution message. Expected: " + NotLoggedIn + ", but got " + message)
 	}
 }
 
-func TestCreateIssueMissingProject(t *testing.T) {
+func TestProfileCommandInvalidTimezone(t *testing.T) {
 	defer monkey.UnpatchAll()
 
-	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
-		return project.Project{}, errors.New("Project not found")
+	monkey.Patch(db.UpdateProfile, func(string, string, string, string) {
+		t.Errorf("An invalid profile was saved")
 	})
 
-	issueCommand := IssueCommand{issue.Issue{}}
-	message, ok := issueCommand.Execute()
-	if ok {
-		t.Errorf("Command execution completed with OK, but shouldn't have")
-	}
-
-	if message != "Could not find project \n" {
-		t.Errorf("Invalid command execution message. Expected: Could not find project \n, but got " + message)
+	message, err := ProfileCommand{Timezone: "Mars/Olympus"}.ExecuteAs(&Session{Username: "ivan"})
+	expected := "Could not update profile - the timezone should be a name such as Europe/Sofia or UTC\n"
+	if err == nil || message != expected {
+		t.Errorf("Invalid command execution message. Expected: " + expected + ", but got " + message)
 	}
 }
 
-func TestResolveIssue(t *testing.T) {
+func TestProfileCommand(t *testing.T) {
 	defer monkey.UnpatchAll()
-	issueMock := issue.Issue{
-		Project:     "project",
-		Reporter:    "reporter",
-		Title:       "title",
-		Description: "description",
-		Resolved:    "false"}
 
-	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
-		return project.Project{}, nil
+	var updated []string
+	monkey.Patch(db.UpdateProfile, func(username string, displayName string, email string, timezone string) {
+		updated = []string{username, displayName, email, timezone}
 	})
 
-	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
-		return issueMock, nil
-	})
+	message, err := ProfileCommand{DisplayName: "Ivan Petrov", Email: "ivan@example.com", Timezone: "Europe/Sofia"}.
+		ExecuteAs(&Session{Username: "ivan"})
+	if err != nil || message != "Profile updated successfully\n" {
+		t.Errorf("Invalid command execution message. Expected: Profile updated successfully\n, but got " + message)
+	}
 
-	monkey.Patch(db.ResolveIssue, func(string, string) {
-		return
-	})
+	if strings.Join(updated, ",") != "ivan,Ivan Petrov,ivan@example.com,Europe/Sofia" {
+		t.Errorf("The profile was not updated, got %v", updated)
+	}
+}
 
-	resolveCommand := ResolveCommand{Project: "project", Title: "title"}
-	message, ok := resolveCommand.Execute()
+func TestDeactivateNotAdmin(t *testing.T) {
+	os.Setenv(config.AdminsVariable, "admin")
+	defer os.Unsetenv(config.AdminsVariable)
 
-	if !ok {
-		t.Errorf("Command execution didn't complete with OK, but should have")
+	message, err := DeactivateCommand{Username: "ivan"}.ExecuteAs(&Session{Username: "maria"})
+	if err == nil || message != "Could not deactivate user - administrator rights are required\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not deactivate user - administrator rights are required\n, but got " + message)
 	}
+}
 
-	if message != "Issue resolved successfully\n" {
-		t.Errorf("Invalid command execution message. Expected: Issue resolved successfully\n, but got " + message)
+func TestDeactivateSelf(t *testing.T) {
+	defer monkey.UnpatchAll()
+	os.Setenv(config.AdminsVariable, "admin")
+	defer os.Unsetenv(config.AdminsVariable)
+	patchUsers()
+
+	message, err := DeactivateCommand{Username: "admin"}.ExecuteAs(&Session{Username: "admin"})
+	if err == nil || message != "Could not deactivate user - you can't deactivate yourself\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not deactivate user - you can't deactivate yourself\n, but got " + message)
 	}
 }
 
-func TestResolveMissingIssue(t *testing.T) {
+func TestDeactivateAndActivate(t *testing.T) {
 	defer monkey.UnpatchAll()
+	os.Setenv(config.AdminsVariable, "admin")
+	defer os.Unsetenv(config.AdminsVariable)
 
-	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
-		return project.Project{}, nil
+	stored := user.User{Username: "ivan"}
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		if username == "admin" {
+			return user.User{Username: username}, nil
+		}
+		return stored, nil
 	})
 
-	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
-		return issue.Issue{}, errors.New("Issue not found")
+	monkey.Patch(db.SetDeactivated, func(_ string, deactivated bool) {
+		stored.Deactivated = deactivated
 	})
 
-	resolveCommand := ResolveCommand{Project: "project", Title: "title"}
-	message, ok := resolveCommand.Execute()
+	revoked := false
+	monkey.Patch(db.DeleteUserTokens, func(username string, _ string) {
+		revoked = username == "ivan"
+	})
 
-	if ok {
-		t.Errorf("Command execution completed with OK, but shouldn't have")
+	var actions []string
+	monkey.Patch(db.InsertAuditEntry, func(entry audit.Entry) {
+		actions = append(actions, entry.Action)
+	})
+
+	disconnected := false
+	id := clients.Add("10.0.0.1:5000", func() { disconnected = true })
+	defer clients.Remove(id)
+	clients.SetUser(id, "ivan")
+
+	message, err := DeactivateCommand{Username: "ivan"}.ExecuteAs(&Session{Username: "admin"})
+	if err != nil || message != "User ivan deactivated successfully\n" {
+		t.Errorf("Invalid command execution message. Expected: User ivan deactivated successfully\n, but got " + message)
 	}
 
-	if message != "Could not resolve issue - issue does not exist \n" {
-		t.Errorf("Invalid command execution message. Expected: Could not resolve issue - issue does not exist \n, but got " + message)
+	if !stored.Deactivated || !revoked || !disconnected {
+		t.Errorf("The user was not deactivated or their sessions were not ended")
+	}
+
+	message, err = DeactivateCommand{Username: "ivan"}.ExecuteAs(&Session{Username: "admin"})
+	if err == nil || message != "User is already deactivated\n" {
+		t.Errorf("Invalid command execution message. Expected: User is already deactivated\n, but got " + message)
+	}
+
+	message, err = ActivateCommand{Username: "ivan"}.ExecuteAs(&Session{Username: "admin"})
+	if err != nil || message != "User ivan activated successfully\n" {
+		t.Errorf("Invalid command execution message. Expected: User ivan activated successfully\n, but got " + message)
+	}
+
+	if stored.Deactivated || strings.Join(actions, ",") != audit.Deactivate+","+audit.Activate {
+		t.Errorf("The user was not activated or the audit entries are wrong, got %v", actions)
 	}
 }
 
-func TestResolveIssueMissingProject(t *testing.T) {
+func TestStoredAdminRole(t *testing.T) {
 	defer monkey.UnpatchAll()
+	os.Setenv(config.AdminsVariable, "root")
+	defer os.Unsetenv(config.AdminsVariable)
 
-	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
-		return project.Project{}, errors.New("Project not found")
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		return user.User{Username: username, Admin: username != "maria", Deactivated: username == "old"}, nil
 	})
 
-	resolveCommand := ResolveCommand{Project: "project", Title: "title"}
-	message, ok := resolveCommand.Execute()
-
-	if ok {
-		t.Errorf("Command execution completed with OK, but shouldn't have")
+	if !isAdmin("root") || !isAdmin("ivan") {
+		t.Errorf("The administrators were not recognized")
 	}
 
-	if message != "Could not find project \n" {
-		t.Errorf("Invalid command execution message. Expected: Could not find project \n, but got " + message)
+	if isAdmin("maria") || isAdmin("old") || isAdmin("") {
+		t.Errorf("A regular or deactivated user was recognized as an administrator")
 	}
 }
 
-func TestResolveResolvedIssue(t *testing.T) {
+func TestGrantAndRevokeAdmin(t *testing.T) {
 	defer monkey.UnpatchAll()
-	issueMock := issue.Issue{
-		Project:     "project",
-		Reporter:    "reporter",
-		Title:       "title",
-		Description: "description",
-		Resolved:    "true"}
+	os.Setenv(config.AdminsVariable, "root")
+	defer os.Unsetenv(config.AdminsVariable)
 
-	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
-		return project.Project{}, nil
+	stored := user.User{Username: "ivan"}
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		if username == "ivan" {
+			return stored, nil
+		}
+		return user.User{Username: username}, nil
 	})
 
-	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
-		return issueMock, nil
+	monkey.Patch(db.SetAdmin, func(_ string, admin bool) {
+		stored.Admin = admin
 	})
 
-	resolveCommand := ResolveCommand{Project: "project", Title: "title"}
-	message, ok := resolveCommand.Execute()
+	var targets []string
+	monkey.Patch(db.InsertAuditEntry, func(entry audit.Entry) {
+		targets = append(targets, entry.Target)
+	})
 
-	if ok {
-		t.Errorf("Command execution completed with OK, but shouldn't have")
+	message, err := GrantAdminCommand{Username: "ivan"}.ExecuteAs(&Session{Username: "root"})
+	if err != nil || message != "User ivan is now an administrator\n" {
+		t.Errorf("Invalid command execution message. Expected: User ivan is now an administrator\n, but got " + message)
 	}
 
-	if message != "Issue is already resolved \n" {
-		t.Errorf("Invalid command execution message. Expected: Issue is already resolved \n, but got " + message)
+	message, err = GrantAdminCommand{Username: "maria"}.ExecuteAs(&Session{Username: "ivan"})
+	if err != nil || message != "User maria is now an administrator\n" {
+		t.Errorf("Invalid command execution message. Expected: User maria is now an administrator\n, but got " + message)
+	}
+
+	message, err = RevokeAdminCommand{Username: "root"}.ExecuteAs(&Session{Username: "ivan"})
+	if err == nil || message != "Could not revoke administrator rights - the user is an administrator by configuration\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not revoke administrator rights - the user is an administrator by configuration\n, but got " + message)
+	}
+
+	message, err = RevokeAdminCommand{Username: "ivan"}.ExecuteAs(&Session{Username: "root"})
+	if err != nil || message != "User ivan is no longer an administrator\n" {
+		t.Errorf("Invalid command execution message. Expected: User ivan is no longer an administrator\n, but got " + message)
+	}
+
+	if stored.Admin || strings.Join(targets, ",") != "ivan (admin),maria (admin),ivan (user)" {
+		t.Errorf("The role was not revoked or the audit entries are wrong, got %v", targets)
 	}
 }
 
-func TestListCommand(t *testing.T) {
+func TestRevokeOwnAdmin(t *testing.T) {
 	defer monkey.UnpatchAll()
+	os.Setenv(config.AdminsVariable, "root")
+	defer os.Unsetenv(config.AdminsVariable)
+	patchUsers()
 
-	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
-		return project.Project{}, nil
-	})
+	message, err := RevokeAdminCommand{Username: "root"}.ExecuteAs(&Session{Username: "root"})
+	if err == nil || message != "Could not revoke administrator rights - you can't revoke your own rights\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not revoke administrator rights - you can't revoke your own rights\n, but got " + message)
+	}
+}
 
-	monkey.Patch(db.ListIssues, func(string) []issue.Issue {
-		return []issue.Issue{issue.Issue{Title: "first issue"}, issue.Issue{Title: "second issue"}}
-	})
+func TestConfiguredAdminMustBeActive(t *testing.T) {
+	defer monkey.UnpatchAll()
+	os.Setenv(config.AdminsVariable, "root,ghost")
+	defer os.Unsetenv(config.AdminsVariable)
 
-	listCommand := ListCommand{Project: "project"}
-	message, ok := listCommand.Execute()
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		if username == "ghost" {
+			return user.User{}, errors.New("User doesn't exist")
+		}
+		return user.User{Username: username, Deactivated: true}, nil
+	})
 
-	if !ok {
-		t.Errorf("Command execution didn't complete with OK, but should have")
+	for _, username := range []string{"root", "ghost"} {
+		message, err := StatsCommand{}.ExecuteAs(&Session{Username: username})
+		if err != ErrForbidden {
+			t.Errorf("A configured administrator who is deactivated or doesn't exist should have no rights, but got " + message)
+		}
 	}
+}
 
-	if message != "Issues in project: first issue, second issue\n" {
-		t.Errorf("Invalid command execution message. Expected: Issues in project: first issue, second issue\n, but got " + message)
+func TestDeleteProjectNotAdmin(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		return user.User{Username: username}, nil
+	})
+
+	monkey.Patch(db.DeleteProject, func(string) {
+		t.Errorf("A project was deleted by a regular user")
+	})
+
+	message, err := DeleteProjectCommand{Name: "name"}.ExecuteAs(&Session{Username: "owner"})
+	if err == nil || message != "Could not delete project - administrator rights are required\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not delete project - administrator rights are required\n, but got " + message)
 	}
 }
 
-func TestListCommandNoIssues(t *testing.T) {
+func TestDeleteProject(t *testing.T) {
 	defer monkey.UnpatchAll()
+	os.Setenv(config.AdminsVariable, "root")
+	defer os.Unsetenv(config.AdminsVariable)
+	patchUsers()
 
-	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
-		return project.Project{}, nil
+	monkey.Patch(db.FindExistingProject, func(name string) (project.Project, error) {
+		if name != "name" {
+			return project.Project{}, errors.New("Project doesn't exist")
+		}
+		return project.Project{Name: name}, nil
 	})
 
-	monkey.Patch(db.ListIssues, func(string) []issue.Issue {
-		return []issue.Issue{}
+	deleted := ""
+	monkey.Patch(db.DeleteProject, func(name string) {
+		deleted = name
 	})
 
-	listCommand := ListCommand{Project: "project"}
-	message, ok := listCommand.Execute()
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		return
+	})
 
-	if !ok {
-		t.Errorf("Command execution didn't complete with OK, but should have")
+	message, err := DeleteProjectCommand{Name: "missing"}.ExecuteAs(&Session{Username: "root"})
+	if err == nil || message != ProjectNotFound {
+		t.Errorf("Invalid command execution message. Expected: " + ProjectNotFound + ", but got " + message)
 	}
 
-	if message != "There aren't any issues in this project\n" {
-		t.Errorf("Invalid command execution message. Expected: There aren't any issues in this project\n, but got " + message)
+	message, err = DeleteProjectCommand{Name: "name"}.ExecuteAs(&Session{Username: "root"})
+	if err != nil || message != "Project name deleted successfully\n" || deleted != "name" {
+		t.Errorf("Invalid command execution message. Expected: Project name deleted successfully\n, but got " + message)
 	}
 }
 
-func TestListCommandMissingProject(t *testing.T) {
+func TestStatsAsData(t *testing.T) {
 	defer monkey.UnpatchAll()
+	os.Setenv(config.AdminsVariable, "root")
+	defer os.Unsetenv(config.AdminsVariable)
+	patchUsers()
 
-	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
-		return project.Project{}, errors.New("Project not found")
+	monkey.Patch(db.CountDocuments, func() stats.Stats {
+		return stats.Stats{Users: 3, OpenIssues: 2}
 	})
 
-	listCommand := ListCommand{Project: "project"}
-	message, ok := listCommand.Execute()
-
-	if ok {
-		t.Errorf("Command execution completed with OK, but shouldn't have")
+	message, err := ExecuteInSession(ParseCommand("data|-|stats"), &Session{Username: "root"})
+	if err != nil || !strings.HasPrefix(message, `data|-|{"users":3,"deactivatedUsers":0,"projects":0,"openIssues":2,`) {
+		t.Errorf("Invalid command execution message, got " + message)
 	}
 
-	if message != "Could not find project \n" {
-		t.Errorf("Invalid command execution message. Expected: Could not find project \n, but got " + message)
+	message, err = ParseCommand("data|-|stats").Execute()
+	if err == nil || message != "Could not show statistics - administrator rights are required\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not show statistics - administrator rights are required\n, but got " + message)
 	}
 }
 
-func TestFindCommand(t *testing.T) {
+func TestKick(t *testing.T) {
 	defer monkey.UnpatchAll()
-	commentMock := comment.Comment{
-		Project:   "project",
-		Title:     "title",
-		Content:   "content",
-		Commenter: "commenter"}
-	issueMock := issue.Issue{
-		Project:     "project",
-		Reporter:    "reporter",
-		Title:       "title",
-		Description: "description",
-		Resolved:    "true"}
-
-	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
-		return project.Project{Name: "project"}, nil
-	})
+	os.Setenv(config.AdminsVariable, "root")
+	defer os.Unsetenv(config.AdminsVariable)
+	patchUsers()
 
-	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
-		return issueMock, nil
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		return
 	})
 
-	monkey.Patch(db.FindComments, func(string, string) []comment.Comment {
-		return []comment.Comment{commentMock}
-	})
+	closed := false
+	id := clients.Add("10.0.0.1:5000", func() { closed = true })
+	defer clients.Remove(id)
+	own := clients.Add("10.0.0.2:5000", func() {})
+	defer clients.Remove(own)
 
-	findCommand := FindCommand{Project: "project", Title: "title"}
-	message, ok := findCommand.Execute()
+	message, err := KickCommand{ID: own}.ExecuteAs(&Session{Username: "root", ClientID: own})
+	expected := "Could not disconnect client - use disconnect to close your own connection\n"
+	if err == nil || message != expected {
+		t.Errorf("Invalid command execution message. Expected: " + expected + ", but got " + message)
+	}
 
-	if !ok {
-		t.Errorf("Command execution didn't complete with OK, but should have")
+	message, err = KickCommand{ID: id}.ExecuteAs(&Session{Username: "root", ClientID: own})
+	expected = "Client #" + strconv.Itoa(id) + " disconnected successfully\n"
+	if err != nil || message != expected || !closed {
+		t.Errorf("Invalid command execution message. Expected: " + expected + ", but got " + message)
 	}
 
-	if message != "Project: project; Reporter: reporter; Title: title; Description: description; Resolved: true; Comments: \"content\" - commenter;\n" {
-		t.Errorf("Invalid command execution message. Expected: Project: project; Reporter: reporter; Title: title; Description: description; Resolved: true; Comments: \"content\" - commenter;\n, but got " + message)
+	message, err = KickCommand{ID: id}.ExecuteAs(&Session{Username: "root", ClientID: own})
+	expected = "Could not disconnect client - there is no client #" + strconv.Itoa(id) + "\n"
+	if err == nil || message != expected {
+		t.Errorf("Invalid command execution message. Expected: " + expected + ", but got " + message)
 	}
 }
 
-func TestFindCommandMissingIssue(t *testing.T) {
+func TestAccessTokenScopes(t *testing.T) {
 	defer monkey.UnpatchAll()
 
-	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
-		return project.Project{Name: "project"}, nil
+	monkey.Patch(db.ListProjects, func() []project.Project {
+		return nil
 	})
 
-	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
-		return issue.Issue{}, errors.New("Missing issue")
+	monkey.Patch(db.InsertNewProject, func(project.Project) error {
+		t.Errorf("A project was created with a read-only token")
+		return nil
 	})
 
-	findCommand := FindCommand{Project: "project", Title: "title"}
-	message, ok := findCommand.Execute()
+	session := &Session{Username: "ci", Scopes: []token.Scope{token.ScopeRead}}
+	if _, err := ExecuteInSession(ParseCommand("data|-|projects"), session); err != nil {
+		t.Errorf("A read-only token could not list the projects")
+	}
 
-	if ok {
-		t.Errorf("Command execution completed with OK, but shouldn't have")
+	message, err := ExecuteInSession(ProjectCommand{Project: project.Project{Name: "name"}}, session)
+	if err != ErrForbidden || message != MissingScope {
+		t.Errorf("Invalid command execution message. Expected: " + MissingScope + ", but got " + message)
 	}
 
-	if message != "Issue does not exist \n" {
-		t.Errorf("Invalid command execution message. Expected: Issue does not exist \n, but got " + message)
+	for _, c := range []Command{InboxCommand{}, MarkReadCommand{}, ListWebhooksCommand{}, DeliveriesCommand{}} {
+		if message, err := checkScope(c, session.Scopes); err != nil {
+			t.Errorf("A read-only token could not execute %T: %s", c, message)
+		}
+	}
+
+	session.Scopes = []token.Scope{token.ScopeAdmin}
+	message, err = ExecuteInSession(CreateAccessTokenCommand{Name: "more", Scopes: "admin"}, session)
+	if err == nil || message != CredentialsWithToken {
+		t.Errorf("Invalid command execution message. Expected: " + CredentialsWithToken + ", but got " + message)
 	}
 }
 
-func TestFindCommandMissingProject(t *testing.T) {
-	defer monkey.UnpatchAll()
+func TestWriteCommandsRequireLogin(t *testing.T) {
+	for _, c := range []Command{
+		ProjectCommand{Project: project.Project{Name: "name"}},
+		IssueCommand{issue.Issue{Project: "name", Title: "title"}},
+		AddWebhookCommand{Project: "name", URL: "https://example.org", Secret: "secret"},
+		WatchCommand{Project: "name"},
+		DeleteProjectCommand{Name: "name"}} {
+		if message, err := ExecuteInSession(c, &Session{}); err != ErrUnauthenticated || message != NotLoggedIn {
+			t.Errorf("An anonymous session executed %T: %s", c, message)
+		}
+	}
 
-	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
-		return project.Project{}, errors.New("Missing project")
-	})
+	for _, c := range []Command{RegisterCommand{}, LoginCommand{}, ResumeCommand{}, SetPasswordCommand{}, ProjectsCommand{}} {
+		if requiresLogin(c) {
+			t.Errorf("%T should be allowed before logging in", c)
+		}
+	}
+}
 
-	findCommand := FindCommand{Project: "project", Title: "title"}
-	message, ok := findCommand.Execute()
+func TestLogoutKeepsAccessToken(t *testing.T) {
+	defer monkey.UnpatchAll()
 
-	if ok {
-		t.Errorf("Command execution completed with OK, but shouldn't have")
-	}
+	monkey.Patch(db.DeleteToken, func(string) {
+		t.Errorf("The access token was revoked on logout")
+	})
 
-	if message != "Could not find project \n" {
-		t.Errorf("Invalid command execution message. Expected: Could not find project \n, but got " + message)
+	session := &Session{Username: "ci", TokenHash: "hash", Scopes: []token.Scope{token.ScopeWrite}}
+	if message, err := (LogoutCommand{}).ExecuteAs(session); err != nil || session.Username != "" || session.Scopes != nil {
+		t.Errorf("Invalid command execution message. Expected: Successfully logged out\n, but got " + message)
 	}
 }
 
-func TestCommentCommand(t *testing.T) {
+func TestCreateAccessToken(t *testing.T) {
 	defer monkey.UnpatchAll()
-	commentMock := comment.Comment{
-		Project:   "project",
-		Title:     "title",
-		Content:   "content",
-		Commenter: "commenter"}
 
-	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
-		return project.Project{Name: "project"}, nil
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		return user.User{Username: username}, nil
 	})
 
-	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
-		return issue.Issue{}, nil
+	var inserted token.Token
+	monkey.Patch(db.InsertAccessToken, func(newToken token.Token) int {
+		inserted = newToken
+		return 4
 	})
 
-	monkey.Patch(db.InsertComment, func(comment.Comment) {
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
 		return
 	})
 
-	commentCommand := CommentCommand{commentMock}
-	message, ok := commentCommand.Execute()
+	message, err := CreateAccessTokenCommand{Name: "deploy", Scopes: "read,write", Days: "30"}.ExecuteAs(&Session{Username: "ivan"})
+	prefix := "Token #4 for ivan (read,write), valid until "
+	if err != nil || !strings.HasPrefix(message, prefix) {
+		t.Fatalf("Invalid command execution message. Expected: " + prefix + "..., but got " + message)
+	}
 
-	if !ok {
-		t.Errorf("Command execution didn't complete with OK, but should have")
+	secret := strings.TrimSpace(message[strings.LastIndex(message, " ")+1:])
+	if inserted.Hash != token.Hash(secret) || inserted.Name != "deploy" || inserted.Username != "ivan" {
+		t.Errorf("The stored token doesn't match the shown secret: %+v", inserted)
 	}
 
-	if message != "Comment added successfully\n" {
-		t.Errorf("Invalid command execution message. Expected: Comment added successfully\n, but got " + message)
+	if lifetime := inserted.Expires.Sub(inserted.Created); lifetime != 30*24*time.Hour {
+		t.Errorf("Expected the token to be valid for 30 days, but it is valid for %v", lifetime)
 	}
 }
 
-func TestCommentCommandMissingIssue(t *testing.T) {
+func TestCreateAccessTokenForServiceAccountOnly(t *testing.T) {
 	defer monkey.UnpatchAll()
+	os.Setenv(config.AdminsVariable, "admin")
+	defer os.Unsetenv(config.AdminsVariable)
 
-	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
-		return project.Project{Name: "project"}, nil
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		return user.User{Username: username, Service: username == "ci-bot"}, nil
 	})
 
-	monkey.Patch(db.FindExistingIssue, func(string, string) (issue.Issue, error) {
-		return issue.Issue{}, errors.New("Issue not found")
+	monkey.Patch(db.InsertAccessToken, func(token.Token) int {
+		return 4
 	})
 
-	commentCommand := CommentCommand{comment.Comment{}}
-	message, ok := commentCommand.Execute()
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		return
+	})
 
-	if ok {
-		t.Errorf("Command execution completed with OK, but shouldn't have")
+	expected := "Could not create token - tokens for other users can be created only for service accounts\n"
+	message, err := CreateAccessTokenCommand{Username: "ivan", Name: "deploy", Scopes: "read"}.ExecuteAs(&Session{Username: "admin"})
+	if err != ErrForbidden || message != expected {
+		t.Errorf("Invalid command execution message. Expected: " + expected + ", but got " + message)
 	}
 
-	if message != "Issue does not exist \n" {
-		t.Errorf("Invalid command execution message. Expected: Issue does not exist \n, but got " + message)
+	prefix := "Token #4 for ci-bot (read), valid until "
+	message, err = CreateAccessTokenCommand{Username: "ci-bot", Name: "deploy", Scopes: "read"}.ExecuteAs(&Session{Username: "admin"})
+	if err != nil || !strings.HasPrefix(message, prefix) {
+		t.Errorf("Invalid command execution message. Expected: " + prefix + "..., but got " + message)
 	}
 }
 
-func TestCommentCommandMissingProject(t *testing.T) {
+func TestCreateAccessTokenInvalid(t *testing.T) {
+	os.Setenv(config.AdminsVariable, "admin")
+	defer os.Unsetenv(config.AdminsVariable)
+
+	invalid := map[CreateAccessTokenCommand]string{
+		{Username: "other", Name: "deploy", Scopes: "read"}: "Could not create token - administrator rights are required to create tokens for other users\n",
+		{Scopes: "read"}:                              "Could not create token - a name is required\n",
+		{Name: "deploy", Scopes: "delete"}:            "Could not create token - unknown scope delete, expected read, write or admin\n",
+		{Name: "deploy", Scopes: "read", Days: "400"}: "Could not create token - the days should be a number between 1 and 366\n",
+	}
+
+	for cc, expected := range invalid {
+		message, err := cc.ExecuteAs(&Session{Username: "ivan"})
+		if err == nil || message != expected {
+			t.Errorf("Invalid command execution message. Expected: " + expected + ", but got " + message)
+		}
+	}
+}
+
+func TestRevokeAccessTokenNotOwner(t *testing.T) {
 	defer monkey.UnpatchAll()
 
-	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
-		return project.Project{}, errors.New("Project not found")
+	monkey.Patch(db.FindAccessToken, func(id int) (token.Token, error) {
+		return token.Token{ID: id, Username: "ivan", Scopes: []token.Scope{token.ScopeRead}}, nil
 	})
 
-	commentCommand := CommentCommand{comment.Comment{}}
-	message, ok := commentCommand.Execute()
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		return user.User{Username: username}, nil
+	})
 
-	if ok {
-		t.Errorf("Command execution completed with OK, but shouldn't have")
+	monkey.Patch(db.DeleteAccessToken, func(int) {
+		t.Errorf("A token was revoked by someone else")
+	})
+
+	message, err := RevokeAccessTokenCommand{ID: 4}.ExecuteAs(&Session{Username: "maria"})
+	if err == nil || message != "Could not revoke token - only its owner or an administrator can revoke it\n" {
+		t.Errorf("Invalid command execution message. Expected: Could not revoke token - only its owner or an administrator can revoke it\n, but got " + message)
 	}
+}
 
-	if message != "Could not find project \n" {
-		t.Errorf("Invalid command execution message. Expected: Could not find project \n, but got " + message)
+func TestServiceAccount(t *testing.T) {
+	defer monkey.UnpatchAll()
+	os.Setenv(config.AdminsVariable, "admin")
+	defer os.Unsetenv(config.AdminsVariable)
+	patchUsers()
+
+	var inserted user.User
+	monkey.Patch(db.InsertRegisteredUser, func(newUser user.User) error {
+		inserted = newUser
+		return nil
+	})
+
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		return
+	})
+
+	message, err := ServiceAccountCommand{Username: "ci-bot"}.ExecuteAs(&Session{Username: "admin"})
+	if err != nil || message != "Service account ci-bot created successfully\n" {
+		t.Errorf("Invalid command execution message. Expected: Service account ci-bot created successfully\n, but got " + message)
+	}
+
+	if !inserted.Service || inserted.Password != "" || user.ComparePasswords(inserted.Password, "") {
+		t.Errorf("The service account should have no password to log in with: %+v", inserted)
 	}
 }
@@ -1,9 +1,64 @@
 package comment
 
+import (
+	"sort"
+	"time"
+)
+
 // Comment is an abstraction for a real-life comment
 type Comment struct {
-	Project   string
-	Title     string
-	Content   string
-	Commenter string
+	ID        int       `json:"id"`
+	Parent    int       `json:"parent,omitempty"`
+	Project   string    `json:"project"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Commenter string    `json:"commenter"`
+	Created   time.Time `json:"created"`
+	Edited    time.Time `json:"edited"`
+	Deleted   bool      `json:"deleted,omitempty"`
+}
+
+// ThreadEntry is a comment together with its depth in the thread it belongs to
+type ThreadEntry struct {
+	Comment Comment `json:"comment"`
+	Depth   int     `json:"depth"`
+}
+
+// Thread orders the comments for an issue as a thread - every comment is followed by its replies
+// and comments on the same level are in chronological order
+func Thread(comments []Comment) []ThreadEntry {
+	sorted := make([]Comment, len(comments))
+	copy(sorted, comments)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Created.Before(sorted[j].Created)
+	})
+
+	ids := make(map[int]bool)
+	for _, c := range sorted {
+		ids[c.ID] = true
+	}
+
+	replies := make(map[int][]Comment)
+	var roots []Comment
+	for _, c := range sorted {
+		if c.Parent != 0 && c.Parent != c.ID && ids[c.Parent] {
+			replies[c.Parent] = append(replies[c.Parent], c)
+		} else {
+			roots = append(roots, c)
+		}
+	}
+
+	var thread []ThreadEntry
+	var walk func(c Comment, depth int)
+	walk = func(c Comment, depth int) {
+		thread = append(thread, ThreadEntry{Comment: c, Depth: depth})
+		for _, reply := range replies[c.ID] {
+			walk(reply, depth+1)
+		}
+	}
+	for _, root := range roots {
+		walk(root, 0)
+	}
+
+	return thread
 }
@@ -0,0 +1,40 @@
+package comment
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThread(t *testing.T) {
+	start := time.Date(2021, 1, 5, 10, 0, 0, 0, time.UTC)
+	comments := []Comment{
+		Comment{ID: 4, Parent: 1, Created: start.Add(4 * time.Minute)},
+		Comment{ID: 3, Created: start.Add(3 * time.Minute)},
+		Comment{ID: 1, Created: start.Add(1 * time.Minute)},
+		Comment{ID: 2, Parent: 1, Created: start.Add(2 * time.Minute)},
+		Comment{ID: 5, Parent: 2, Created: start.Add(5 * time.Minute)}}
+
+	expectedIDs := []int{1, 2, 5, 4, 3}
+	expectedDepths := []int{0, 1, 2, 1, 0}
+
+	thread := Thread(comments)
+	if len(thread) != len(expectedIDs) {
+		t.Fatalf("Expected %d comments in the thread, but got %d", len(expectedIDs), len(thread))
+	}
+
+	for i, entry := range thread {
+		if entry.Comment.ID != expectedIDs[i] || entry.Depth != expectedDepths[i] {
+			t.Errorf("Comment %d in the thread should be #%d at depth %d, but got #%d at depth %d",
+				i, expectedIDs[i], expectedDepths[i], entry.Comment.ID, entry.Depth)
+		}
+	}
+}
+
+func TestThreadMissingParent(t *testing.T) {
+	comments := []Comment{Comment{ID: 2, Parent: 1}}
+
+	thread := Thread(comments)
+	if len(thread) != 1 || thread[0].Depth != 0 {
+		t.Errorf("Reply to a missing comment should be shown at the top level")
+	}
+}
@@ -0,0 +1,283 @@
+package config
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AdminsVariable is the environment variable listing the server administrators, separated by commas
+const AdminsVariable = "ISSUETRACKER_ADMINS"
+
+// Admins returns the usernames which have administrator rights on the server
+func Admins() []string {
+	var admins []string
+	for _, username := range strings.Split(os.Getenv(AdminsVariable), ",") {
+		if username = strings.TrimSpace(username); username != "" {
+			admins = append(admins, username)
+		}
+	}
+
+	return admins
+}
+
+// IsAdmin checks whether a user has administrator rights on the server
+func IsAdmin(username string) bool {
+	if username == "" {
+		return false
+	}
+
+	for _, admin := range Admins() {
+		if admin == username {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Environment variables for sending notifications by email
+const (
+	SMTPAddressVariable    = "ISSUETRACKER_SMTP_ADDRESS"
+	SMTPFromVariable       = "ISSUETRACKER_SMTP_FROM"
+	SMTPUsernameVariable   = "ISSUETRACKER_SMTP_USERNAME"
+	SMTPPasswordVariable   = "ISSUETRACKER_SMTP_PASSWORD"
+	DigestIntervalVariable = "ISSUETRACKER_DIGEST_INTERVAL"
+)
+
+// SMTP holds the settings of the server used for sending emails
+type SMTP struct {
+	Address        string
+	From           string
+	Username       string
+	Password       string
+	DigestInterval time.Duration
+}
+
+// Email returns the settings for sending notifications by email. Emails are disabled when no SMTP server address is set
+func Email() (SMTP, bool) {
+	settings := SMTP{
+		Address:        os.Getenv(SMTPAddressVariable),
+		From:           os.Getenv(SMTPFromVariable),
+		Username:       os.Getenv(SMTPUsernameVariable),
+		Password:       os.Getenv(SMTPPasswordVariable),
+		DigestInterval: 24 * time.Hour}
+
+	if settings.From == "" {
+		settings.From = "issuetracker@localhost"
+	}
+
+	if interval, err := time.ParseDuration(os.Getenv(DigestIntervalVariable)); err == nil && interval > 0 {
+		settings.DigestInterval = interval
+	}
+
+	return settings, settings.Address != ""
+}
+
+// WebhookNetworksVariable is the environment variable listing the private networks to which webhooks may post,
+// separated by commas
+const WebhookNetworksVariable = "ISSUETRACKER_WEBHOOK_ALLOWED_NETWORKS"
+
+// WebhookNetworks returns the private networks to which webhooks may post, given as e.g. 10.0.0.0/8 or a single
+// address. Invalid networks are skipped
+func WebhookNetworks() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range strings.Split(os.Getenv(WebhookNetworksVariable), ",") {
+		if cidr = strings.TrimSpace(cidr); cidr == "" {
+			continue
+		}
+
+		if ip := net.ParseIP(cidr); ip != nil {
+			bits := 8 * len(ip)
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		} else if _, network, err := net.ParseCIDR(cidr); err == nil {
+			networks = append(networks, network)
+		}
+	}
+
+	return networks
+}
+
+// HTTPAddressVariable is the environment variable holding the address of the HTTP API and the web interface
+const HTTPAddressVariable = "ISSUETRACKER_HTTP_ADDRESS"
+
+// HTTPAddress returns the address on which the HTTP API and the web interface listen, 0.0.0.0:8080 by default
+func HTTPAddress() string {
+	if address := strings.TrimSpace(os.Getenv(HTTPAddressVariable)); address != "" {
+		return address
+	}
+
+	return "0.0.0.0:8080"
+}
+
+// GRPCAddressVariable is the environment variable holding the address of the gRPC service
+const GRPCAddressVariable = "ISSUETRACKER_GRPC_ADDRESS"
+
+// GRPCAddress returns the address on which the gRPC service listens, 0.0.0.0:9090 by default
+func GRPCAddress() string {
+	if address := strings.TrimSpace(os.Getenv(GRPCAddressVariable)); address != "" {
+		return address
+	}
+
+	return "0.0.0.0:9090"
+}
+
+// Environment variables for the passwords of the users
+const (
+	PasswordMinLengthVariable  = "ISSUETRACKER_PASSWORD_MIN_LENGTH"
+	PasswordMinClassesVariable = "ISSUETRACKER_PASSWORD_MIN_CLASSES"
+	BcryptCostVariable         = "ISSUETRACKER_BCRYPT_COST"
+)
+
+// Passwords holds the requirements for new passwords and the cost with which they are hashed
+type Passwords struct {
+	MinLength int
+	// MinClasses is how many of lowercase letters, uppercase letters, digits and symbols a password should contain
+	MinClasses int
+	BcryptCost int
+}
+
+// PasswordPolicy returns the requirements for new passwords, by default at least 8 characters of any kind
+// hashed with cost 10. Invalid values fall back to the defaults
+func PasswordPolicy() Passwords {
+	policy := Passwords{MinLength: 8, MinClasses: 1, BcryptCost: 10}
+
+	if length, err := strconv.Atoi(strings.TrimSpace(os.Getenv(PasswordMinLengthVariable))); err == nil && length > 0 {
+		policy.MinLength = length
+	}
+
+	if classes, err := strconv.Atoi(strings.TrimSpace(os.Getenv(PasswordMinClassesVariable))); err == nil && classes >= 1 && classes <= 4 {
+		policy.MinClasses = classes
+	}
+
+	if cost, err := strconv.Atoi(strings.TrimSpace(os.Getenv(BcryptCostVariable))); err == nil && cost >= 4 && cost <= 31 {
+		policy.BcryptCost = cost
+	}
+
+	return policy
+}
+
+// Environment variables for protecting the accounts against guessing their passwords
+const (
+	LockoutThresholdVariable        = "ISSUETRACKER_LOCKOUT_THRESHOLD"
+	LockoutAddressThresholdVariable = "ISSUETRACKER_LOCKOUT_ADDRESS_THRESHOLD"
+	LockoutDurationVariable         = "ISSUETRACKER_LOCKOUT_DURATION"
+)
+
+// Lockout holds after how many failed logins an account or a remote address is locked out, and for how long
+type Lockout struct {
+	AccountThreshold int
+	// AddressThreshold is higher, because many users may log in from behind the same address
+	AddressThreshold int
+	Duration         time.Duration
+}
+
+// LoginLockout returns the settings for locking out accounts and addresses, by default 5 failures for an account
+// and 20 for an address locking them out for 15 minutes. Invalid values fall back to the defaults
+func LoginLockout() Lockout {
+	settings := Lockout{AccountThreshold: 5, AddressThreshold: 20, Duration: 15 * time.Minute}
+
+	if threshold, err := strconv.Atoi(strings.TrimSpace(os.Getenv(LockoutThresholdVariable))); err == nil && threshold > 0 {
+		settings.AccountThreshold = threshold
+	}
+
+	if threshold, err := strconv.Atoi(strings.TrimSpace(os.Getenv(LockoutAddressThresholdVariable))); err == nil && threshold > 0 {
+		settings.AddressThreshold = threshold
+	}
+
+	if duration, err := time.ParseDuration(strings.TrimSpace(os.Getenv(LockoutDurationVariable))); err == nil && duration > 0 {
+		settings.Duration = duration
+	}
+
+	return settings
+}
+
+// AuthProvidersVariable is the environment variable listing the providers which check the passwords of the users,
+// separated by commas in the order in which they are tried, e.g. local,ldap
+const AuthProvidersVariable = "ISSUETRACKER_AUTH_PROVIDERS"
+
+// AuthProviders returns the names of the providers which check the passwords of the users, only local by default
+func AuthProviders() []string {
+	var providers []string
+	for _, name := range strings.Split(os.Getenv(AuthProvidersVariable), ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			providers = append(providers, name)
+		}
+	}
+
+	if len(providers) == 0 {
+		return []string{"local"}
+	}
+
+	return providers
+}
+
+// Environment variables for authenticating the users against an LDAP directory
+const (
+	LDAPURLVariable               = "ISSUETRACKER_LDAP_URL"
+	LDAPInsecureVariable          = "ISSUETRACKER_LDAP_INSECURE"
+	LDAPBindDNVariable            = "ISSUETRACKER_LDAP_BIND_DN"
+	LDAPBindPasswordVariable      = "ISSUETRACKER_LDAP_BIND_PASSWORD"
+	LDAPBaseDNVariable            = "ISSUETRACKER_LDAP_BASE_DN"
+	LDAPUserFilterVariable        = "ISSUETRACKER_LDAP_USER_FILTER"
+	LDAPUsernameAttributeVariable = "ISSUETRACKER_LDAP_USERNAME_ATTRIBUTE"
+	LDAPNameAttributeVariable     = "ISSUETRACKER_LDAP_NAME_ATTRIBUTE"
+	LDAPEmailAttributeVariable    = "ISSUETRACKER_LDAP_EMAIL_ATTRIBUTE"
+)
+
+// Directory holds the settings of the LDAP directory in which the users are looked up
+type Directory struct {
+	URL string
+	// Insecure allows an ldap:// URL without StartTLS, which sends the passwords in cleartext
+	Insecure bool
+	// BindDN and BindPassword are of the account with which the users are searched for, anonymous when empty
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+	// UserFilter finds the entry of a user, with %s replaced by the escaped username
+	UserFilter string
+	// UsernameAttribute holds the username as stored in the directory, which may differ in case from the typed one
+	UsernameAttribute string
+	NameAttribute     string
+	EmailAttribute    string
+	Timeout           time.Duration
+}
+
+// LDAP returns the settings of the LDAP directory, by default searching for (uid=%s) and taking the username from uid,
+// the name from cn and the email address from mail. The directory is disabled when no URL is set
+func LDAP() (Directory, bool) {
+	settings := Directory{
+		URL:               strings.TrimSpace(os.Getenv(LDAPURLVariable)),
+		BindDN:            os.Getenv(LDAPBindDNVariable),
+		BindPassword:      os.Getenv(LDAPBindPasswordVariable),
+		BaseDN:            os.Getenv(LDAPBaseDNVariable),
+		UserFilter:        os.Getenv(LDAPUserFilterVariable),
+		UsernameAttribute: os.Getenv(LDAPUsernameAttributeVariable),
+		NameAttribute:     os.Getenv(LDAPNameAttributeVariable),
+		EmailAttribute:    os.Getenv(LDAPEmailAttributeVariable),
+		Timeout:           10 * time.Second}
+	settings.Insecure, _ = strconv.ParseBool(strings.TrimSpace(os.Getenv(LDAPInsecureVariable)))
+
+	if !strings.Contains(settings.UserFilter, "%s") {
+		settings.UserFilter = "(uid=%s)"
+	}
+
+	if settings.UsernameAttribute == "" {
+		settings.UsernameAttribute = "uid"
+	}
+
+	if settings.NameAttribute == "" {
+		settings.NameAttribute = "cn"
+	}
+
+	if settings.EmailAttribute == "" {
+		settings.EmailAttribute = "mail"
+	}
+
+	return settings, settings.URL != ""
+}
@@ -2,17 +2,25 @@ package db
 
 import (
 	"context"
+	"errors"
 	"log"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"go.fmi/issuetracker/audit"
 	"go.fmi/issuetracker/comment"
 	"go.fmi/issuetracker/issue"
+	"go.fmi/issuetracker/notification"
 	"go.fmi/issuetracker/project"
+	"go.fmi/issuetracker/stats"
+	"go.fmi/issuetracker/token"
 	"go.fmi/issuetracker/user"
+	"go.fmi/issuetracker/watch"
+	"go.fmi/issuetracker/webhook"
 )
 
 // Client is used to make transactions in the database
@@ -25,6 +33,16 @@ const (
 	projectsCollection = "projects"
 	issuesCollection   = "issues"
 	commentsCollection = "comments"
+	auditCollection    = "audit"
+	countersCollection = "counters"
+	inboxCollection    = "inbox"
+	watchesCollection  = "watches"
+	emailCollection    = "email"
+	digestsCollection  = "digests"
+	webhooksCollection = "webhooks"
+	deliveryCollection = "deliveries"
+	tokensCollection   = "tokens"
+	resetsCollection   = "resets"
 )
 
 // Connect establishes a connection to the database
@@ -34,20 +52,84 @@ func Connect() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	ctx, _ := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 	err = clientLocal.Connect(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
 }
 
-// IsertRegisteredUser inserts a new user in the 'users' collection
-func InsertRegisteredUser(registeredUser user.User) {
-	collection := Client.Database(dbName).Collection(usersCollection)
-	_, err := collection.InsertOne(context.TODO(), registeredUser)
+// ErrDuplicate is returned when a unique index rejects an insert, e.g. when two clients register the same username at once
+var ErrDuplicate = errors.New("duplicate key")
+
+// duplicateKeyCode is the code of the error with which MongoDB rejects a write violating a unique index
+const duplicateKeyCode = 11000
+
+// usernameCollation compares the usernames regardless of their case
+var usernameCollation = &options.Collation{Locale: "en", Strength: 2}
+
+// EnsureIndexes creates the unique indexes of the users, the projects, the issues in a project and the applied
+// migrations, unless they exist. Usernames are unique regardless of their case
+func EnsureIndexes() {
+	indexes := []struct {
+		collection string
+		model      mongo.IndexModel
+	}{
+		{usersCollection, mongo.IndexModel{
+			Keys:    bson.D{{Key: "username", Value: 1}},
+			Options: options.Index().SetUnique(true).SetCollation(usernameCollation)}},
+		{projectsCollection, mongo.IndexModel{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true)}},
+		{issuesCollection, mongo.IndexModel{
+			Keys:    bson.D{{Key: "project", Value: 1}, {Key: "title", Value: 1}},
+			Options: options.Index().SetUnique(true)}},
+		{migrationsCollection, migrationsIndex},
+	}
+
+	for _, index := range indexes {
+		collection := Client.Database(dbName).Collection(index.collection)
+		if _, err := collection.Indexes().CreateOne(context.TODO(), index.model); err != nil {
+			// Duplicates which are already stored prevent the index, but not the server from starting
+			log.Println("Could not create the unique index of '" + index.collection + "': " + err.Error())
+		}
+	}
+}
+
+// checkUnique turns an error of an insert into ErrDuplicate when a unique index rejected it
+func checkUnique(err error) error {
+	if isDuplicate(err) {
+		return ErrDuplicate
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	return nil
+}
+
+// isDuplicate checks whether a write failed because it violates a unique index
+func isDuplicate(err error) bool {
+	var writeException mongo.WriteException
+	if errors.As(err, &writeException) {
+		for _, writeError := range writeException.WriteErrors {
+			if writeError.Code == duplicateKeyCode {
+				return true
+			}
+		}
+	}
+
+	var commandError mongo.CommandError
+	return errors.As(err, &commandError) && commandError.Code == duplicateKeyCode
+}
+
+// IsertRegisteredUser inserts a new user in the 'users' collection. It returns ErrDuplicate when the username is taken
+func InsertRegisteredUser(registeredUser user.User) error {
+	collection := Client.Database(dbName).Collection(usersCollection)
+	_, err := collection.InsertOne(context.TODO(), registeredUser)
+
+	return checkUnique(err)
 }
 
 // FindRegisteredUser checks whether a specific username is already in the 'users' collection
@@ -60,15 +142,83 @@ func FindRegisteredUser(username string) (user.User, error) {
 	return registeredUser, err
 }
 
-// InsertNewProject inserts a new project in the 'projects' collection
-func InsertNewProject(newProject project.Project) {
-	collection := Client.Database(dbName).Collection(projectsCollection)
-	_, err := collection.InsertOne(context.TODO(), newProject)
+// FindUserIgnoringCase searches the 'users' collection for a user whose username differs from the given one at most
+// in case, with the collation of the unique index on the usernames
+func FindUserIgnoringCase(username string) (user.User, error) {
+	collection := Client.Database(dbName).Collection(usersCollection)
+	filter := bson.M{"username": username}
+	var registeredUser user.User
+	err := collection.FindOne(context.TODO(), filter, options.FindOne().SetCollation(usernameCollation)).Decode(&registeredUser)
+
+	return registeredUser, err
+}
+
+// UpdatePassword replaces the hash of the password of a user in the 'users' collection
+func UpdatePassword(username string, hashedPassword string) {
+	collection := Client.Database(dbName).Collection(usersCollection)
+	filter := bson.M{"username": username}
+	update := bson.M{"$set": bson.M{"password": hashedPassword}}
+	_, err := collection.UpdateOne(context.TODO(), filter, update)
 	if err != nil {
 		log.Fatal(err)
 	}
 }
 
+// ListUsers lists all users in the 'users' collection in alphabetical order of their usernames
+func ListUsers() []user.User {
+	collection := Client.Database(dbName).Collection(usersCollection)
+	cursor, _ := collection.Find(
+		context.TODO(),
+		bson.M{},
+		options.Find().SetSort(bson.M{"username": 1}).SetProjection(bson.M{"password": 0}))
+
+	var users []user.User
+	cursor.All(context.TODO(), &users)
+
+	return users
+}
+
+// UpdateProfile replaces the display name, the email and the timezone of a user in the 'users' collection
+func UpdateProfile(username string, displayName string, email string, timezone string) {
+	collection := Client.Database(dbName).Collection(usersCollection)
+	filter := bson.M{"username": username}
+	update := bson.M{"$set": bson.M{"displayname": displayName, "email": email, "timezone": timezone}}
+	_, err := collection.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// SetDeactivated deactivates or reactivates a user in the 'users' collection
+func SetDeactivated(username string, deactivated bool) {
+	collection := Client.Database(dbName).Collection(usersCollection)
+	filter := bson.M{"username": username}
+	update := bson.M{"$set": bson.M{"deactivated": deactivated}}
+	_, err := collection.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// SetAdmin grants or revokes the administrator role of a user in the 'users' collection
+func SetAdmin(username string, admin bool) {
+	collection := Client.Database(dbName).Collection(usersCollection)
+	filter := bson.M{"username": username}
+	update := bson.M{"$set": bson.M{"admin": admin}}
+	_, err := collection.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// InsertNewProject inserts a new project in the 'projects' collection. It returns ErrDuplicate when the name is taken
+func InsertNewProject(newProject project.Project) error {
+	collection := Client.Database(dbName).Collection(projectsCollection)
+	_, err := collection.InsertOne(context.TODO(), newProject)
+
+	return checkUnique(err)
+}
+
 // FindExistingProject checks whether a specific project is already in the 'projects' collection
 func FindExistingProject(name string) (project.Project, error) {
 	collection := Client.Database(dbName).Collection(projectsCollection)
@@ -79,15 +229,46 @@ func FindExistingProject(name string) (project.Project, error) {
 	return existingProject, err
 }
 
-// InsertNewIssue inserts a new issue in the 'issues' collection
-func InsertNewIssue(newIssue issue.Issue) {
-	collection := Client.Database(dbName).Collection(issuesCollection)
-	_, err := collection.InsertOne(context.TODO(), newIssue)
+// ListProjects lists all projects in the 'projects' collection in alphabetical order
+func ListProjects() []project.Project {
+	collection := Client.Database(dbName).Collection(projectsCollection)
+	cursor, _ := collection.Find(
+		context.TODO(),
+		bson.M{},
+		options.Find().SetSort(bson.M{"name": 1}))
+
+	var projects []project.Project
+	cursor.All(context.TODO(), &projects)
+
+	return projects
+}
+
+// DeleteProject removes a project from the 'projects' collection together with its issues, their comments and watches,
+// and its webhooks with their deliveries. The notifications about the project are kept in the inboxes
+func DeleteProject(name string) {
+	for _, collectionName := range []string{issuesCollection, commentsCollection, watchesCollection, webhooksCollection, deliveryCollection} {
+		collection := Client.Database(dbName).Collection(collectionName)
+		_, err := collection.DeleteMany(context.TODO(), bson.M{"project": name})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	collection := Client.Database(dbName).Collection(projectsCollection)
+	_, err := collection.DeleteOne(context.TODO(), bson.M{"name": name})
 	if err != nil {
 		log.Fatal(err)
 	}
 }
 
+// InsertNewIssue inserts a new issue in the 'issues' collection. It returns ErrDuplicate when the title is taken in the project
+func InsertNewIssue(newIssue issue.Issue) error {
+	collection := Client.Database(dbName).Collection(issuesCollection)
+	_, err := collection.InsertOne(context.TODO(), newIssue)
+
+	return checkUnique(err)
+}
+
 // FindExistingIssue checks whether an issue with a title and a project is already in the 'issues' collection
 func FindExistingIssue(project string, title string) (issue.Issue, error) {
 	collection := Client.Database(dbName).Collection(issuesCollection)
@@ -98,15 +279,41 @@ func FindExistingIssue(project string, title string) (issue.Issue, error) {
 	return existingIssue, err
 }
 
-// ResolveIssue updates an entry in the 'issues' collection by changing the value of the 'resolved' attribute to 'true'
+// ResolveIssue updates an entry in the 'issues' collection by changing its status to resolved
 func ResolveIssue(project string, title string) {
 	collection := Client.Database(dbName).Collection(issuesCollection)
 	_, err := collection.UpdateOne(
 		context.TODO(),
 		bson.M{"project": project, "title": title},
-		bson.D{
-			{"$set", bson.D{{"resolved", "true"}}},
-		},
+		bson.M{"$set": bson.M{"status": issue.StatusResolved}},
+	)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// AssignIssue updates an entry in the 'issues' collection by changing its assignee
+func AssignIssue(project string, title string, assignee string) {
+	collection := Client.Database(dbName).Collection(issuesCollection)
+	_, err := collection.UpdateOne(
+		context.TODO(),
+		bson.M{"project": project, "title": title},
+		bson.M{"$set": bson.M{"assignee": assignee}},
+	)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// LabelIssue updates an entry in the 'issues' collection by replacing its labels
+func LabelIssue(project string, title string, labels string) {
+	collection := Client.Database(dbName).Collection(issuesCollection)
+	_, err := collection.UpdateOne(
+		context.TODO(),
+		bson.M{"project": project, "title": title},
+		bson.M{"$set": bson.M{"labels": labels}},
 	)
 
 	if err != nil {
@@ -127,8 +334,29 @@ func ListIssues(project string) []issue.Issue {
 	return issues
 }
 
-// InsertComment insert a new comment for an issue in the 'comments' collection
+// NextSequence atomically increments a named counter in the 'counters' collection and returns its new value
+func NextSequence(name string) int {
+	collection := Client.Database(dbName).Collection(countersCollection)
+	var counter struct {
+		Value int
+	}
+	err := collection.FindOneAndUpdate(
+		context.TODO(),
+		bson.M{"_id": name},
+		bson.M{"$inc": bson.M{"value": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return counter.Value
+}
+
+// InsertComment assigns the next comment ID to a new comment for an issue and inserts it in the 'comments' collection
 func InsertComment(comment comment.Comment) {
+	comment.ID = NextSequence(commentsCollection)
 	collection := Client.Database(dbName).Collection(commentsCollection)
 	_, err := collection.InsertOne(context.TODO(), comment)
 	if err != nil {
@@ -136,6 +364,45 @@ func InsertComment(comment comment.Comment) {
 	}
 }
 
+// FindComment finds a comment by its ID in the 'comments' collection
+func FindComment(id int) (comment.Comment, error) {
+	collection := Client.Database(dbName).Collection(commentsCollection)
+	filter := bson.M{"id": id}
+	var existingComment comment.Comment
+	err := collection.FindOne(context.TODO(), filter).Decode(&existingComment)
+
+	return existingComment, err
+}
+
+// EditComment updates the content of a comment in the 'comments' collection and marks when it was edited
+func EditComment(id int, content string, edited time.Time) {
+	collection := Client.Database(dbName).Collection(commentsCollection)
+	_, err := collection.UpdateOne(
+		context.TODO(),
+		bson.M{"id": id},
+		bson.M{"$set": bson.M{"content": content, "edited": edited}},
+	)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// DeleteComment marks a comment in the 'comments' collection as deleted and erases its content.
+// The comment itself is kept, so that the replies to it remain in the thread
+func DeleteComment(id int) {
+	collection := Client.Database(dbName).Collection(commentsCollection)
+	_, err := collection.UpdateOne(
+		context.TODO(),
+		bson.M{"id": id},
+		bson.M{"$set": bson.M{"content": "", "deleted": true}},
+	)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
 // FindComments lists all comments for an issue
 func FindComments(project string, title string) []comment.Comment {
 	collection := Client.Database(dbName).Collection(commentsCollection)
@@ -148,3 +415,424 @@ func FindComments(project string, title string) []comment.Comment {
 
 	return comments
 }
+
+// InsertAuditEntry appends an entry to the 'audit' collection
+func InsertAuditEntry(entry audit.Entry) {
+	collection := Client.Database(dbName).Collection(auditCollection)
+	_, err := collection.InsertOne(context.TODO(), entry)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// FindAuditEntries lists the entries in the 'audit' collection which match a filter, oldest first
+func FindAuditEntries(filter audit.Filter) []audit.Entry {
+	collection := Client.Database(dbName).Collection(auditCollection)
+	query := bson.M{}
+	if filter.Actor != "" {
+		query["actor"] = filter.Actor
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+
+	timeRange := bson.M{}
+	if !filter.From.IsZero() {
+		timeRange["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		timeRange["$lt"] = filter.To
+	}
+	if len(timeRange) > 0 {
+		query["time"] = timeRange
+	}
+
+	cursor, _ := collection.Find(
+		context.TODO(),
+		query,
+		options.Find().SetSort(bson.M{"time": 1}))
+
+	var entries []audit.Entry
+	cursor.All(context.TODO(), &entries)
+
+	return entries
+}
+
+// InsertNotification assigns the next notification ID to a notification and delivers it in the 'inbox' collection
+func InsertNotification(newNotification notification.Notification) {
+	newNotification.ID = NextSequence(inboxCollection)
+	collection := Client.Database(dbName).Collection(inboxCollection)
+	_, err := collection.InsertOne(context.TODO(), newNotification)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// FindNotifications lists the notifications in the inbox of a user, newest first
+func FindNotifications(recipient string, unreadOnly bool) []notification.Notification {
+	collection := Client.Database(dbName).Collection(inboxCollection)
+	filter := bson.M{"recipient": recipient}
+	if unreadOnly {
+		filter["read"] = false
+	}
+
+	cursor, _ := collection.Find(
+		context.TODO(),
+		filter,
+		options.Find().SetSort(bson.M{"id": -1}))
+
+	var notifications []notification.Notification
+	cursor.All(context.TODO(), &notifications)
+
+	return notifications
+}
+
+// MarkNotificationsRead marks a notification in the inbox of a user as read. An ID of 0 marks the whole inbox as read.
+// It returns the number of notifications which were unread
+func MarkNotificationsRead(recipient string, id int) int {
+	collection := Client.Database(dbName).Collection(inboxCollection)
+	filter := bson.M{"recipient": recipient, "read": false}
+	if id != 0 {
+		filter["id"] = id
+	}
+
+	result, err := collection.UpdateMany(
+		context.TODO(),
+		filter,
+		bson.M{"$set": bson.M{"read": true}},
+	)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return int(result.ModifiedCount)
+}
+
+// InsertWatch adds a watch to the 'watches' collection, unless the user is already watching
+func InsertWatch(newWatch watch.Watch) {
+	collection := Client.Database(dbName).Collection(watchesCollection)
+	_, err := collection.UpdateOne(
+		context.TODO(),
+		newWatch,
+		bson.M{"$set": newWatch},
+		options.Update().SetUpsert(true),
+	)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// DeleteWatch removes a watch from the 'watches' collection and reports whether it existed
+func DeleteWatch(existingWatch watch.Watch) bool {
+	collection := Client.Database(dbName).Collection(watchesCollection)
+	result, err := collection.DeleteOne(context.TODO(), existingWatch)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return result.DeletedCount > 0
+}
+
+// FindWatchers lists the distinct users watching an issue, either directly or through its project
+func FindWatchers(project string, title string) []string {
+	collection := Client.Database(dbName).Collection(watchesCollection)
+	watchers, _ := collection.Distinct(
+		context.TODO(),
+		"username",
+		bson.M{"project": project, "title": bson.M{"$in": bson.A{"", title}}})
+
+	var usernames []string
+	for _, watcher := range watchers {
+		if username, ok := watcher.(string); ok {
+			usernames = append(usernames, username)
+		}
+	}
+
+	return usernames
+}
+
+// SavePreferences stores the email preferences of a user in the 'email' collection, replacing the previous ones
+func SavePreferences(preferences notification.Preferences) {
+	collection := Client.Database(dbName).Collection(emailCollection)
+	_, err := collection.ReplaceOne(
+		context.TODO(),
+		bson.M{"username": preferences.Username},
+		preferences,
+		options.Replace().SetUpsert(true),
+	)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// FindPreferences finds the email preferences of a user in the 'email' collection
+func FindPreferences(username string) (notification.Preferences, error) {
+	collection := Client.Database(dbName).Collection(emailCollection)
+	filter := bson.M{"username": username}
+	var preferences notification.Preferences
+	err := collection.FindOne(context.TODO(), filter).Decode(&preferences)
+
+	return preferences, err
+}
+
+// InsertDigestNotification queues a notification in the 'digests' collection until the next digest of its recipient is sent
+func InsertDigestNotification(n notification.Notification) {
+	collection := Client.Database(dbName).Collection(digestsCollection)
+	_, err := collection.InsertOne(context.TODO(), n)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// FindDigestRecipients lists the users who have notifications queued in the 'digests' collection
+func FindDigestRecipients() []string {
+	collection := Client.Database(dbName).Collection(digestsCollection)
+	recipients, _ := collection.Distinct(context.TODO(), "recipient", bson.M{})
+
+	var usernames []string
+	for _, recipient := range recipients {
+		if username, ok := recipient.(string); ok {
+			usernames = append(usernames, username)
+		}
+	}
+
+	return usernames
+}
+
+// TakeDigestNotifications removes the notifications queued for a user from the 'digests' collection and returns them, oldest first
+func TakeDigestNotifications(recipient string) []notification.Notification {
+	collection := Client.Database(dbName).Collection(digestsCollection)
+	cursor, _ := collection.Find(
+		context.TODO(),
+		bson.M{"recipient": recipient},
+		options.Find().SetSort(bson.M{"created": 1}))
+
+	var queued []struct {
+		ObjectID     primitive.ObjectID        `bson:"_id"`
+		Notification notification.Notification `bson:",inline"`
+	}
+	cursor.All(context.TODO(), &queued)
+	if len(queued) == 0 {
+		return nil
+	}
+
+	var notifications []notification.Notification
+	var objectIDs bson.A
+	for _, entry := range queued {
+		notifications = append(notifications, entry.Notification)
+		objectIDs = append(objectIDs, entry.ObjectID)
+	}
+
+	_, err := collection.DeleteMany(context.TODO(), bson.M{"_id": bson.M{"$in": objectIDs}})
+	if err != nil {
+		log.Println(err)
+	}
+
+	return notifications
+}
+
+// InsertWebhook assigns the next webhook ID to a new webhook and inserts it in the 'webhooks' collection
+func InsertWebhook(newWebhook webhook.Webhook) int {
+	newWebhook.ID = NextSequence(webhooksCollection)
+	collection := Client.Database(dbName).Collection(webhooksCollection)
+	_, err := collection.InsertOne(context.TODO(), newWebhook)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return newWebhook.ID
+}
+
+// FindWebhook finds a webhook by its ID in the 'webhooks' collection
+func FindWebhook(id int) (webhook.Webhook, error) {
+	collection := Client.Database(dbName).Collection(webhooksCollection)
+	filter := bson.M{"id": id}
+	var existingWebhook webhook.Webhook
+	err := collection.FindOne(context.TODO(), filter).Decode(&existingWebhook)
+
+	return existingWebhook, err
+}
+
+// FindWebhooks lists the webhooks registered for a project
+func FindWebhooks(project string) []webhook.Webhook {
+	collection := Client.Database(dbName).Collection(webhooksCollection)
+	cursor, _ := collection.Find(
+		context.TODO(),
+		bson.M{"project": project})
+
+	var webhooks []webhook.Webhook
+	cursor.All(context.TODO(), &webhooks)
+
+	return webhooks
+}
+
+// DeleteWebhook removes a webhook from the 'webhooks' collection
+func DeleteWebhook(id int) {
+	collection := Client.Database(dbName).Collection(webhooksCollection)
+	_, err := collection.DeleteOne(context.TODO(), bson.M{"id": id})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// InsertDelivery appends an attempt to deliver a webhook payload to the 'deliveries' collection
+func InsertDelivery(delivery webhook.Delivery) {
+	collection := Client.Database(dbName).Collection(deliveryCollection)
+	_, err := collection.InsertOne(context.TODO(), delivery)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// FindDeliveries lists the latest attempts to deliver webhook payloads for a project, newest first
+func FindDeliveries(project string, limit int) []webhook.Delivery {
+	collection := Client.Database(dbName).Collection(deliveryCollection)
+	cursor, _ := collection.Find(
+		context.TODO(),
+		bson.M{"project": project},
+		options.Find().SetSort(bson.M{"time": -1}).SetLimit(int64(limit)))
+
+	var deliveries []webhook.Delivery
+	cursor.All(context.TODO(), &deliveries)
+
+	return deliveries
+}
+
+// InsertToken inserts a new token in the 'tokens' collection
+func InsertToken(newToken token.Token) {
+	collection := Client.Database(dbName).Collection(tokensCollection)
+	_, err := collection.InsertOne(context.TODO(), newToken)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// FindToken finds a token by the hash of its secret in the 'tokens' collection
+func FindToken(hash string) (token.Token, error) {
+	collection := Client.Database(dbName).Collection(tokensCollection)
+	filter := bson.M{"hash": hash}
+	var existingToken token.Token
+	err := collection.FindOne(context.TODO(), filter).Decode(&existingToken)
+
+	return existingToken, err
+}
+
+// DeleteToken removes a token from the 'tokens' collection, so that it can no longer be used
+func DeleteToken(hash string) {
+	collection := Client.Database(dbName).Collection(tokensCollection)
+	_, err := collection.DeleteOne(context.TODO(), bson.M{"hash": hash})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// DeleteUserTokens removes the tokens of a user from the 'tokens' collection, except the one with a given hash,
+// which ends all their other sessions and revokes their personal access tokens
+func DeleteUserTokens(username string, exceptHash string) {
+	collection := Client.Database(dbName).Collection(tokensCollection)
+	filter := bson.M{"username": username, "hash": bson.M{"$ne": exceptHash}}
+	_, err := collection.DeleteMany(context.TODO(), filter)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// InsertAccessToken inserts a new personal access token in the 'tokens' collection and returns its ID
+func InsertAccessToken(newToken token.Token) int {
+	newToken.ID = NextSequence(tokensCollection)
+	collection := Client.Database(dbName).Collection(tokensCollection)
+	_, err := collection.InsertOne(context.TODO(), newToken)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return newToken.ID
+}
+
+// FindAccessToken finds a personal access token by its ID in the 'tokens' collection
+func FindAccessToken(id int) (token.Token, error) {
+	collection := Client.Database(dbName).Collection(tokensCollection)
+	filter := bson.M{"id": id, "scopes.0": bson.M{"$exists": true}}
+	var existingToken token.Token
+	err := collection.FindOne(context.TODO(), filter).Decode(&existingToken)
+
+	return existingToken, err
+}
+
+// FindAccessTokens lists the personal access tokens of a user, or of all users when the username is empty, in the order
+// in which they were created
+func FindAccessTokens(username string) []token.Token {
+	filter := bson.M{"scopes.0": bson.M{"$exists": true}}
+	if username != "" {
+		filter["username"] = username
+	}
+
+	collection := Client.Database(dbName).Collection(tokensCollection)
+	cursor, _ := collection.Find(
+		context.TODO(),
+		filter,
+		options.Find().SetSort(bson.M{"id": 1}))
+
+	var tokens []token.Token
+	cursor.All(context.TODO(), &tokens)
+
+	return tokens
+}
+
+// DeleteAccessToken removes a personal access token from the 'tokens' collection, so that it can no longer be used
+func DeleteAccessToken(id int) {
+	collection := Client.Database(dbName).Collection(tokensCollection)
+	_, err := collection.DeleteOne(context.TODO(), bson.M{"id": id, "scopes.0": bson.M{"$exists": true}})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// InsertResetToken inserts a new password reset token in the 'resets' collection, replacing the earlier ones of the user
+func InsertResetToken(newToken token.Token) {
+	collection := Client.Database(dbName).Collection(resetsCollection)
+	_, err := collection.DeleteMany(context.TODO(), bson.M{"username": newToken.Username})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = collection.InsertOne(context.TODO(), newToken)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// TakeResetToken finds a password reset token by the hash of its secret and removes it from the 'resets' collection,
+// so that it can be used only once
+func TakeResetToken(hash string) (token.Token, error) {
+	collection := Client.Database(dbName).Collection(resetsCollection)
+	filter := bson.M{"hash": hash}
+	var existingToken token.Token
+	err := collection.FindOneAndDelete(context.TODO(), filter).Decode(&existingToken)
+
+	return existingToken, err
+}
+
+// CountDocuments counts the users, projects, issues, comments and unexpired session tokens
+func CountDocuments() stats.Stats {
+	count := func(collectionName string, filter bson.M) int64 {
+		collection := Client.Database(dbName).Collection(collectionName)
+		n, err := collection.CountDocuments(context.TODO(), filter)
+		if err != nil {
+			log.Println(err)
+		}
+		return n
+	}
+
+	return stats.Stats{
+		Users:            count(usersCollection, bson.M{}),
+		DeactivatedUsers: count(usersCollection, bson.M{"deactivated": true}),
+		Projects:         count(projectsCollection, bson.M{}),
+		OpenIssues:       count(issuesCollection, bson.M{"status": issue.StatusOpen}),
+		ResolvedIssues:   count(issuesCollection, bson.M{"status": issue.StatusResolved}),
+		Comments:         count(commentsCollection, bson.M{"deleted": bson.M{"$ne": true}}),
+		Sessions:         count(tokensCollection, bson.M{"expires": bson.M{"$gt": time.Now()}})}
+}
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go.fmi/issuetracker/issue"
+)
+
+const migrationsCollection = "migrations"
+
+// collections are the collections of the database, which are created at startup unless they exist
+var collections = []string{
+	usersCollection, projectsCollection, issuesCollection, commentsCollection, auditCollection, countersCollection,
+	inboxCollection, watchesCollection, emailCollection, digestsCollection, webhooksCollection, deliveryCollection,
+	tokensCollection, resetsCollection, migrationsCollection,
+}
+
+// migrationsIndex keeps a migration from being recorded twice when several servers start at once
+var migrationsIndex = mongo.IndexModel{
+	Keys:    bson.D{{Key: "version", Value: 1}},
+	Options: options.Index().SetUnique(true)}
+
+// Migration is a versioned change of the stored data, which is applied once and recorded in the 'migrations' collection
+type Migration struct {
+	Version     int
+	Description string
+	apply       func() error
+}
+
+// AppliedMigration is the record of an applied migration in the 'migrations' collection
+type AppliedMigration struct {
+	Version     int
+	Description string
+	Applied     time.Time
+}
+
+// migrations are all the migrations in the order of their versions. A migration is never changed once released,
+// a new one is appended instead
+var migrations = []Migration{
+	{1, "replace the resolved flag of the issues with a status", migrateIssueStatus},
+}
+
+// EnsureCollections creates the collections which don't exist yet
+func EnsureCollections() error {
+	database := Client.Database(dbName)
+	existing, err := database.ListCollectionNames(context.TODO(), bson.M{})
+	if err != nil {
+		return err
+	}
+
+	found := make(map[string]bool)
+	for _, name := range existing {
+		found[name] = true
+	}
+
+	for _, name := range collections {
+		if found[name] {
+			continue
+		}
+		if err := database.CreateCollection(context.TODO(), name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AppliedMigrations returns the records of the applied migrations by their versions
+func AppliedMigrations() (map[int]AppliedMigration, error) {
+	collection := Client.Database(dbName).Collection(migrationsCollection)
+	cursor, err := collection.Find(context.TODO(), bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	var records []AppliedMigration
+	if err := cursor.All(context.TODO(), &records); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]AppliedMigration)
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+
+	return applied, nil
+}
+
+// Migrations returns all the migrations in the order of their versions
+func Migrations() []Migration {
+	return append([]Migration(nil), migrations...)
+}
+
+// Migrate applies the pending migrations in the order of their versions and returns the applied ones.
+// It stops at the first migration which fails, so that the later ones don't run on data they don't expect
+func Migrate() ([]Migration, error) {
+	applied, err := AppliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	collection := Client.Database(dbName).Collection(migrationsCollection)
+	for _, m := range Migrations() {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		if err := m.apply(); err != nil {
+			return done, err
+		}
+
+		record := AppliedMigration{Version: m.Version, Description: m.Description, Applied: time.Now().UTC()}
+		if _, err := collection.InsertOne(context.TODO(), record); err != nil && !isDuplicate(err) {
+			return done, err
+		}
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+// migrateIssueStatus replaces the 'resolved' attribute of the issues, which was 'true' or 'false', with their status
+func migrateIssueStatus() error {
+	collection := Client.Database(dbName).Collection(issuesCollection)
+	updates := []struct {
+		filter bson.M
+		update bson.M
+	}{
+		{bson.M{"resolved": "true"},
+			bson.M{"$set": bson.M{"status": issue.StatusResolved}, "$unset": bson.M{"resolved": ""}}},
+		{bson.M{"resolved": bson.M{"$exists": true}},
+			bson.M{"$set": bson.M{"status": issue.StatusOpen}, "$unset": bson.M{"resolved": ""}}},
+		{bson.M{"status": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"status": issue.StatusOpen}}},
+	}
+
+	for _, u := range updates {
+		if _, err := collection.UpdateMany(context.TODO(), u.filter, u.update); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,10 +1,73 @@
 package issue
 
+import (
+	"sort"
+	"strings"
+)
+
+// Status is the stage of an issue in its lifecycle
+type Status string
+
+// Statuses of an issue
+const (
+	StatusOpen     Status = "open"
+	StatusResolved Status = "resolved"
+)
+
 // Issue is an abstraction of a real-life issue
 type Issue struct {
-	Project     string
-	Reporter    string
-	Title       string
-	Description string
-	Resolved    string
+	Project     string `json:"project"`
+	Reporter    string `json:"reporter"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Status      Status `json:"status"`
+	Assignee    string `json:"assignee,omitempty"`
+	// Labels are the sorted labels of the issue, separated by commas
+	Labels string `json:"labels,omitempty"`
+}
+
+// IsResolved checks whether an issue is resolved
+func (i Issue) IsResolved() bool {
+	return i.Status == StatusResolved
+}
+
+// NormalizeLabels turns a comma separated list of labels into the form kept in an issue - trimmed, without
+// duplicates and sorted
+func NormalizeLabels(labels string) string {
+	seen := make(map[string]bool)
+	var normalized []string
+	for _, label := range strings.Split(labels, ",") {
+		label = strings.TrimSpace(label)
+		if label != "" && !seen[label] {
+			seen[label] = true
+			normalized = append(normalized, label)
+		}
+	}
+
+	sort.Strings(normalized)
+	return strings.Join(normalized, ",")
+}
+
+// Filter narrows down a list of issues
+type Filter struct {
+	Status   string
+	Assignee string
+	Text     string
+}
+
+// Matches checks whether an issue passes the filter. Empty criteria match every issue
+func (f Filter) Matches(i Issue) bool {
+	if f.Status != "" && Status(f.Status) != i.Status {
+		return false
+	}
+
+	if f.Assignee != "" && i.Assignee != f.Assignee {
+		return false
+	}
+
+	text := strings.ToLower(f.Text)
+	return text == "" ||
+		strings.Contains(strings.ToLower(i.Title), text) ||
+		strings.Contains(strings.ToLower(i.Description), text) ||
+		strings.Contains(strings.ToLower(i.Labels), text)
 }
@@ -0,0 +1,38 @@
+package issue
+
+import "testing"
+
+func TestNormalizeLabels(t *testing.T) {
+	if labels := NormalizeLabels(" ui, bug,,ui ,backend "); labels != "backend,bug,ui" {
+		t.Errorf("Invalid normalized labels. Expected: backend,bug,ui, but got " + labels)
+	}
+
+	if labels := NormalizeLabels(" , "); labels != "" {
+		t.Errorf("Invalid normalized labels. Expected no labels, but got " + labels)
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	open := Issue{Title: "Crash on start", Description: "The app crashes", Status: StatusOpen, Assignee: "user", Labels: "bug,ui"}
+	resolved := Issue{Title: "Typo", Description: "In the README", Status: StatusResolved}
+
+	cases := []struct {
+		filter   Filter
+		open     bool
+		resolved bool
+	}{
+		{Filter{}, true, true},
+		{Filter{Status: "open"}, true, false},
+		{Filter{Status: "resolved"}, false, true},
+		{Filter{Assignee: "user"}, true, false},
+		{Filter{Text: "readme"}, false, true},
+		{Filter{Text: "CRASH", Status: "resolved"}, false, false},
+		{Filter{Text: "ui"}, true, false},
+	}
+
+	for _, c := range cases {
+		if c.filter.Matches(open) != c.open || c.filter.Matches(resolved) != c.resolved {
+			t.Errorf("Invalid matches for filter %+v", c.filter)
+		}
+	}
+}
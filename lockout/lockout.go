@@ -0,0 +1,144 @@
+package lockout
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backoff is how long a key waits after its first failed attempt. The wait doubles with every next failure up to MaxBackoff
+const (
+	Backoff    = time.Second
+	MaxBackoff = 30 * time.Second
+)
+
+// record holds the failed attempts of a key since its last success
+type record struct {
+	failures    int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+var (
+	lock    sync.Mutex
+	records = make(map[string]*record)
+	pruned  time.Time
+	// now is replaced in the tests to move the time forward
+	now = time.Now
+)
+
+// AccountKey is the key under which the failed logins to an account are counted. The usernames are unique regardless
+// of their case, so all of its spellings share the key
+func AccountKey(username string) string {
+	return "account:" + strings.ToLower(username)
+}
+
+// AddressKey is the key under which the failed logins from a remote address are counted, regardless of its port
+func AddressKey(remoteAddr string) string {
+	return "address:" + Host(remoteAddr)
+}
+
+// Host returns a remote address without its port
+func Host(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+
+	return remoteAddr
+}
+
+// Wait returns how long a key has to wait before its next attempt, zero if it can try now
+func Wait(key string) time.Duration {
+	lock.Lock()
+	defer lock.Unlock()
+
+	r := current(key)
+	if r == nil {
+		return 0
+	}
+
+	until := r.lockedUntil
+	if backoffUntil := r.last.Add(backoff(r.failures)); backoffUntil.After(until) {
+		until = backoffUntil
+	}
+
+	if wait := until.Sub(now()); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// Fail records a failed attempt of a key and reports whether it has just locked the key out for a duration,
+// which happens after threshold failures
+func Fail(key string, threshold int, duration time.Duration) bool {
+	lock.Lock()
+	defer lock.Unlock()
+
+	prune(duration)
+
+	r := current(key)
+	if r == nil {
+		r = &record{}
+		records[key] = r
+	}
+
+	r.failures++
+	r.last = now()
+	if r.failures < threshold || r.lockedUntil.After(r.last) {
+		return false
+	}
+
+	r.lockedUntil = r.last.Add(duration)
+	return true
+}
+
+// Reset forgets the failed attempts of a key, e.g. after a successful login or when an administrator unlocks it.
+// It reports whether the key had any
+func Reset(key string) bool {
+	lock.Lock()
+	defer lock.Unlock()
+
+	_, ok := records[key]
+	delete(records, key)
+	return ok
+}
+
+// current returns the record of a key, forgetting it once its lockout has expired, so that the key starts afresh
+func current(key string) *record {
+	r := records[key]
+	if r != nil && !r.lockedUntil.IsZero() && !r.lockedUntil.After(now()) {
+		delete(records, key)
+		return nil
+	}
+
+	return r
+}
+
+// backoff returns how long a key waits after a number of failures
+func backoff(failures int) time.Duration {
+	wait := Backoff
+	for i := 1; i < failures && wait < MaxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > MaxBackoff {
+		return MaxBackoff
+	}
+	return wait
+}
+
+// prune forgets the records which haven't failed for a duration and aren't locked out, at most once per duration,
+// so that the records of addresses which tried once don't pile up
+func prune(duration time.Duration) {
+	if now().Sub(pruned) < duration {
+		return
+	}
+
+	for key, r := range records {
+		if now().Sub(r.last) >= duration && !r.lockedUntil.After(now()) {
+			delete(records, key)
+		}
+	}
+	pruned = now()
+}
@@ -0,0 +1,116 @@
+package lockout
+
+import (
+	"testing"
+	"time"
+)
+
+// freeze stops the clock of the package at a moment and returns the function moving it forward
+func freeze(t *testing.T) func(time.Duration) {
+	moment := time.Date(2021, 1, 5, 10, 30, 0, 0, time.UTC)
+	now = func() time.Time { return moment }
+	t.Cleanup(func() { now = time.Now })
+
+	return func(d time.Duration) {
+		moment = moment.Add(d)
+	}
+}
+
+func TestBackoffDoubles(t *testing.T) {
+	advance := freeze(t)
+	key := AccountKey("backoff")
+	defer Reset(key)
+
+	if Wait(key) != 0 {
+		t.Errorf("A key without failures should not wait")
+	}
+
+	Fail(key, 10, time.Minute)
+	if wait := Wait(key); wait != time.Second {
+		t.Errorf("Expected a wait of 1s after the first failure, but got %v", wait)
+	}
+
+	advance(time.Second)
+	Fail(key, 10, time.Minute)
+	if wait := Wait(key); wait != 2*time.Second {
+		t.Errorf("Expected a wait of 2s after the second failure, but got %v", wait)
+	}
+
+	advance(2 * time.Second)
+	if Wait(key) != 0 {
+		t.Errorf("The key should be able to try again after its wait")
+	}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	freeze(t)
+	key := AccountKey("capped")
+	defer Reset(key)
+
+	for i := 0; i < 9; i++ {
+		Fail(key, 100, time.Hour)
+	}
+
+	if wait := Wait(key); wait != MaxBackoff {
+		t.Errorf("Expected a wait of %v, but got %v", MaxBackoff, wait)
+	}
+}
+
+func TestLockout(t *testing.T) {
+	advance := freeze(t)
+	key := AccountKey("locked")
+	defer Reset(key)
+
+	for i := 1; i < 3; i++ {
+		if Fail(key, 3, time.Minute) {
+			t.Errorf("The key was locked out after %d failures", i)
+		}
+	}
+
+	if !Fail(key, 3, time.Minute) {
+		t.Errorf("The key was not locked out after reaching the threshold")
+	}
+	if wait := Wait(key); wait != time.Minute {
+		t.Errorf("Expected a wait of 1m, but got %v", wait)
+	}
+	if Fail(key, 3, time.Minute) {
+		t.Errorf("A failure of a locked out key should not lock it out again")
+	}
+
+	advance(time.Minute)
+	if Wait(key) != 0 {
+		t.Errorf("The lockout should have expired")
+	}
+	if Fail(key, 3, time.Minute) {
+		t.Errorf("The failures should be counted afresh after the lockout")
+	}
+}
+
+func TestReset(t *testing.T) {
+	freeze(t)
+	key := AccountKey("reset")
+
+	Fail(key, 1, time.Minute)
+	if !Reset(key) || Wait(key) != 0 {
+		t.Errorf("The key was not unlocked")
+	}
+	if Reset(key) {
+		t.Errorf("A key without failures should not be reported as reset")
+	}
+}
+
+func TestAccountKeyIgnoresCase(t *testing.T) {
+	if AccountKey("Alice") != AccountKey("alice") || AccountKey("ALICE") != AccountKey("alice") {
+		t.Errorf("The spellings of a username should share a key")
+	}
+}
+
+func TestAddressKeyIgnoresPort(t *testing.T) {
+	if AddressKey("10.0.0.1:5555") != AddressKey("10.0.0.1:6666") {
+		t.Errorf("Connections from the same address should share a key")
+	}
+
+	if AddressKey("[::1]:5555") != "address:::1" {
+		t.Errorf("Invalid key for an IPv6 address: " + AddressKey("[::1]:5555"))
+	}
+}
@@ -0,0 +1,107 @@
+package notification
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of notifications
+const (
+	Mention       = "mention"
+	Assignment    = "assignment"
+	IssueCreated  = "issue-created"
+	IssueResolved = "issue-resolved"
+	IssueUpdated  = "issue-updated"
+	CommentAdded  = "comment-added"
+)
+
+// Notification is an abstraction for a message delivered to the inbox of a user
+type Notification struct {
+	ID        int
+	Recipient string
+	Actor     string
+	Kind      string
+	Project   string
+	Title     string
+	Message   string
+	Created   time.Time
+	Read      bool
+}
+
+// mentionPattern matches '@username', unless the '@' is a part of a word, as in an email address
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@(\w[\w.-]*)`)
+
+// New creates an unread notification for something which happened just now
+func New(recipient string, actor string, kind string, project string, title string, message string) Notification {
+	return Notification{
+		Recipient: recipient,
+		Actor:     actor,
+		Kind:      kind,
+		Project:   project,
+		Title:     title,
+		Message:   message,
+		Created:   time.Now().UTC()}
+}
+
+// Mentions returns the distinct usernames mentioned with '@' in a text, in order of appearance
+func Mentions(text string) []string {
+	var mentions []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		// Punctuation right after a mention is not a part of the username
+		username := strings.TrimRight(match[1], ".-")
+		if !seen[username] {
+			seen[username] = true
+			mentions = append(mentions, username)
+		}
+	}
+
+	return mentions
+}
+
+// String formats a notification as a single human-readable line
+func (n Notification) String() string {
+	line := "#" + strconv.Itoa(n.ID) + " "
+	if !n.Read {
+		line += "[unread] "
+	}
+
+	return line + n.Created.Format("2006-01-02 15:04") + " " + n.Message
+}
+
+// Email delivery modes
+const (
+	EmailOff     = "off"
+	EmailInstant = "instant"
+	EmailDigest  = "digest"
+)
+
+// Preferences are the settings of a user for receiving notifications by email
+type Preferences struct {
+	Username      string
+	Email         string
+	Mode          string
+	Mentions      bool
+	Assignments   bool
+	StatusChanges bool
+}
+
+// WantsEmail checks whether a notification should be sent by email according to the preferences
+func (p Preferences) WantsEmail(n Notification) bool {
+	if p.Email == "" || p.Mode == "" || p.Mode == EmailOff {
+		return false
+	}
+
+	switch n.Kind {
+	case Mention:
+		return p.Mentions
+	case Assignment:
+		return p.Assignments
+	case IssueResolved:
+		return p.StatusChanges
+	default:
+		return false
+	}
+}
@@ -0,0 +1,47 @@
+package notification
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMentions(t *testing.T) {
+	text := "@alice, could you and @bob.smith take a look? Thanks @alice. Mail me at carol@example.com"
+	expected := []string{"alice", "bob.smith"}
+
+	got := Mentions(text)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Mentions were not extracted properly. Expected: %v, but got: %v", expected, got)
+	}
+}
+
+func TestMentionsNone(t *testing.T) {
+	if got := Mentions("no mentions @ all"); len(got) != 0 {
+		t.Errorf("Expected no mentions, but got: %v", got)
+	}
+}
+
+func TestPreferencesWantsEmail(t *testing.T) {
+	preferences := Preferences{
+		Email:         "user@example.com",
+		Mode:          EmailInstant,
+		Mentions:      true,
+		StatusChanges: true}
+
+	if !preferences.WantsEmail(Notification{Kind: Mention}) {
+		t.Errorf("Mentions should be sent by email")
+	}
+
+	if preferences.WantsEmail(Notification{Kind: Assignment}) {
+		t.Errorf("Assignments should not be sent by email")
+	}
+
+	if preferences.WantsEmail(Notification{Kind: CommentAdded}) {
+		t.Errorf("New comments are never sent by email")
+	}
+
+	preferences.Mode = EmailOff
+	if preferences.WantsEmail(Notification{Kind: Mention}) {
+		t.Errorf("Nothing should be sent by email when it is turned off")
+	}
+}
@@ -0,0 +1,73 @@
+package notifier
+
+import (
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.fmi/issuetracker/notification"
+)
+
+// Notifier delivers notifications to users who may not be connected to the server
+type Notifier interface {
+	Notify(address string, notifications []notification.Notification) error
+}
+
+// Default is the notifier used by the server. Notifications are not delivered outside of the tracker when it is nil
+var Default Notifier
+
+// SMTPNotifier sends notifications by email through an SMTP server
+type SMTPNotifier struct {
+	Address  string
+	From     string
+	Username string
+	Password string
+}
+
+// Notify sends a single email to an address, containing one or more notifications
+func (sn SMTPNotifier) Notify(address string, notifications []notification.Notification) error {
+	var auth smtp.Auth
+	if sn.Username != "" {
+		host, _, err := net.SplitHostPort(sn.Address)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", sn.Username, sn.Password, host)
+	}
+
+	return smtp.SendMail(sn.Address, auth, sn.From, []string{address}, Message(sn.From, address, notifications))
+}
+
+// headerBreaks replaces the line breaks which would end a header
+var headerBreaks = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// Message composes an email with notifications. A single notification is used as a subject
+// and more of them are sent together as a digest
+func Message(from string, to string, notifications []notification.Notification) []byte {
+	subject := "[Issue Tracker] "
+	if len(notifications) == 1 {
+		subject += notifications[0].Message
+	} else {
+		subject += "Digest of " + strconv.Itoa(len(notifications)) + " notifications"
+	}
+
+	// The subject contains names of projects and titles of issues, which must not start new headers
+	subject = mime.QEncoding.Encode("utf-8", headerBreaks.Replace(subject))
+
+	var body strings.Builder
+	for _, n := range notifications {
+		body.WriteString(n.Created.Format("2006-01-02 15:04") + " " + n.Message + "\r\n")
+	}
+
+	headers := "From: " + from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n"
+
+	return []byte(headers + "\r\n" + body.String())
+}
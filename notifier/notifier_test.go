@@ -0,0 +1,135 @@
+package notifier
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"go.fmi/issuetracker/notification"
+)
+
+// fakeSMTPServer accepts a single email on a local port and hands its recipients and data over a channel
+func fakeSMTPServer(t *testing.T) (string, <-chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan []string, 1)
+	go func() {
+		defer listener.Close()
+		con, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer con.Close()
+
+		reader := bufio.NewReader(con)
+		reply := func(line string) {
+			con.Write([]byte(line + "\r\n"))
+		}
+
+		var mail []string
+		reply("220 localhost fake SMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+			switch verb {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "MAIL", "RCPT":
+				mail = append(mail, line)
+				reply("250 OK")
+			case "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					dataLine = strings.TrimRight(dataLine, "\r\n")
+					if dataLine == "." {
+						break
+					}
+					mail = append(mail, dataLine)
+				}
+				reply("250 OK")
+			case "QUIT":
+				reply("221 Bye")
+				received <- mail
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func TestSMTPNotifierSendsEmail(t *testing.T) {
+	address, received := fakeSMTPServer(t)
+	smtpNotifier := SMTPNotifier{Address: address, From: "tracker@example.com"}
+
+	n := notification.Notification{
+		Message: "alice mentioned you in a comment of issue 'title' in project 'project'",
+		Created: time.Date(2021, 1, 5, 10, 30, 0, 0, time.UTC)}
+	if err := smtpNotifier.Notify("bob@example.com", []notification.Notification{n}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case mail := <-received:
+		text := strings.Join(mail, "\n")
+		if !strings.Contains(text, "RCPT TO:<bob@example.com>") {
+			t.Errorf("Email was not sent to the recipient:\n" + text)
+		}
+		if !strings.Contains(text, "Subject: [Issue Tracker] "+n.Message) {
+			t.Errorf("Email subject should contain the notification:\n" + text)
+		}
+		if !strings.Contains(text, "2021-01-05 10:30 "+n.Message) {
+			t.Errorf("Email body should contain the notification:\n" + text)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Email was not received")
+	}
+}
+
+func TestDigestMessage(t *testing.T) {
+	notifications := []notification.Notification{
+		notification.Notification{Message: "first"},
+		notification.Notification{Message: "second"}}
+
+	message := string(Message("tracker@example.com", "bob@example.com", notifications))
+
+	if !strings.Contains(message, "Subject: [Issue Tracker] Digest of 2 notifications\r\n") {
+		t.Errorf("Digest should have a summary as a subject:\n" + message)
+	}
+
+	if !strings.Contains(message, " first\r\n") || !strings.Contains(message, " second\r\n") {
+		t.Errorf("Digest should contain all notifications:\n" + message)
+	}
+}
+
+func TestMessageSubjectIsOneHeader(t *testing.T) {
+	notifications := []notification.Notification{
+		notification.Notification{Message: "alice resolved issue 'Crash\r\nBcc: eve@example.com' in project 'Проект'"}}
+
+	message := string(Message("tracker@example.com", "bob@example.com", notifications))
+	headers := message[:strings.Index(message, "\r\n\r\n")]
+
+	if strings.Contains(headers, "\r\nBcc:") {
+		t.Errorf("A line break in the subject should not start a new header:\n" + message)
+	}
+
+	if !strings.Contains(headers, "Subject: =?utf-8?q?") {
+		t.Errorf("A subject which isn't ASCII should be encoded:\n" + message)
+	}
+}
@@ -2,5 +2,6 @@ package project
 
 // Project is an abstraction for a real-life project
 type Project struct {
-	Name string
+	Name  string `json:"name"`
+	Owner string `json:"owner,omitempty"`
 }
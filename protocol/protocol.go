@@ -0,0 +1,106 @@
+package protocol
+
+import "strings"
+
+// Separator separates the type and the fields of a request
+const Separator = "|-|"
+
+// FailurePrefix marks the responses to requests which could not be executed
+const FailurePrefix = "error" + Separator
+
+// DataPrefix marks the requests for structured data and the responses carrying it as JSON
+const DataPrefix = "data" + Separator
+
+// escaper replaces the characters which would end a line or form a separator with escape sequences
+var escaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`, "|", `\p`)
+
+// Escape encodes a field or a message, e.g. a multi-line issue description, so that it fits on a single line
+// and doesn't contain the separator
+func Escape(text string) string {
+	return escaper.Replace(text)
+}
+
+// Unescape decodes a field or a message encoded with Escape. Unknown escape sequences are left as they are
+func Unescape(text string) string {
+	if !strings.Contains(text, `\`) {
+		return text
+	}
+
+	var decoded strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' || i == len(text)-1 {
+			decoded.WriteByte(text[i])
+			continue
+		}
+
+		i++
+		switch text[i] {
+		case '\\':
+			decoded.WriteByte('\\')
+		case 'n':
+			decoded.WriteByte('\n')
+		case 'r':
+			decoded.WriteByte('\r')
+		case 'p':
+			decoded.WriteByte('|')
+		default:
+			decoded.WriteByte('\\')
+			decoded.WriteByte(text[i])
+		}
+	}
+
+	return decoded.String()
+}
+
+// Request joins the type and the escaped fields of a request into a line, without the trailing newline
+func Request(commandType string, fields ...string) string {
+	escaped := []string{commandType}
+	for _, field := range fields {
+		escaped = append(escaped, Escape(field))
+	}
+
+	return strings.Join(escaped, Separator)
+}
+
+// Fields splits a request line into its type and its unescaped fields
+func Fields(request string) []string {
+	fields := strings.Split(request, Separator)
+	for i := range fields {
+		fields[i] = Unescape(fields[i])
+	}
+
+	return fields
+}
+
+// Response formats the message of an executed request as a line sent to the client
+func Response(message string, ok bool) string {
+	message = Escape(strings.TrimRight(message, "\n")) + "\n"
+	if !ok {
+		return FailurePrefix + message
+	}
+
+	return message
+}
+
+// ParseResponse splits a response line into its unescaped message and whether the request was executed
+func ParseResponse(line string) (string, bool) {
+	if strings.HasPrefix(line, FailurePrefix) {
+		return Unescape(strings.TrimPrefix(line, FailurePrefix)), false
+	}
+
+	return Unescape(line), true
+}
+
+// DataRequest asks for the result of a request as structured data instead of a message
+func DataRequest(request string) string {
+	return DataPrefix + request
+}
+
+// Data returns the JSON carried by the message of a response to a data request
+func Data(message string) ([]byte, bool) {
+	if !strings.HasPrefix(message, DataPrefix) {
+		return nil, false
+	}
+
+	return []byte(strings.TrimSpace(strings.TrimPrefix(message, DataPrefix))), true
+}
@@ -0,0 +1,65 @@
+package protocol
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRequest(t *testing.T) {
+	expected := "find|-|project|-|title"
+	if request := Request("find", "project", "title"); request != expected {
+		t.Errorf("Invalid request. Expected: " + expected + ", but got " + request)
+	}
+}
+
+func TestResponseRoundTrip(t *testing.T) {
+	for _, ok := range []bool{true, false} {
+		message, parsedOK := ParseResponse(Response("Issue does not exist \n", ok))
+		if message != "Issue does not exist \n" || parsedOK != ok {
+			t.Errorf("Invalid parsed response %q, %v", message, parsedOK)
+		}
+	}
+}
+
+func TestDataResponse(t *testing.T) {
+	message, ok := ParseResponse(Response(DataPrefix+`{"title":"a"}`, true))
+	data, isData := Data(message)
+	if !ok || !isData || string(data) != `{"title":"a"}` {
+		t.Errorf("Invalid data %q", data)
+	}
+
+	if _, isData := Data("Issues: a, b\n"); isData {
+		t.Errorf("A plain message was taken for data")
+	}
+}
+
+func TestEscapedFieldsRoundTrip(t *testing.T) {
+	description := "## Stack trace\n\tat main.go:12 |-| C:\\temp\\n\r\n"
+	request := Request("issue", "project", description)
+	if strings.ContainsAny(request, "\n\r") || strings.Count(request, Separator) != 2 {
+		t.Fatalf("Invalid escaped request %q", request)
+	}
+
+	fields := Fields(request)
+	if len(fields) != 3 || fields[0] != "issue" || fields[1] != "project" || fields[2] != description {
+		t.Errorf("Invalid fields %q", fields)
+	}
+}
+
+func TestMultiLineResponse(t *testing.T) {
+	response := Response("first line\nsecond | line\n", false)
+	if strings.Count(response, "\n") != 1 {
+		t.Fatalf("The response %q spans more than one line", response)
+	}
+
+	message, ok := ParseResponse(response)
+	if message != "first line\nsecond | line\n" || ok {
+		t.Errorf("Invalid parsed response %q, %v", message, ok)
+	}
+}
+
+func TestUnescapeUnknownSequence(t *testing.T) {
+	if text := Unescape(`C:\temp\`); text != `C:\temp\` {
+		t.Errorf("Invalid unescaped text %q", text)
+	}
+}
@@ -0,0 +1,92 @@
+package push
+
+import (
+	"sync"
+
+	"go.fmi/issuetracker/notification"
+	"go.fmi/issuetracker/webhook"
+)
+
+// EventPrefix marks the lines which the server pushes to a client on its own, as opposed to responses to requests
+const EventPrefix = "event|-|"
+
+var (
+	lock               sync.Mutex
+	subscribers        = make(map[string]map[chan<- notification.Notification]bool)
+	projectSubscribers = make(map[string]map[chan<- webhook.Payload]bool)
+)
+
+// Subscribe starts delivering the notifications of a user to a channel of one of their connections
+func Subscribe(username string, events chan<- notification.Notification) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if subscribers[username] == nil {
+		subscribers[username] = make(map[chan<- notification.Notification]bool)
+	}
+	subscribers[username][events] = true
+}
+
+// Unsubscribe stops delivering the notifications of a user to a channel. Once it returns, nothing more is sent to the channel
+func Unsubscribe(username string, events chan<- notification.Notification) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	delete(subscribers[username], events)
+	if len(subscribers[username]) == 0 {
+		delete(subscribers, username)
+	}
+}
+
+// Publish pushes a notification to every connection of its recipient. A connection which is not keeping up
+// misses the notification instead of blocking the sender - it can still be found in the inbox of the user
+func Publish(n notification.Notification) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	for events := range subscribers[n.Recipient] {
+		select {
+		case events <- n:
+		default:
+		}
+	}
+}
+
+// SubscribeProject starts delivering the events of a project to a channel. An empty project subscribes to the events
+// of all projects
+func SubscribeProject(project string, events chan<- webhook.Payload) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if projectSubscribers[project] == nil {
+		projectSubscribers[project] = make(map[chan<- webhook.Payload]bool)
+	}
+	projectSubscribers[project][events] = true
+}
+
+// UnsubscribeProject stops delivering the events of a project to a channel. Once it returns, nothing more is sent to the channel
+func UnsubscribeProject(project string, events chan<- webhook.Payload) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	delete(projectSubscribers[project], events)
+	if len(projectSubscribers[project]) == 0 {
+		delete(projectSubscribers, project)
+	}
+}
+
+// PublishEvent pushes an event to the subscribers of its project and of all projects. As with notifications,
+// a subscriber which is not keeping up misses the event
+func PublishEvent(payload webhook.Payload) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	for _, project := range []string{payload.Project, ""} {
+		for events := range projectSubscribers[project] {
+			select {
+			case events <- payload:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package push
+
+import (
+	"testing"
+
+	"go.fmi/issuetracker/notification"
+	"go.fmi/issuetracker/webhook"
+)
+
+func TestPublishToSubscriber(t *testing.T) {
+	events := make(chan notification.Notification, 1)
+	Subscribe("user", events)
+	defer Unsubscribe("user", events)
+
+	Publish(notification.Notification{Recipient: "user", Message: "message"})
+	Publish(notification.Notification{Recipient: "someone else", Message: "other message"})
+
+	select {
+	case n := <-events:
+		if n.Message != "message" {
+			t.Errorf("Expected the notification for the subscriber, but got: " + n.Message)
+		}
+	default:
+		t.Fatalf("Notification was not pushed to the subscriber")
+	}
+
+	if len(events) != 0 {
+		t.Errorf("Notification for another user was pushed to the subscriber")
+	}
+}
+
+func TestPublishDoesNotBlock(t *testing.T) {
+	events := make(chan notification.Notification)
+	Subscribe("user", events)
+	defer Unsubscribe("user", events)
+
+	// Nobody is receiving from the channel, so the notification is dropped
+	Publish(notification.Notification{Recipient: "user"})
+}
+
+func TestUnsubscribe(t *testing.T) {
+	events := make(chan notification.Notification, 1)
+	Subscribe("user", events)
+	Unsubscribe("user", events)
+
+	Publish(notification.Notification{Recipient: "user"})
+
+	if len(events) != 0 {
+		t.Errorf("Notification was pushed after unsubscribing")
+	}
+}
+
+func TestPublishEvent(t *testing.T) {
+	projectEvents := make(chan webhook.Payload, 2)
+	SubscribeProject("project", projectEvents)
+	defer UnsubscribeProject("project", projectEvents)
+
+	allEvents := make(chan webhook.Payload, 2)
+	SubscribeProject("", allEvents)
+	defer UnsubscribeProject("", allEvents)
+
+	PublishEvent(webhook.Payload{Project: "project", Event: webhook.IssueCreated})
+	PublishEvent(webhook.Payload{Project: "other", Event: webhook.IssueResolved})
+
+	if len(projectEvents) != 1 || (<-projectEvents).Project != "project" {
+		t.Errorf("Expected only the event of the subscribed project")
+	}
+	if len(allEvents) != 2 {
+		t.Errorf("Expected the events of all projects, but got %d", len(allEvents))
+	}
+}
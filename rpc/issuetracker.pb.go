@@ -0,0 +1,1001 @@
+// The gRPC contract of the issue tracker. The Go code in issuetracker.pb.go and issuetracker_grpc.pb.go is generated
+// from it with go generate, and clients in other languages can be generated from it as usual.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: issuetracker.proto
+
+package rpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Credentials struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Credentials) Reset() {
+	*x = Credentials{}
+	mi := &file_issuetracker_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Credentials) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Credentials) ProtoMessage() {}
+
+func (x *Credentials) ProtoReflect() protoreflect.Message {
+	mi := &file_issuetracker_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Credentials.ProtoReflect.Descriptor instead.
+func (*Credentials) Descriptor() ([]byte, []int) {
+	return file_issuetracker_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Credentials) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *Credentials) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type Token struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Username string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Token    string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	// Unix time in seconds
+	Expires       int64 `protobuf:"varint,3,opt,name=expires,proto3" json:"expires,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Token) Reset() {
+	*x = Token{}
+	mi := &file_issuetracker_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Token) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Token) ProtoMessage() {}
+
+func (x *Token) ProtoReflect() protoreflect.Message {
+	mi := &file_issuetracker_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Token.ProtoReflect.Descriptor instead.
+func (*Token) Descriptor() ([]byte, []int) {
+	return file_issuetracker_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Token) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *Token) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *Token) GetExpires() int64 {
+	if x != nil {
+		return x.Expires
+	}
+	return 0
+}
+
+type ProjectRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProjectRequest) Reset() {
+	*x = ProjectRequest{}
+	mi := &file_issuetracker_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProjectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProjectRequest) ProtoMessage() {}
+
+func (x *ProjectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_issuetracker_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProjectRequest.ProtoReflect.Descriptor instead.
+func (*ProjectRequest) Descriptor() ([]byte, []int) {
+	return file_issuetracker_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ProjectRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ProjectRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Project       string                 `protobuf:"bytes,1,opt,name=project,proto3" json:"project,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProjectRef) Reset() {
+	*x = ProjectRef{}
+	mi := &file_issuetracker_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProjectRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProjectRef) ProtoMessage() {}
+
+func (x *ProjectRef) ProtoReflect() protoreflect.Message {
+	mi := &file_issuetracker_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProjectRef.ProtoReflect.Descriptor instead.
+func (*ProjectRef) Descriptor() ([]byte, []int) {
+	return file_issuetracker_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ProjectRef) GetProject() string {
+	if x != nil {
+		return x.Project
+	}
+	return ""
+}
+
+type IssueRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Project       string                 `protobuf:"bytes,1,opt,name=project,proto3" json:"project,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IssueRequest) Reset() {
+	*x = IssueRequest{}
+	mi := &file_issuetracker_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IssueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssueRequest) ProtoMessage() {}
+
+func (x *IssueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_issuetracker_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IssueRequest.ProtoReflect.Descriptor instead.
+func (*IssueRequest) Descriptor() ([]byte, []int) {
+	return file_issuetracker_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *IssueRequest) GetProject() string {
+	if x != nil {
+		return x.Project
+	}
+	return ""
+}
+
+func (x *IssueRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *IssueRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type IssueRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Project       string                 `protobuf:"bytes,1,opt,name=project,proto3" json:"project,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IssueRef) Reset() {
+	*x = IssueRef{}
+	mi := &file_issuetracker_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IssueRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssueRef) ProtoMessage() {}
+
+func (x *IssueRef) ProtoReflect() protoreflect.Message {
+	mi := &file_issuetracker_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IssueRef.ProtoReflect.Descriptor instead.
+func (*IssueRef) Descriptor() ([]byte, []int) {
+	return file_issuetracker_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *IssueRef) GetProject() string {
+	if x != nil {
+		return x.Project
+	}
+	return ""
+}
+
+func (x *IssueRef) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+type CommentRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Project string                 `protobuf:"bytes,1,opt,name=project,proto3" json:"project,omitempty"`
+	Title   string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Content string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	// The comment which is replied to, 0 for none
+	Parent        int64 `protobuf:"varint,4,opt,name=parent,proto3" json:"parent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommentRequest) Reset() {
+	*x = CommentRequest{}
+	mi := &file_issuetracker_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommentRequest) ProtoMessage() {}
+
+func (x *CommentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_issuetracker_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommentRequest.ProtoReflect.Descriptor instead.
+func (*CommentRequest) Descriptor() ([]byte, []int) {
+	return file_issuetracker_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CommentRequest) GetProject() string {
+	if x != nil {
+		return x.Project
+	}
+	return ""
+}
+
+func (x *CommentRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *CommentRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *CommentRequest) GetParent() int64 {
+	if x != nil {
+		return x.Parent
+	}
+	return 0
+}
+
+type Reply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Reply) Reset() {
+	*x = Reply{}
+	mi := &file_issuetracker_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reply) ProtoMessage() {}
+
+func (x *Reply) ProtoReflect() protoreflect.Message {
+	mi := &file_issuetracker_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reply.ProtoReflect.Descriptor instead.
+func (*Reply) Descriptor() ([]byte, []int) {
+	return file_issuetracker_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Reply) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type Issue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Project       string                 `protobuf:"bytes,1,opt,name=project,proto3" json:"project,omitempty"`
+	Reporter      string                 `protobuf:"bytes,2,opt,name=reporter,proto3" json:"reporter,omitempty"`
+	Title         string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Description   string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	Resolved      bool                   `protobuf:"varint,5,opt,name=resolved,proto3" json:"resolved,omitempty"`
+	Assignee      string                 `protobuf:"bytes,6,opt,name=assignee,proto3" json:"assignee,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Issue) Reset() {
+	*x = Issue{}
+	mi := &file_issuetracker_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Issue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Issue) ProtoMessage() {}
+
+func (x *Issue) ProtoReflect() protoreflect.Message {
+	mi := &file_issuetracker_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Issue.ProtoReflect.Descriptor instead.
+func (*Issue) Descriptor() ([]byte, []int) {
+	return file_issuetracker_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Issue) GetProject() string {
+	if x != nil {
+		return x.Project
+	}
+	return ""
+}
+
+func (x *Issue) GetReporter() string {
+	if x != nil {
+		return x.Reporter
+	}
+	return ""
+}
+
+func (x *Issue) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Issue) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Issue) GetResolved() bool {
+	if x != nil {
+		return x.Resolved
+	}
+	return false
+}
+
+func (x *Issue) GetAssignee() string {
+	if x != nil {
+		return x.Assignee
+	}
+	return ""
+}
+
+type Issues struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Issues        []*Issue               `protobuf:"bytes,1,rep,name=issues,proto3" json:"issues,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Issues) Reset() {
+	*x = Issues{}
+	mi := &file_issuetracker_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Issues) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Issues) ProtoMessage() {}
+
+func (x *Issues) ProtoReflect() protoreflect.Message {
+	mi := &file_issuetracker_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Issues.ProtoReflect.Descriptor instead.
+func (*Issues) Descriptor() ([]byte, []int) {
+	return file_issuetracker_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Issues) GetIssues() []*Issue {
+	if x != nil {
+		return x.Issues
+	}
+	return nil
+}
+
+type Comment struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Parent    int64                  `protobuf:"varint,2,opt,name=parent,proto3" json:"parent,omitempty"`
+	Project   string                 `protobuf:"bytes,3,opt,name=project,proto3" json:"project,omitempty"`
+	Title     string                 `protobuf:"bytes,4,opt,name=title,proto3" json:"title,omitempty"`
+	Content   string                 `protobuf:"bytes,5,opt,name=content,proto3" json:"content,omitempty"`
+	Commenter string                 `protobuf:"bytes,6,opt,name=commenter,proto3" json:"commenter,omitempty"`
+	// Unix time in seconds, 0 when the comment has not been edited
+	Created int64 `protobuf:"varint,7,opt,name=created,proto3" json:"created,omitempty"`
+	Edited  int64 `protobuf:"varint,8,opt,name=edited,proto3" json:"edited,omitempty"`
+	Deleted bool  `protobuf:"varint,9,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	// The depth of the reply in its thread, 0 for a top-level comment
+	Depth         int64 `protobuf:"varint,10,opt,name=depth,proto3" json:"depth,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Comment) Reset() {
+	*x = Comment{}
+	mi := &file_issuetracker_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Comment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Comment) ProtoMessage() {}
+
+func (x *Comment) ProtoReflect() protoreflect.Message {
+	mi := &file_issuetracker_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Comment.ProtoReflect.Descriptor instead.
+func (*Comment) Descriptor() ([]byte, []int) {
+	return file_issuetracker_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Comment) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Comment) GetParent() int64 {
+	if x != nil {
+		return x.Parent
+	}
+	return 0
+}
+
+func (x *Comment) GetProject() string {
+	if x != nil {
+		return x.Project
+	}
+	return ""
+}
+
+func (x *Comment) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Comment) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Comment) GetCommenter() string {
+	if x != nil {
+		return x.Commenter
+	}
+	return ""
+}
+
+func (x *Comment) GetCreated() int64 {
+	if x != nil {
+		return x.Created
+	}
+	return 0
+}
+
+func (x *Comment) GetEdited() int64 {
+	if x != nil {
+		return x.Edited
+	}
+	return 0
+}
+
+func (x *Comment) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+func (x *Comment) GetDepth() int64 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+type IssueDetails struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Issue *Issue                 `protobuf:"bytes,1,opt,name=issue,proto3" json:"issue,omitempty"`
+	// In thread order - every comment is followed by its replies
+	Comments      []*Comment `protobuf:"bytes,2,rep,name=comments,proto3" json:"comments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IssueDetails) Reset() {
+	*x = IssueDetails{}
+	mi := &file_issuetracker_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IssueDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssueDetails) ProtoMessage() {}
+
+func (x *IssueDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_issuetracker_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IssueDetails.ProtoReflect.Descriptor instead.
+func (*IssueDetails) Descriptor() ([]byte, []int) {
+	return file_issuetracker_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *IssueDetails) GetIssue() *Issue {
+	if x != nil {
+		return x.Issue
+	}
+	return nil
+}
+
+func (x *IssueDetails) GetComments() []*Comment {
+	if x != nil {
+		return x.Comments
+	}
+	return nil
+}
+
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// One of issue.created, issue.updated, issue.resolved and comment.added
+	Kind    string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	Project string `protobuf:"bytes,2,opt,name=project,proto3" json:"project,omitempty"`
+	Title   string `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Actor   string `protobuf:"bytes,4,opt,name=actor,proto3" json:"actor,omitempty"`
+	// Unix time in seconds
+	Time          int64    `protobuf:"varint,5,opt,name=time,proto3" json:"time,omitempty"`
+	Issue         *Issue   `protobuf:"bytes,6,opt,name=issue,proto3" json:"issue,omitempty"`
+	Comment       *Comment `protobuf:"bytes,7,opt,name=comment,proto3" json:"comment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_issuetracker_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_issuetracker_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_issuetracker_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Event) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Event) GetProject() string {
+	if x != nil {
+		return x.Project
+	}
+	return ""
+}
+
+func (x *Event) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Event) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *Event) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *Event) GetIssue() *Issue {
+	if x != nil {
+		return x.Issue
+	}
+	return nil
+}
+
+func (x *Event) GetComment() *Comment {
+	if x != nil {
+		return x.Comment
+	}
+	return nil
+}
+
+var File_issuetracker_proto protoreflect.FileDescriptor
+
+const file_issuetracker_proto_rawDesc = "" +
+	"\n" +
+	"\x12issuetracker.proto\x12\fissuetracker\"E\n" +
+	"\vCredentials\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"S\n" +
+	"\x05Token\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12\x18\n" +
+	"\aexpires\x18\x03 \x01(\x03R\aexpires\"$\n" +
+	"\x0eProjectRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"&\n" +
+	"\n" +
+	"ProjectRef\x12\x18\n" +
+	"\aproject\x18\x01 \x01(\tR\aproject\"`\n" +
+	"\fIssueRequest\x12\x18\n" +
+	"\aproject\x18\x01 \x01(\tR\aproject\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\":\n" +
+	"\bIssueRef\x12\x18\n" +
+	"\aproject\x18\x01 \x01(\tR\aproject\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\"r\n" +
+	"\x0eCommentRequest\x12\x18\n" +
+	"\aproject\x18\x01 \x01(\tR\aproject\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x12\x16\n" +
+	"\x06parent\x18\x04 \x01(\x03R\x06parent\"!\n" +
+	"\x05Reply\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"\xad\x01\n" +
+	"\x05Issue\x12\x18\n" +
+	"\aproject\x18\x01 \x01(\tR\aproject\x12\x1a\n" +
+	"\breporter\x18\x02 \x01(\tR\breporter\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\x12\x1a\n" +
+	"\bresolved\x18\x05 \x01(\bR\bresolved\x12\x1a\n" +
+	"\bassignee\x18\x06 \x01(\tR\bassignee\"5\n" +
+	"\x06Issues\x12+\n" +
+	"\x06issues\x18\x01 \x03(\v2\x13.issuetracker.IssueR\x06issues\"\xfb\x01\n" +
+	"\aComment\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x16\n" +
+	"\x06parent\x18\x02 \x01(\x03R\x06parent\x12\x18\n" +
+	"\aproject\x18\x03 \x01(\tR\aproject\x12\x14\n" +
+	"\x05title\x18\x04 \x01(\tR\x05title\x12\x18\n" +
+	"\acontent\x18\x05 \x01(\tR\acontent\x12\x1c\n" +
+	"\tcommenter\x18\x06 \x01(\tR\tcommenter\x12\x18\n" +
+	"\acreated\x18\a \x01(\x03R\acreated\x12\x16\n" +
+	"\x06edited\x18\b \x01(\x03R\x06edited\x12\x18\n" +
+	"\adeleted\x18\t \x01(\bR\adeleted\x12\x14\n" +
+	"\x05depth\x18\n" +
+	" \x01(\x03R\x05depth\"l\n" +
+	"\fIssueDetails\x12)\n" +
+	"\x05issue\x18\x01 \x01(\v2\x13.issuetracker.IssueR\x05issue\x121\n" +
+	"\bcomments\x18\x02 \x03(\v2\x15.issuetracker.CommentR\bcomments\"\xd1\x01\n" +
+	"\x05Event\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x18\n" +
+	"\aproject\x18\x02 \x01(\tR\aproject\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\x12\x14\n" +
+	"\x05actor\x18\x04 \x01(\tR\x05actor\x12\x12\n" +
+	"\x04time\x18\x05 \x01(\x03R\x04time\x12)\n" +
+	"\x05issue\x18\x06 \x01(\v2\x13.issuetracker.IssueR\x05issue\x12/\n" +
+	"\acomment\x18\a \x01(\v2\x15.issuetracker.CommentR\acomment2\xac\x04\n" +
+	"\fIssueTracker\x12:\n" +
+	"\bRegister\x12\x19.issuetracker.Credentials\x1a\x13.issuetracker.Token\x127\n" +
+	"\x05Login\x12\x19.issuetracker.Credentials\x1a\x13.issuetracker.Token\x12B\n" +
+	"\rCreateProject\x12\x1c.issuetracker.ProjectRequest\x1a\x13.issuetracker.Reply\x12>\n" +
+	"\vCreateIssue\x12\x1a.issuetracker.IssueRequest\x1a\x13.issuetracker.Reply\x126\n" +
+	"\aResolve\x12\x16.issuetracker.IssueRef\x1a\x13.issuetracker.Reply\x126\n" +
+	"\x04List\x12\x18.issuetracker.ProjectRef\x1a\x14.issuetracker.Issues\x12:\n" +
+	"\x04Find\x12\x16.issuetracker.IssueRef\x1a\x1a.issuetracker.IssueDetails\x12<\n" +
+	"\aComment\x12\x1c.issuetracker.CommentRequest\x1a\x13.issuetracker.Reply\x129\n" +
+	"\x06Events\x12\x18.issuetracker.ProjectRef\x1a\x13.issuetracker.Event0\x01B\x19Z\x17go.fmi/issuetracker/rpcb\x06proto3"
+
+var (
+	file_issuetracker_proto_rawDescOnce sync.Once
+	file_issuetracker_proto_rawDescData []byte
+)
+
+func file_issuetracker_proto_rawDescGZIP() []byte {
+	file_issuetracker_proto_rawDescOnce.Do(func() {
+		file_issuetracker_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_issuetracker_proto_rawDesc), len(file_issuetracker_proto_rawDesc)))
+	})
+	return file_issuetracker_proto_rawDescData
+}
+
+var file_issuetracker_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_issuetracker_proto_goTypes = []any{
+	(*Credentials)(nil),    // 0: issuetracker.Credentials
+	(*Token)(nil),          // 1: issuetracker.Token
+	(*ProjectRequest)(nil), // 2: issuetracker.ProjectRequest
+	(*ProjectRef)(nil),     // 3: issuetracker.ProjectRef
+	(*IssueRequest)(nil),   // 4: issuetracker.IssueRequest
+	(*IssueRef)(nil),       // 5: issuetracker.IssueRef
+	(*CommentRequest)(nil), // 6: issuetracker.CommentRequest
+	(*Reply)(nil),          // 7: issuetracker.Reply
+	(*Issue)(nil),          // 8: issuetracker.Issue
+	(*Issues)(nil),         // 9: issuetracker.Issues
+	(*Comment)(nil),        // 10: issuetracker.Comment
+	(*IssueDetails)(nil),   // 11: issuetracker.IssueDetails
+	(*Event)(nil),          // 12: issuetracker.Event
+}
+var file_issuetracker_proto_depIdxs = []int32{
+	8,  // 0: issuetracker.Issues.issues:type_name -> issuetracker.Issue
+	8,  // 1: issuetracker.IssueDetails.issue:type_name -> issuetracker.Issue
+	10, // 2: issuetracker.IssueDetails.comments:type_name -> issuetracker.Comment
+	8,  // 3: issuetracker.Event.issue:type_name -> issuetracker.Issue
+	10, // 4: issuetracker.Event.comment:type_name -> issuetracker.Comment
+	0,  // 5: issuetracker.IssueTracker.Register:input_type -> issuetracker.Credentials
+	0,  // 6: issuetracker.IssueTracker.Login:input_type -> issuetracker.Credentials
+	2,  // 7: issuetracker.IssueTracker.CreateProject:input_type -> issuetracker.ProjectRequest
+	4,  // 8: issuetracker.IssueTracker.CreateIssue:input_type -> issuetracker.IssueRequest
+	5,  // 9: issuetracker.IssueTracker.Resolve:input_type -> issuetracker.IssueRef
+	3,  // 10: issuetracker.IssueTracker.List:input_type -> issuetracker.ProjectRef
+	5,  // 11: issuetracker.IssueTracker.Find:input_type -> issuetracker.IssueRef
+	6,  // 12: issuetracker.IssueTracker.Comment:input_type -> issuetracker.CommentRequest
+	3,  // 13: issuetracker.IssueTracker.Events:input_type -> issuetracker.ProjectRef
+	1,  // 14: issuetracker.IssueTracker.Register:output_type -> issuetracker.Token
+	1,  // 15: issuetracker.IssueTracker.Login:output_type -> issuetracker.Token
+	7,  // 16: issuetracker.IssueTracker.CreateProject:output_type -> issuetracker.Reply
+	7,  // 17: issuetracker.IssueTracker.CreateIssue:output_type -> issuetracker.Reply
+	7,  // 18: issuetracker.IssueTracker.Resolve:output_type -> issuetracker.Reply
+	9,  // 19: issuetracker.IssueTracker.List:output_type -> issuetracker.Issues
+	11, // 20: issuetracker.IssueTracker.Find:output_type -> issuetracker.IssueDetails
+	7,  // 21: issuetracker.IssueTracker.Comment:output_type -> issuetracker.Reply
+	12, // 22: issuetracker.IssueTracker.Events:output_type -> issuetracker.Event
+	14, // [14:23] is the sub-list for method output_type
+	5,  // [5:14] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_issuetracker_proto_init() }
+func file_issuetracker_proto_init() {
+	if File_issuetracker_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_issuetracker_proto_rawDesc), len(file_issuetracker_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_issuetracker_proto_goTypes,
+		DependencyIndexes: file_issuetracker_proto_depIdxs,
+		MessageInfos:      file_issuetracker_proto_msgTypes,
+	}.Build()
+	File_issuetracker_proto = out.File
+	file_issuetracker_proto_goTypes = nil
+	file_issuetracker_proto_depIdxs = nil
+}
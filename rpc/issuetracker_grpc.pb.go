@@ -0,0 +1,438 @@
+// The gRPC contract of the issue tracker. The Go code in issuetracker.pb.go and issuetracker_grpc.pb.go is generated
+// from it with go generate, and clients in other languages can be generated from it as usual.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: issuetracker.proto
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	IssueTracker_Register_FullMethodName      = "/issuetracker.IssueTracker/Register"
+	IssueTracker_Login_FullMethodName         = "/issuetracker.IssueTracker/Login"
+	IssueTracker_CreateProject_FullMethodName = "/issuetracker.IssueTracker/CreateProject"
+	IssueTracker_CreateIssue_FullMethodName   = "/issuetracker.IssueTracker/CreateIssue"
+	IssueTracker_Resolve_FullMethodName       = "/issuetracker.IssueTracker/Resolve"
+	IssueTracker_List_FullMethodName          = "/issuetracker.IssueTracker/List"
+	IssueTracker_Find_FullMethodName          = "/issuetracker.IssueTracker/Find"
+	IssueTracker_Comment_FullMethodName       = "/issuetracker.IssueTracker/Comment"
+	IssueTracker_Events_FullMethodName        = "/issuetracker.IssueTracker/Events"
+)
+
+// IssueTrackerClient is the client API for IssueTracker service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// All calls except for Register and Login need an "authorization: Bearer <token>" metadata entry
+type IssueTrackerClient interface {
+	Register(ctx context.Context, in *Credentials, opts ...grpc.CallOption) (*Token, error)
+	Login(ctx context.Context, in *Credentials, opts ...grpc.CallOption) (*Token, error)
+	CreateProject(ctx context.Context, in *ProjectRequest, opts ...grpc.CallOption) (*Reply, error)
+	CreateIssue(ctx context.Context, in *IssueRequest, opts ...grpc.CallOption) (*Reply, error)
+	Resolve(ctx context.Context, in *IssueRef, opts ...grpc.CallOption) (*Reply, error)
+	List(ctx context.Context, in *ProjectRef, opts ...grpc.CallOption) (*Issues, error)
+	Find(ctx context.Context, in *IssueRef, opts ...grpc.CallOption) (*IssueDetails, error)
+	Comment(ctx context.Context, in *CommentRequest, opts ...grpc.CallOption) (*Reply, error)
+	// Events streams the changes of the issues in a project, or in all projects when the project is empty
+	Events(ctx context.Context, in *ProjectRef, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type issueTrackerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIssueTrackerClient(cc grpc.ClientConnInterface) IssueTrackerClient {
+	return &issueTrackerClient{cc}
+}
+
+func (c *issueTrackerClient) Register(ctx context.Context, in *Credentials, opts ...grpc.CallOption) (*Token, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Token)
+	err := c.cc.Invoke(ctx, IssueTracker_Register_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *issueTrackerClient) Login(ctx context.Context, in *Credentials, opts ...grpc.CallOption) (*Token, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Token)
+	err := c.cc.Invoke(ctx, IssueTracker_Login_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *issueTrackerClient) CreateProject(ctx context.Context, in *ProjectRequest, opts ...grpc.CallOption) (*Reply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reply)
+	err := c.cc.Invoke(ctx, IssueTracker_CreateProject_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *issueTrackerClient) CreateIssue(ctx context.Context, in *IssueRequest, opts ...grpc.CallOption) (*Reply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reply)
+	err := c.cc.Invoke(ctx, IssueTracker_CreateIssue_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *issueTrackerClient) Resolve(ctx context.Context, in *IssueRef, opts ...grpc.CallOption) (*Reply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reply)
+	err := c.cc.Invoke(ctx, IssueTracker_Resolve_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *issueTrackerClient) List(ctx context.Context, in *ProjectRef, opts ...grpc.CallOption) (*Issues, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Issues)
+	err := c.cc.Invoke(ctx, IssueTracker_List_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *issueTrackerClient) Find(ctx context.Context, in *IssueRef, opts ...grpc.CallOption) (*IssueDetails, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IssueDetails)
+	err := c.cc.Invoke(ctx, IssueTracker_Find_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *issueTrackerClient) Comment(ctx context.Context, in *CommentRequest, opts ...grpc.CallOption) (*Reply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Reply)
+	err := c.cc.Invoke(ctx, IssueTracker_Comment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *issueTrackerClient) Events(ctx context.Context, in *ProjectRef, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &IssueTracker_ServiceDesc.Streams[0], IssueTracker_Events_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ProjectRef, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type IssueTracker_EventsClient = grpc.ServerStreamingClient[Event]
+
+// IssueTrackerServer is the server API for IssueTracker service.
+// All implementations must embed UnimplementedIssueTrackerServer
+// for forward compatibility.
+//
+// All calls except for Register and Login need an "authorization: Bearer <token>" metadata entry
+type IssueTrackerServer interface {
+	Register(context.Context, *Credentials) (*Token, error)
+	Login(context.Context, *Credentials) (*Token, error)
+	CreateProject(context.Context, *ProjectRequest) (*Reply, error)
+	CreateIssue(context.Context, *IssueRequest) (*Reply, error)
+	Resolve(context.Context, *IssueRef) (*Reply, error)
+	List(context.Context, *ProjectRef) (*Issues, error)
+	Find(context.Context, *IssueRef) (*IssueDetails, error)
+	Comment(context.Context, *CommentRequest) (*Reply, error)
+	// Events streams the changes of the issues in a project, or in all projects when the project is empty
+	Events(*ProjectRef, grpc.ServerStreamingServer[Event]) error
+	mustEmbedUnimplementedIssueTrackerServer()
+}
+
+// UnimplementedIssueTrackerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedIssueTrackerServer struct{}
+
+func (UnimplementedIssueTrackerServer) Register(context.Context, *Credentials) (*Token, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedIssueTrackerServer) Login(context.Context, *Credentials) (*Token, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedIssueTrackerServer) CreateProject(context.Context, *ProjectRequest) (*Reply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateProject not implemented")
+}
+func (UnimplementedIssueTrackerServer) CreateIssue(context.Context, *IssueRequest) (*Reply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateIssue not implemented")
+}
+func (UnimplementedIssueTrackerServer) Resolve(context.Context, *IssueRef) (*Reply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Resolve not implemented")
+}
+func (UnimplementedIssueTrackerServer) List(context.Context, *ProjectRef) (*Issues, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedIssueTrackerServer) Find(context.Context, *IssueRef) (*IssueDetails, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Find not implemented")
+}
+func (UnimplementedIssueTrackerServer) Comment(context.Context, *CommentRequest) (*Reply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Comment not implemented")
+}
+func (UnimplementedIssueTrackerServer) Events(*ProjectRef, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method Events not implemented")
+}
+func (UnimplementedIssueTrackerServer) mustEmbedUnimplementedIssueTrackerServer() {}
+func (UnimplementedIssueTrackerServer) testEmbeddedByValue()                      {}
+
+// UnsafeIssueTrackerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IssueTrackerServer will
+// result in compilation errors.
+type UnsafeIssueTrackerServer interface {
+	mustEmbedUnimplementedIssueTrackerServer()
+}
+
+func RegisterIssueTrackerServer(s grpc.ServiceRegistrar, srv IssueTrackerServer) {
+	// If the following call pancis, it indicates UnimplementedIssueTrackerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&IssueTracker_ServiceDesc, srv)
+}
+
+func _IssueTracker_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Credentials)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IssueTrackerServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IssueTracker_Register_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IssueTrackerServer).Register(ctx, req.(*Credentials))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IssueTracker_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Credentials)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IssueTrackerServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IssueTracker_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IssueTrackerServer).Login(ctx, req.(*Credentials))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IssueTracker_CreateProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IssueTrackerServer).CreateProject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IssueTracker_CreateProject_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IssueTrackerServer).CreateProject(ctx, req.(*ProjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IssueTracker_CreateIssue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IssueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IssueTrackerServer).CreateIssue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IssueTracker_CreateIssue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IssueTrackerServer).CreateIssue(ctx, req.(*IssueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IssueTracker_Resolve_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IssueRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IssueTrackerServer).Resolve(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IssueTracker_Resolve_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IssueTrackerServer).Resolve(ctx, req.(*IssueRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IssueTracker_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProjectRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IssueTrackerServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IssueTracker_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IssueTrackerServer).List(ctx, req.(*ProjectRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IssueTracker_Find_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IssueRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IssueTrackerServer).Find(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IssueTracker_Find_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IssueTrackerServer).Find(ctx, req.(*IssueRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IssueTracker_Comment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IssueTrackerServer).Comment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IssueTracker_Comment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IssueTrackerServer).Comment(ctx, req.(*CommentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IssueTracker_Events_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ProjectRef)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IssueTrackerServer).Events(m, &grpc.GenericServerStream[ProjectRef, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type IssueTracker_EventsServer = grpc.ServerStreamingServer[Event]
+
+// IssueTracker_ServiceDesc is the grpc.ServiceDesc for IssueTracker service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var IssueTracker_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "issuetracker.IssueTracker",
+	HandlerType: (*IssueTrackerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _IssueTracker_Register_Handler,
+		},
+		{
+			MethodName: "Login",
+			Handler:    _IssueTracker_Login_Handler,
+		},
+		{
+			MethodName: "CreateProject",
+			Handler:    _IssueTracker_CreateProject_Handler,
+		},
+		{
+			MethodName: "CreateIssue",
+			Handler:    _IssueTracker_CreateIssue_Handler,
+		},
+		{
+			MethodName: "Resolve",
+			Handler:    _IssueTracker_Resolve_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _IssueTracker_List_Handler,
+		},
+		{
+			MethodName: "Find",
+			Handler:    _IssueTracker_Find_Handler,
+		},
+		{
+			MethodName: "Comment",
+			Handler:    _IssueTracker_Comment_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Events",
+			Handler:       _IssueTracker_Events_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "issuetracker.proto",
+}
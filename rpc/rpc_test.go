@@ -0,0 +1,180 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"go.fmi/issuetracker/audit"
+	"go.fmi/issuetracker/comment"
+	"go.fmi/issuetracker/db"
+	"go.fmi/issuetracker/issue"
+	"go.fmi/issuetracker/project"
+	"go.fmi/issuetracker/push"
+	"go.fmi/issuetracker/token"
+	"go.fmi/issuetracker/user"
+	"go.fmi/issuetracker/webhook"
+
+	"bou.ke/monkey"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// connect starts the service on an in-memory listener and returns a client for it
+func connect(t *testing.T) IssueTrackerClient {
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer()
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return NewIssueTrackerClient(conn)
+}
+
+// authorized returns a context carrying a token, which is valid for the given user
+func authorized(username string) context.Context {
+	monkey.Patch(db.FindToken, func(hash string) (token.Token, error) {
+		return token.Token{Hash: hash, Username: username, Expires: time.Now().Add(time.Hour)}, nil
+	})
+
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+}
+
+func TestLogin(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		return user.User{Username: username, Password: user.HashAndSalt("password")}, nil
+	})
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		return
+	})
+
+	var inserted token.Token
+	monkey.Patch(db.InsertToken, func(newToken token.Token) {
+		inserted = newToken
+	})
+
+	reply, err := connect(t).Login(context.Background(), &Credentials{Username: "user", Password: "password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reply.Username != "user" || token.Hash(reply.Token) != inserted.Hash {
+		t.Errorf("The issued token is not the stored one: %+v", reply)
+	}
+}
+
+func TestCallWithoutToken(t *testing.T) {
+	_, err := connect(t).List(context.Background(), &ProjectRef{Project: "project"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Error("Invalid status. Expected: Unauthenticated, but got ", err)
+	}
+}
+
+func TestFindIssue(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	ctx := authorized("user")
+	monkey.Patch(db.FindExistingProject, func(name string) (project.Project, error) {
+		return project.Project{Name: name}, nil
+	})
+	monkey.Patch(db.FindExistingIssue, func(projectName string, title string) (issue.Issue, error) {
+		return issue.Issue{Project: projectName, Title: title, Status: issue.StatusResolved}, nil
+	})
+	monkey.Patch(db.FindComments, func(string, string) []comment.Comment {
+		return []comment.Comment{{ID: 2, Parent: 1, Content: "reply"}, {ID: 1, Content: "root"}}
+	})
+
+	details, err := connect(t).Find(ctx, &IssueRef{Project: "project", Title: "title"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !details.Issue.Resolved || len(details.Comments) != 2 ||
+		details.Comments[0].Id != 1 || details.Comments[1].Id != 2 || details.Comments[1].Depth != 1 {
+		t.Errorf("Invalid issue details: %+v", details)
+	}
+}
+
+func TestCreateProjectWithTakenName(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	ctx := authorized("user")
+	monkey.Patch(db.FindExistingProject, func(name string) (project.Project, error) {
+		return project.Project{Name: name}, nil
+	})
+
+	_, err := connect(t).CreateProject(ctx, &ProjectRequest{Name: "project"})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Error("Invalid status. Expected: AlreadyExists, but got ", err)
+	}
+}
+
+func TestListMissingProject(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	ctx := authorized("user")
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{}, errors.New("Not found")
+	})
+
+	_, err := connect(t).List(ctx, &ProjectRef{Project: "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Error("Invalid status. Expected: NotFound, but got ", err)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	ctx, cancel := context.WithCancel(authorized("user"))
+	defer cancel()
+
+	stream, err := connect(t).Events(ctx, &ProjectRef{Project: "project"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The subscription is made once the server has received the request, so keep publishing until an event arrives
+	received := make(chan *Event)
+	go func() {
+		event, err := stream.Recv()
+		if err == nil {
+			received <- event
+		}
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		push.PublishEvent(webhook.Payload{
+			Event:   webhook.IssueResolved,
+			Project: "project",
+			Title:   "title",
+			Issue:   &issue.Issue{Project: "project", Title: "title", Status: issue.StatusResolved}})
+
+		select {
+		case event := <-received:
+			if event.Kind != webhook.IssueResolved || event.Title != "title" || event.Issue == nil || !event.Issue.Resolved {
+				t.Errorf("Invalid event: %+v", event)
+			}
+			return
+		case <-deadline:
+			t.Fatal("No event was streamed")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.fmi/issuetracker/command"
+	"go.fmi/issuetracker/comment"
+	"go.fmi/issuetracker/db"
+	"go.fmi/issuetracker/issue"
+	"go.fmi/issuetracker/project"
+	"go.fmi/issuetracker/push"
+	"go.fmi/issuetracker/token"
+	"go.fmi/issuetracker/user"
+	"go.fmi/issuetracker/webhook"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative issuetracker.proto
+
+// failureCode maps the kinds of failures of the commands to gRPC status codes
+var failureCode = map[error]codes.Code{
+	command.ErrInvalid:         codes.InvalidArgument,
+	command.ErrNotFound:        codes.NotFound,
+	command.ErrUnauthenticated: codes.Unauthenticated,
+	command.ErrForbidden:       codes.PermissionDenied,
+	command.ErrExists:          codes.AlreadyExists,
+	command.ErrConflict:        codes.FailedPrecondition,
+	command.ErrTooManyRequests: codes.ResourceExhausted,
+	command.ErrUnavailable:     codes.Unavailable,
+	command.ErrInternal:        codes.Internal,
+}
+
+// service implements the IssueTracker service of issuetracker.proto
+type service struct {
+	UnimplementedIssueTrackerServer
+}
+
+// NewServer creates a gRPC server with the issue tracker service registered on it
+func NewServer() *grpc.Server {
+	server := grpc.NewServer()
+	RegisterIssueTrackerServer(server, service{})
+	return server
+}
+
+// newSession creates the session of a call, which is anonymous until it is authenticated
+func newSession(ctx context.Context) *command.Session {
+	session := &command.Session{}
+	if p, ok := peer.FromContext(ctx); ok {
+		session.RemoteAddr = p.Addr.String()
+	}
+
+	return session
+}
+
+// authenticate logs the user owning the bearer token in the metadata of a call in a new session
+func authenticate(ctx context.Context) (*command.Session, error) {
+	session := newSession(ctx)
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, authorization := range md.Get("authorization") {
+		secret := strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+		existingToken, err := db.FindToken(token.Hash(secret))
+		if err == nil && !existingToken.Expired() {
+			session.Authenticate(existingToken)
+			return session, nil
+		}
+	}
+
+	return nil, status.Error(codes.Unauthenticated, "A valid token is required")
+}
+
+// failure converts the failure of a command to a gRPC error with its message
+func failure(message string, err error) error {
+	code, ok := failureCode[err]
+	if !ok {
+		code = codes.InvalidArgument
+	}
+
+	return status.Error(code, strings.TrimSpace(message))
+}
+
+// execute runs a command on behalf of the user authenticated in a call
+func execute(ctx context.Context, c command.Command) (*Reply, error) {
+	session, err := authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := command.ExecuteInSession(c, session)
+	if err != nil {
+		return nil, failure(result, err)
+	}
+
+	return &Reply{Message: strings.TrimSpace(result)}, nil
+}
+
+// issueToken runs a login or a registration and on success issues a session token
+func issueToken(ctx context.Context, run func(*command.Session) (string, error)) (*Token, error) {
+	session := newSession(ctx)
+	if result, err := run(session); err != nil {
+		return nil, failure(result, err)
+	}
+
+	newToken, secret, err := token.New(session.Username, token.SessionLifetime)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Could not issue a token")
+	}
+
+	db.InsertToken(newToken)
+	return &Token{Username: session.Username, Token: secret, Expires: newToken.Expires.Unix()}, nil
+}
+
+// Register registers a user and returns a token for them
+func (service) Register(ctx context.Context, credentials *Credentials) (*Token, error) {
+	registerCommand := command.RegisterCommand{User: user.User{Username: credentials.Username, Password: credentials.Password}}
+	return issueToken(ctx, registerCommand.ExecuteAs)
+}
+
+// Login returns a token for a registered user
+func (service) Login(ctx context.Context, credentials *Credentials) (*Token, error) {
+	loginCommand := command.LoginCommand{User: user.User{Username: credentials.Username, Password: credentials.Password}}
+	return issueToken(ctx, loginCommand.ExecuteAs)
+}
+
+// CreateProject creates a project owned by the caller
+func (service) CreateProject(ctx context.Context, request *ProjectRequest) (*Reply, error) {
+	return execute(ctx, command.ProjectCommand{Project: project.Project{Name: request.Name}})
+}
+
+// CreateIssue creates an issue reported by the caller
+func (service) CreateIssue(ctx context.Context, newIssue *IssueRequest) (*Reply, error) {
+	session, err := authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := command.ExecuteInSession(command.IssueCommand{Issue: issue.Issue{
+		Project:     newIssue.Project,
+		Title:       newIssue.Title,
+		Description: newIssue.Description}}, session)
+	if err != nil {
+		return nil, failure(result, err)
+	}
+
+	return &Reply{Message: strings.TrimSpace(result)}, nil
+}
+
+// Resolve resolves an issue
+func (service) Resolve(ctx context.Context, ref *IssueRef) (*Reply, error) {
+	return execute(ctx, command.ResolveCommand{Project: ref.Project, Title: ref.Title})
+}
+
+// List returns the issues in a project
+func (service) List(ctx context.Context, ref *ProjectRef) (*Issues, error) {
+	if _, err := authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	issues, result, err := command.ListCommand{Project: ref.Project}.Issues()
+	if err != nil {
+		return nil, failure(result, err)
+	}
+
+	reply := &Issues{}
+	for _, i := range issues {
+		reply.Issues = append(reply.Issues, toIssue(i))
+	}
+
+	return reply, nil
+}
+
+// Find returns an issue with its comments
+func (service) Find(ctx context.Context, ref *IssueRef) (*IssueDetails, error) {
+	if _, err := authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	foundIssue, comments, result, err := command.FindCommand{Project: ref.Project, Title: ref.Title}.Details()
+	if err != nil {
+		return nil, failure(result, err)
+	}
+
+	reply := &IssueDetails{Issue: toIssue(foundIssue)}
+	for _, entry := range comment.Thread(comments) {
+		c := toComment(entry.Comment)
+		c.Depth = int64(entry.Depth)
+		reply.Comments = append(reply.Comments, c)
+	}
+
+	return reply, nil
+}
+
+// Comment comments an issue or replies to a comment
+func (service) Comment(ctx context.Context, newComment *CommentRequest) (*Reply, error) {
+	session, err := authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := command.ExecuteInSession(command.CommentCommand{Comment: comment.Comment{
+		Parent:    int(newComment.Parent),
+		Project:   newComment.Project,
+		Title:     newComment.Title,
+		Content:   newComment.Content,
+		Commenter: session.Username}}, session)
+	if err != nil {
+		return nil, failure(result, err)
+	}
+
+	return &Reply{Message: strings.TrimSpace(result)}, nil
+}
+
+// Events streams the events of a project to the caller until the call is cancelled
+func (service) Events(ref *ProjectRef, stream grpc.ServerStreamingServer[Event]) error {
+	if _, err := authenticate(stream.Context()); err != nil {
+		return err
+	}
+
+	payloads := make(chan webhook.Payload, 16)
+	push.SubscribeProject(ref.Project, payloads)
+	defer push.UnsubscribeProject(ref.Project, payloads)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case payload := <-payloads:
+			if err := stream.Send(toEvent(payload)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toIssue(i issue.Issue) *Issue {
+	return &Issue{
+		Project:     i.Project,
+		Reporter:    i.Reporter,
+		Title:       i.Title,
+		Description: i.Description,
+		Resolved:    i.IsResolved(),
+		Assignee:    i.Assignee}
+}
+
+func toComment(c comment.Comment) *Comment {
+	return &Comment{
+		Id:        int64(c.ID),
+		Parent:    int64(c.Parent),
+		Project:   c.Project,
+		Title:     c.Title,
+		Content:   c.Content,
+		Commenter: c.Commenter,
+		Created:   unix(c.Created),
+		Edited:    unix(c.Edited),
+		Deleted:   c.Deleted}
+}
+
+func toEvent(payload webhook.Payload) *Event {
+	event := &Event{
+		Kind:    payload.Event,
+		Project: payload.Project,
+		Title:   payload.Title,
+		Actor:   payload.Actor,
+		Time:    unix(payload.Time)}
+	if payload.Issue != nil {
+		event.Issue = toIssue(*payload.Issue)
+	}
+	if payload.Comment != nil {
+		event.Comment = toComment(*payload.Comment)
+	}
+
+	return event
+}
+
+// unix returns the Unix time of a moment in seconds, 0 for the zero time
+func unix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.Unix()
+}
@@ -2,24 +2,60 @@ package main
 
 import (
 	"bufio"
+	"fmt"
 	"io"
 	"log"
 	"net"
+	"net/http"
+	"os"
 	"strings"
+	"sync"
+	"time"
+	// The timezones of the users are checked against the embedded database when the system has none
+	_ "time/tzdata"
 
+	"go.fmi/issuetracker/api"
+	"go.fmi/issuetracker/clients"
 	"go.fmi/issuetracker/command"
+	"go.fmi/issuetracker/config"
 	"go.fmi/issuetracker/db"
+	"go.fmi/issuetracker/notification"
+	"go.fmi/issuetracker/notifier"
+	"go.fmi/issuetracker/protocol"
+	"go.fmi/issuetracker/push"
+	"go.fmi/issuetracker/rpc"
+	"go.fmi/issuetracker/web"
+	"go.fmi/issuetracker/webhook"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runSubcommand(os.Args[1:]))
+	}
+
 	listener, err := net.Listen("tcp", "0.0.0.0:9999")
 	if err != nil {
 		log.Fatalln(err)
 	}
 
 	db.Connect()
+	setupDatabase()
 	defer listener.Close()
 
+	if settings, ok := config.Email(); ok {
+		notifier.Default = notifier.SMTPNotifier{
+			Address:  settings.Address,
+			From:     settings.From,
+			Username: settings.Username,
+			Password: settings.Password}
+		go sendDigests(settings.DigestInterval)
+	}
+
+	webhook.AllowedNetworks = config.WebhookNetworks()
+
+	go serveHTTP(config.HTTPAddress())
+	go serveGRPC(config.GRPCAddress())
+
 	for {
 		con, err := listener.Accept()
 		if err != nil {
@@ -27,15 +63,95 @@ func main() {
 			continue
 		}
 
-		// If you want, you can increment a counter here and inject to handleClientRequest below as client identifier
 		go handleClientRequest(con)
 	}
 }
 
+// runSubcommand runs a maintenance command instead of the server and returns its exit code:
+//
+//	migrate           creates the missing collections and indexes and applies the pending migrations
+//	migrate status    lists the migrations and when they were applied
+func runSubcommand(args []string) int {
+	switch strings.Join(args, " ") {
+	case "migrate":
+		db.Connect()
+		setupDatabase()
+		fmt.Println("The database is up to date")
+		return 0
+	case "migrate status":
+		db.Connect()
+		applied, err := db.AppliedMigrations()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		for _, m := range db.Migrations() {
+			state := "pending"
+			if record, ok := applied[m.Version]; ok {
+				state = "applied " + record.Applied.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%3d  %-27s  %s\n", m.Version, state, m.Description)
+		}
+		return 0
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: server [migrate [status]]")
+		return 2
+	}
+}
+
+// setupDatabase creates the missing collections and indexes and applies the pending migrations, before any request is served
+func setupDatabase() {
+	if err := db.EnsureCollections(); err != nil {
+		log.Fatal(err)
+	}
+	db.EnsureIndexes()
+
+	applied, err := db.Migrate()
+	for _, m := range applied {
+		log.Printf("Applied migration %d: %s", m.Version, m.Description)
+	}
+	if err != nil {
+		log.Fatal("Could not migrate the database: ", err)
+	}
+}
+
+// clientConnection serializes the writes to a client, because both responses and pushed events are written to it
+type clientConnection struct {
+	con       net.Conn
+	writeLock sync.Mutex
+}
+
+func (cc *clientConnection) write(message string) {
+	cc.writeLock.Lock()
+	defer cc.writeLock.Unlock()
+
+	cc.con.Write([]byte(message))
+}
+
 func handleClientRequest(con net.Conn) {
 	defer con.Close()
 
 	clientReader := bufio.NewReader(con)
+	client := &clientConnection{con: con}
+	session := command.Session{RemoteAddr: con.RemoteAddr().String()}
+
+	// An administrator may close the connection, which ends the loop below with a read error
+	session.ClientID = clients.Add(session.RemoteAddr, func() {
+		client.write(push.EventPrefix + protocol.Escape("You were disconnected by an administrator") + "\n")
+		con.Close()
+	})
+	defer clients.Remove(session.ClientID)
+
+	events := make(chan notification.Notification, 16)
+	go pushEvents(client, events)
+	subscribed := ""
+	defer func() {
+		if subscribed != "" {
+			push.Unsubscribe(subscribed, events)
+		}
+		close(events)
+	}()
 
 	for {
 		// Waiting for the client request
@@ -50,8 +166,25 @@ func handleClientRequest(con net.Conn) {
 			}
 
 			parsedCommand := command.ParseCommand(clientRequest)
-			message, _ := parsedCommand.Execute()
-			con.Write([]byte(message))
+			if parsedCommand == nil {
+				client.write(protocol.Response("Invalid command", false))
+				continue
+			}
+
+			message, err := command.ExecuteInSession(parsedCommand, &session)
+			client.write(protocol.Response(message, err == nil))
+
+			// Events are pushed to the user who is currently logged in through the connection
+			if session.Username != subscribed {
+				if subscribed != "" {
+					push.Unsubscribe(subscribed, events)
+				}
+				if session.Username != "" {
+					push.Subscribe(session.Username, events)
+				}
+				subscribed = session.Username
+				clients.SetUser(session.ClientID, session.Username)
+			}
 		case io.EOF:
 			log.Println("Client closed the connection by terminating the process")
 			return
@@ -61,3 +194,37 @@ func handleClientRequest(con net.Conn) {
 		}
 	}
 }
+
+// pushEvents writes the notifications for the user of a connection as they arrive, until the channel is closed
+func pushEvents(client *clientConnection, events <-chan notification.Notification) {
+	for n := range events {
+		client.write(push.EventPrefix + protocol.Escape(n.Message) + "\n")
+	}
+}
+
+// serveHTTP serves the REST API and the web interface next to the TCP server
+func serveHTTP(address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/api/", api.Handler())
+	mux.Handle("/", web.Handler())
+
+	log.Println(http.ListenAndServe(address, mux))
+}
+
+// serveGRPC serves the gRPC service next to the TCP server
+func serveGRPC(address string) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	log.Println(rpc.NewServer().Serve(listener))
+}
+
+// sendDigests periodically emails the users who prefer to receive their notifications as a digest
+func sendDigests(interval time.Duration) {
+	for range time.Tick(interval) {
+		command.SendDigests()
+	}
+}
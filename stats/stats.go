@@ -0,0 +1,16 @@
+package stats
+
+import "time"
+
+// Stats are the numbers describing the data and the load of the server, which administrators can view
+type Stats struct {
+	Users            int64     `json:"users"`
+	DeactivatedUsers int64     `json:"deactivatedUsers"`
+	Projects         int64     `json:"projects"`
+	OpenIssues       int64     `json:"openIssues"`
+	ResolvedIssues   int64     `json:"resolvedIssues"`
+	Comments         int64     `json:"comments"`
+	Sessions         int64     `json:"sessions"`
+	Clients          int       `json:"clients"`
+	Started          time.Time `json:"started"`
+}
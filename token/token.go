@@ -0,0 +1,126 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// SessionLifetime is how long a session token remains valid after it is issued
+const SessionLifetime = 30 * 24 * time.Hour
+
+// ResetLifetime is how long a password reset token remains valid after an administrator issues it
+const ResetLifetime = 24 * time.Hour
+
+// AccessLifetime is how long a personal access token remains valid when its expiry is not given,
+// and MaxAccessLifetime is the longest it can be valid
+const (
+	AccessLifetime    = 90 * 24 * time.Hour
+	MaxAccessLifetime = 366 * 24 * time.Hour
+)
+
+// Scope is a permission of a personal access token. Each scope includes the ones before it
+type Scope string
+
+const (
+	// ScopeRead allows viewing projects, issues, comments, users and notifications
+	ScopeRead Scope = "read"
+	// ScopeWrite allows creating and changing them as well
+	ScopeWrite Scope = "write"
+	// ScopeAdmin allows the commands of the administrators as well, if the owner of the token is one
+	ScopeAdmin Scope = "admin"
+)
+
+// scopeRanks orders the scopes, so that a scope includes the ones with lower ranks
+var scopeRanks = map[Scope]int{ScopeRead: 1, ScopeWrite: 2, ScopeAdmin: 3}
+
+// Token is an abstraction for a secret which authenticates a user instead of their password.
+// Only the hash of the secret is stored, so a leaked database doesn't give away working tokens
+type Token struct {
+	Hash     string    `json:"-"`
+	Username string    `json:"username"`
+	Created  time.Time `json:"created"`
+	Expires  time.Time `json:"expires"`
+	// ID, Name and Scopes are set only for personal access tokens, which are listed and revoked by their ID
+	ID     int     `json:"id,omitempty"`
+	Name   string  `json:"name,omitempty"`
+	Scopes []Scope `json:"scopes,omitempty"`
+}
+
+// New generates a token for a user, which is valid for a given duration. It returns the token and its secret
+func New(username string, lifetime time.Duration) (Token, string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return Token{}, "", err
+	}
+
+	secret := hex.EncodeToString(bytes)
+	now := time.Now().UTC()
+	return Token{
+		Hash:     Hash(secret),
+		Username: username,
+		Created:  now,
+		Expires:  now.Add(lifetime)}, secret, nil
+}
+
+// Hash computes the hash under which the token with a secret is stored
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Expired checks whether a token can no longer be used
+func (t Token) Expired() bool {
+	return time.Now().After(t.Expires)
+}
+
+// IsAccess checks whether a token is a personal access token, as opposed to a session token
+func (t Token) IsAccess() bool {
+	return len(t.Scopes) > 0
+}
+
+// ParseScopes parses a list of scopes separated by commas, e.g. 'read,write'
+func ParseScopes(text string) ([]Scope, error) {
+	var scopes []Scope
+	for _, name := range strings.Split(text, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if _, ok := scopeRanks[Scope(name)]; !ok {
+			return nil, errors.New("unknown scope " + name + ", expected read, write or admin")
+		}
+		scopes = append(scopes, Scope(name))
+	}
+
+	if len(scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+
+	return scopes, nil
+}
+
+// Allows checks whether the scopes of a token include a required scope
+func Allows(scopes []Scope, required Scope) bool {
+	for _, scope := range scopes {
+		if scopeRanks[scope] >= scopeRanks[required] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FormatScopes lists scopes separated by commas
+func FormatScopes(scopes []Scope) string {
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,56 @@
+package token
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewToken(t *testing.T) {
+	newToken, secret, err := New("user", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if newToken.Hash == secret || newToken.Hash != Hash(secret) {
+		t.Errorf("Only the hash of the secret should be stored in the token")
+	}
+
+	if newToken.Username != "user" || newToken.Expired() {
+		t.Errorf("Token was not issued properly")
+	}
+
+	_, otherSecret, _ := New("user", time.Hour)
+	if otherSecret == secret {
+		t.Errorf("Every token should have a different secret")
+	}
+}
+
+func TestExpiredToken(t *testing.T) {
+	expiredToken := Token{Expires: time.Now().Add(-time.Minute)}
+	if !expiredToken.Expired() {
+		t.Errorf("Token should have expired")
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes(" read, write ")
+	if err != nil || len(scopes) != 2 || scopes[0] != ScopeRead || scopes[1] != ScopeWrite {
+		t.Errorf("The scopes were not parsed properly: %v, %v", scopes, err)
+	}
+
+	for _, text := range []string{"", " , ", "read,delete"} {
+		if _, err := ParseScopes(text); err == nil {
+			t.Errorf("The scopes %q should be rejected", text)
+		}
+	}
+}
+
+func TestAllows(t *testing.T) {
+	if !Allows([]Scope{ScopeWrite}, ScopeRead) || !Allows([]Scope{ScopeAdmin}, ScopeWrite) {
+		t.Errorf("A scope should include the ones before it")
+	}
+
+	if Allows([]Scope{ScopeRead}, ScopeWrite) || Allows([]Scope{ScopeRead, ScopeWrite}, ScopeAdmin) || Allows(nil, ScopeRead) {
+		t.Errorf("A scope should not include the ones after it")
+	}
+}
@@ -1,20 +1,157 @@
 package user
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"net/mail"
+	"regexp"
+	"time"
+	"unicode"
 
 	"golang.org/x/crypto/bcrypt"
+
+	"go.fmi/issuetracker/config"
+)
+
+// Limits of the length of a username
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 32
 )
 
+// usernamePattern matches the usernames which can be mentioned with '@' - letters, digits and '_', with '.' and '-'
+// allowed only between them
+var usernamePattern = regexp.MustCompile(`^\w([\w.-]*\w)?$`)
+
+// MaxDisplayNameLength is the number of characters up to which a display name is kept
+const MaxDisplayNameLength = 64
+
+// MaxPasswordLength is the number of bytes of a password which bcrypt takes into account
+const MaxPasswordLength = 72
+
 // User is an abstraction of a real-life user
 type User struct {
-	Username string
-	Password string
+	Username    string `json:"username"`
+	Password    string `json:"-"`
+	DisplayName string `json:"displayName,omitempty"`
+	Email       string `json:"email,omitempty"`
+	// Timezone is the name of a location in the IANA database, e.g. Europe/Sofia
+	Timezone string `json:"timezone,omitempty"`
+	// Deactivated users can't log in, but their issues and comments are kept
+	Deactivated bool `json:"deactivated,omitempty"`
+	// Admin users have administrator rights on the server, in addition to the ones named in the configuration
+	Admin bool `json:"admin,omitempty"`
+	// Service accounts have no password and authenticate only with personal access tokens, e.g. in CI
+	Service bool `json:"service,omitempty"`
+	// Provider is the external authentication provider which checks the password of the user, e.g. ldap,
+	// and empty for the users with a password stored on the server
+	Provider string `json:"provider,omitempty"`
+}
+
+// Name returns the display name of a user together with their username, or just the username when there is no display name
+func (u User) Name() string {
+	if u.DisplayName == "" {
+		return u.Username
+	}
+
+	return u.DisplayName + " (" + u.Username + ")"
+}
+
+// Status returns whether the user is active or deactivated
+func (u User) Status() string {
+	if u.Deactivated {
+		return "deactivated"
+	}
+
+	return "active"
+}
+
+// Role returns whether the user is an administrator, a service account or a regular user
+func (u User) Role() string {
+	switch {
+	case u.Admin:
+		return "admin"
+	case u.Service:
+		return "service"
+	default:
+		return "user"
+	}
+}
+
+// ValidateProfile checks the fields of a profile which a user sets themselves. Empty fields are valid and clear them
+func ValidateProfile(displayName string, email string, timezone string) error {
+	if len([]rune(displayName)) > MaxDisplayNameLength {
+		return fmt.Errorf("the display name should be at most %d characters long", MaxDisplayNameLength)
+	}
+	if email != "" {
+		if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+			return errors.New("the email address is invalid")
+		}
+	}
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+			return errors.New("the timezone should be a name such as Europe/Sofia or UTC")
+		}
+	}
+
+	return nil
 }
 
-// HashAndSalt hashes a raw string password to store it in the database safely
+// ValidateUsername checks whether a username can be registered
+func ValidateUsername(username string) error {
+	if len(username) < MinUsernameLength || len(username) > MaxUsernameLength {
+		return fmt.Errorf("the username should be between %d and %d characters long", MinUsernameLength, MaxUsernameLength)
+	}
+	if !usernamePattern.MatchString(username) {
+		return errors.New("the username may contain only latin letters, digits, '_', and '.' or '-' between them")
+	}
+
+	return nil
+}
+
+// ValidatePassword checks a new password of a user against the password policy of the server
+func ValidatePassword(username string, password string) error {
+	policy := config.PasswordPolicy()
+
+	if len([]rune(password)) < policy.MinLength {
+		return fmt.Errorf("the password should be at least %d characters long", policy.MinLength)
+	}
+	if len(password) > MaxPasswordLength {
+		return fmt.Errorf("the password should be at most %d bytes long", MaxPasswordLength)
+	}
+	if characterClasses(password) < policy.MinClasses {
+		return fmt.Errorf("the password should contain at least %d of lowercase letters, uppercase letters, digits and symbols", policy.MinClasses)
+	}
+	if password == username {
+		return errors.New("the password should not be the same as the username")
+	}
+
+	return nil
+}
+
+// characterClasses counts how many of lowercase letters, uppercase letters, digits and symbols a password contains
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+
+	return lower + upper + digit + symbol
+}
+
+// HashAndSalt hashes a raw string password to store it in the database safely, with the cost set on the server
 func HashAndSalt(password string) string {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), config.PasswordPolicy().BcryptCost)
 	if err != nil {
 		log.Println(err)
 	}
@@ -22,6 +159,17 @@ func HashAndSalt(password string) string {
 	return string(hash)
 }
 
+// NeedsRehash checks whether a stored password was hashed with a cost other than the one set on the server,
+// so that it is hashed again the next time the user logs in
+func NeedsRehash(hashedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	if err != nil {
+		return false
+	}
+
+	return cost != config.PasswordPolicy().BcryptCost
+}
+
 // ComparePasswords is used during login to verify that the stored password matches with the user input
 func ComparePasswords(hashedPassword string, plainPassword string) bool {
 	byteHash := []byte(hashedPassword)
@@ -1,10 +1,13 @@
 package user
 
 import (
+	"strings"
 	"testing"
 
 	"bou.ke/monkey"
 	"golang.org/x/crypto/bcrypt"
+
+	"go.fmi/issuetracker/config"
 )
 
 func TestCompareMatchingPasswords(t *testing.T) {
@@ -40,3 +43,100 @@ func TestHashAndSalt(t *testing.T) {
 		t.Errorf("Password was not hashed correctly. Expected: " + string(expected) + ", but got " + got)
 	}
 }
+
+func TestHashAndSaltWithConfiguredCost(t *testing.T) {
+	t.Setenv(config.BcryptCostVariable, "5")
+
+	cost, err := bcrypt.Cost([]byte(HashAndSalt("password1234")))
+	if err != nil || cost != 5 {
+		t.Errorf("Expected a hash with cost 5, but got %d, %v", cost, err)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	hashedPassword := "$2a$04$1ORhJ3MFDnqPHdIPsUgzOuGNT/Vt8DVt4UhISBg1oYLlxtPTd0WXy"
+
+	if !NeedsRehash(hashedPassword) {
+		t.Errorf("A hash with cost 4 should be rehashed with the default cost")
+	}
+
+	t.Setenv(config.BcryptCostVariable, "4")
+	if NeedsRehash(hashedPassword) {
+		t.Errorf("A hash with the configured cost should not be rehashed")
+	}
+
+	if NeedsRehash("password1234") {
+		t.Errorf("A value which is not a bcrypt hash should not be rehashed")
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	t.Setenv(config.PasswordMinClassesVariable, "3")
+
+	invalid := map[string]string{
+		"short1A":                 "too short",
+		"longenoughbutlower":      "too few classes",
+		strings.Repeat("aA1", 25): "too long",
+	}
+	for password, reason := range invalid {
+		if ValidatePassword("user", password) == nil {
+			t.Errorf("The password %q should be rejected as %s", password, reason)
+		}
+	}
+
+	if err := ValidatePassword("user", "Password1234"); err != nil {
+		t.Errorf("A password which follows the policy was rejected: %v", err)
+	}
+}
+
+func TestValidatePasswordSameAsUsername(t *testing.T) {
+	if ValidatePassword("username1", "username1") == nil {
+		t.Errorf("A password which is the same as the username should be rejected")
+	}
+}
+
+func TestValidateUsername(t *testing.T) {
+	for _, username := range []string{"ivan", "ivan.petrov", "ivan-p", "i_v", "Ivan99"} {
+		if err := ValidateUsername(username); err != nil {
+			t.Errorf("The username %q was rejected: %v", username, err)
+		}
+	}
+
+	for _, username := range []string{"", "   ", "iv", "ivan petrov", "ivan.", "-ivan", "ivan|-|x", "иван", strings.Repeat("a", 33)} {
+		if ValidateUsername(username) == nil {
+			t.Errorf("The username %q should be rejected", username)
+		}
+	}
+}
+
+func TestValidateProfile(t *testing.T) {
+	if err := ValidateProfile("Ivan Petrov", "ivan@example.com", "Europe/Sofia"); err != nil {
+		t.Errorf("The profile was rejected: %v", err)
+	}
+
+	if err := ValidateProfile("", "", ""); err != nil {
+		t.Errorf("An empty profile was rejected: %v", err)
+	}
+
+	invalid := [][3]string{
+		{strings.Repeat("a", MaxDisplayNameLength+1), "", ""},
+		{"", "ivan", ""},
+		{"", "Ivan <ivan@example.com>", ""},
+		{"", "", "Mars/Olympus"},
+		{"", "", "Local"}}
+	for _, profile := range invalid {
+		if ValidateProfile(profile[0], profile[1], profile[2]) == nil {
+			t.Errorf("The profile %q should be rejected", profile)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	if name := (User{Username: "ivan", DisplayName: "Ivan Petrov"}).Name(); name != "Ivan Petrov (ivan)" {
+		t.Errorf("Expected: Ivan Petrov (ivan), but got %s", name)
+	}
+
+	if name := (User{Username: "ivan"}).Name(); name != "ivan" {
+		t.Errorf("Expected: ivan, but got %s", name)
+	}
+}
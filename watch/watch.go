@@ -0,0 +1,8 @@
+package watch
+
+// Watch is an abstraction for a user following an issue or, when the title is empty, a whole project
+type Watch struct {
+	Username string
+	Project  string
+	Title    string
+}
@@ -0,0 +1,153 @@
+package web
+
+import (
+	"html/template"
+	"net/url"
+)
+
+const layoutTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{block "title" .}}Issue Tracker{{end}}</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: 1em auto; padding: 0 1em; }
+nav { border-bottom: 1px solid #ccc; padding-bottom: .5em; margin-bottom: 1em; }
+nav form { display: inline; float: right; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: .3em; border-bottom: 1px solid #eee; }
+.error { color: #b00; }
+.resolved { color: #070; }
+.comment { border-left: 2px solid #ccc; padding-left: .5em; margin: .5em 0; }
+.meta { color: #777; font-size: .9em; }
+.text { white-space: pre-wrap; }
+textarea { width: 100%; }
+</style>
+</head>
+<body>
+<nav>
+<a href="/projects">Projects</a>
+{{if .User}}<form method="post" action="/logout">{{.User}} <button>Log out</button></form>{{end}}
+</nav>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+{{block "content" .}}{{end}}
+</body>
+</html>`
+
+const loginTemplate = `{{define "title"}}Log in{{end}}
+{{define "content"}}
+<h1>Log in</h1>
+<form method="post" action="/login">
+<p><label>Username <input name="username" required></label></p>
+<p><label>Password <input name="password" type="password" required></label></p>
+<p><button>Log in</button></p>
+</form>
+<p>No account? <a href="/register">Register</a></p>
+{{end}}`
+
+const registerTemplate = `{{define "title"}}Register{{end}}
+{{define "content"}}
+<h1>Register</h1>
+<form method="post" action="/register">
+<p><label>Username <input name="username" required></label></p>
+<p><label>Password <input name="password" type="password" required></label></p>
+<p><button>Register</button></p>
+</form>
+{{end}}`
+
+const projectsTemplate = `{{define "title"}}Projects{{end}}
+{{define "content"}}
+<h1>Projects</h1>
+<table>
+<tr><th>Name</th><th>Owner</th></tr>
+{{range .Projects}}<tr><td><a href="/projects/{{segment .Name}}">{{.Name}}</a></td><td>{{.Owner}}</td></tr>
+{{else}}<tr><td colspan="2">There are no projects yet</td></tr>
+{{end}}
+</table>
+<h2>New project</h2>
+<form method="post" action="/projects">
+<p><label>Name <input name="name" required></label> <button>Create</button></p>
+</form>
+{{end}}`
+
+const projectTemplate = `{{define "title"}}{{.Project}}{{end}}
+{{define "content"}}
+<h1>{{.Project}}</h1>
+<form method="get">
+<p>
+<label>Status <select name="status">
+<option value="">All</option>
+<option value="open"{{if eq .Filter.Status "open"}} selected{{end}}>Open</option>
+<option value="resolved"{{if eq .Filter.Status "resolved"}} selected{{end}}>Resolved</option>
+</select></label>
+<label>Assignee <input name="assignee" value="{{.Filter.Assignee}}"></label>
+<label>Text <input name="q" value="{{.Filter.Text}}"></label>
+<button>Filter</button>
+</p>
+</form>
+<table>
+<tr><th>Title</th><th>Reporter</th><th>Assignee</th><th>Status</th></tr>
+{{range .Issues}}<tr>
+<td><a href="/projects/{{segment .Project}}/issues/{{segment .Title}}">{{.Title}}</a></td>
+<td>{{.Reporter}}</td><td>{{.Assignee}}</td>
+<td>{{if .IsResolved}}<span class="resolved">resolved</span>{{else}}open{{end}}</td>
+</tr>
+{{else}}<tr><td colspan="4">No issues match</td></tr>
+{{end}}
+</table>
+<h2>New issue</h2>
+<form method="post" action="/projects/{{segment .Project}}/issues">
+<p><label>Title <input name="title" required></label></p>
+<p><label>Description<br><textarea name="description" rows="5"></textarea></label></p>
+<p><button>Create</button></p>
+</form>
+{{end}}`
+
+const issueTemplate = `{{define "title"}}{{.Issue.Title}}{{end}}
+{{define "content"}}
+<p><a href="/projects/{{segment .Issue.Project}}">{{.Issue.Project}}</a></p>
+<h1>{{.Issue.Title}}</h1>
+<p class="meta">Reported by {{.Issue.Reporter}}{{if .Issue.Assignee}}, assigned to {{.Issue.Assignee}}{{end}}</p>
+<p class="text">{{.Issue.Description}}</p>
+{{if .Issue.IsResolved}}<p class="resolved">Resolved</p>
+{{else}}<form method="post" action="/projects/{{segment .Issue.Project}}/issues/{{segment .Issue.Title}}/resolve"><button>Resolve</button></form>
+{{end}}
+<h2>Comments</h2>
+{{range .Comments}}<div class="comment" style="margin-left: {{indent .Depth}}em">{{with .Comment}}
+{{if .Deleted}}<p class="meta" id="comment-{{.ID}}">#{{.ID}} [deleted]</p>
+{{else}}<p class="meta" id="comment-{{.ID}}">#{{.ID}} {{.Commenter}} on {{.Created.Format "2006-01-02 15:04"}}{{if not .Edited.IsZero}} (edited){{end}}</p>
+<p class="text">{{.Content}}</p>{{end}}
+{{end}}</div>
+{{else}}<p>No comments yet</p>
+{{end}}
+<h2>Add a comment</h2>
+<form method="post" action="/projects/{{segment .Issue.Project}}/issues/{{segment .Issue.Title}}/comments">
+<p><textarea name="content" rows="4" required></textarea></p>
+<p><label>Reply to comment # <input name="parent" type="number" min="1"></label></p>
+<p><button>Comment</button></p>
+</form>
+{{end}}`
+
+const errorTemplate = `{{define "title"}}Error{{end}}
+{{define "content"}}<p><a href="javascript:history.back()">Back</a></p>{{end}}`
+
+var functions = template.FuncMap{
+	// segment escapes a name for use as a single segment of a path
+	"segment": url.PathEscape,
+	// indent returns the indentation of a reply in em
+	"indent": func(depth int) int { return depth * 2 },
+}
+
+// pages holds the templates of every page, each one rendered inside the layout
+var pages = map[string]*template.Template{
+	"login":    parsePage(loginTemplate),
+	"register": parsePage(registerTemplate),
+	"projects": parsePage(projectsTemplate),
+	"project":  parsePage(projectTemplate),
+	"issue":    parsePage(issueTemplate),
+	"error":    parsePage(errorTemplate),
+}
+
+func parsePage(page string) *template.Template {
+	return template.Must(template.Must(template.New("layout").Funcs(functions).Parse(layoutTemplate)).Parse(page))
+}
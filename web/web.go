@@ -0,0 +1,223 @@
+package web
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.fmi/issuetracker/api"
+	"go.fmi/issuetracker/command"
+	"go.fmi/issuetracker/comment"
+	"go.fmi/issuetracker/db"
+	"go.fmi/issuetracker/issue"
+	"go.fmi/issuetracker/project"
+	"go.fmi/issuetracker/token"
+	"go.fmi/issuetracker/user"
+)
+
+// TokenCookie is the cookie holding the session token of a logged in browser
+const TokenCookie = "issuetracker_token"
+
+// page is the data every page is rendered with
+type page struct {
+	User     string
+	Error    string
+	Projects []project.Project
+	Project  string
+	Filter   issue.Filter
+	Issues   []issue.Issue
+	Issue    issue.Issue
+	Comments []comment.ThreadEntry
+}
+
+// Handler serves the web interface for browsing and editing issues
+//
+//	GET  /login, /register                  forms for logging in and registering
+//	POST /login, /register, /logout
+//	GET  /projects                          all projects
+//	POST /projects                          creates a project
+//	GET  /projects/{project}                the issues of a project, filtered by status, assignee and text
+//	POST /projects/{project}/issues         creates an issue
+//	GET  /projects/{project}/issues/{title} an issue with its comments
+//	POST /projects/{project}/issues/{title}/resolve
+//	POST /projects/{project}/issues/{title}/comments
+func Handler() http.Handler {
+	return http.HandlerFunc(serveWeb)
+}
+
+func serveWeb(w http.ResponseWriter, r *http.Request) {
+	path, ok := api.SplitPath(r.URL.EscapedPath())
+	if !ok {
+		render(w, http.StatusNotFound, "error", page{Error: "Page not found"})
+		return
+	}
+
+	session := &command.Session{RemoteAddr: r.RemoteAddr}
+	switch {
+	case r.Method == http.MethodGet && len(path) == 1 && path[0] == "":
+		http.Redirect(w, r, "/projects", http.StatusSeeOther)
+		return
+	case api.Match(r, path, http.MethodGet, "login"):
+		render(w, http.StatusOK, "login", page{})
+		return
+	case api.Match(r, path, http.MethodGet, "register"):
+		render(w, http.StatusOK, "register", page{})
+		return
+	case api.Match(r, path, http.MethodPost, "login"):
+		loginCommand := command.LoginCommand{User: formUser(r)}
+		logIn(w, r, session, "login", loginCommand.ExecuteAs)
+		return
+	case api.Match(r, path, http.MethodPost, "register"):
+		registerCommand := command.RegisterCommand{User: formUser(r)}
+		logIn(w, r, session, "register", registerCommand.ExecuteAs)
+		return
+	}
+
+	secret, ok := authenticate(r, session)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	switch {
+	case api.Match(r, path, http.MethodPost, "logout"):
+		// A personal access token in the cookie is revoked only explicitly, as in the HTTP API
+		if len(session.Scopes) == 0 {
+			db.DeleteToken(token.Hash(secret))
+		}
+		http.SetCookie(w, &http.Cookie{Name: TokenCookie, Path: "/", MaxAge: -1})
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+	case api.Match(r, path, http.MethodGet, "projects"):
+		render(w, http.StatusOK, "projects", page{User: session.Username, Projects: db.ListProjects()})
+	case api.Match(r, path, http.MethodPost, "projects"):
+		name := r.PostFormValue("name")
+		execute(w, r, command.ProjectCommand{Project: project.Project{Name: name}}, session, projectPath(name))
+	case api.Match(r, path, http.MethodGet, "projects", ""):
+		showProject(w, r, session, path[1])
+	case api.Match(r, path, http.MethodPost, "projects", "", "issues"):
+		title := r.PostFormValue("title")
+		execute(w, r, command.IssueCommand{Issue: issue.Issue{
+			Project:     path[1],
+			Title:       title,
+			Description: r.PostFormValue("description")}}, session, issuePath(path[1], title))
+	case api.Match(r, path, http.MethodGet, "projects", "", "issues", ""):
+		showIssue(w, session, path[1], path[3])
+	case api.Match(r, path, http.MethodPost, "projects", "", "issues", "", "resolve"):
+		execute(w, r, command.ResolveCommand{Project: path[1], Title: path[3]}, session, issuePath(path[1], path[3]))
+	case api.Match(r, path, http.MethodPost, "projects", "", "issues", "", "comments"):
+		parent, _ := strconv.Atoi(r.PostFormValue("parent"))
+		execute(w, r, command.CommentCommand{Comment: comment.Comment{
+			Parent:    parent,
+			Project:   path[1],
+			Title:     path[3],
+			Content:   r.PostFormValue("content"),
+			Commenter: session.Username}}, session, issuePath(path[1], path[3]))
+	default:
+		render(w, http.StatusNotFound, "error", page{User: session.Username, Error: "Page not found"})
+	}
+}
+
+// authenticate logs the user owning the token cookie of a request in the session and returns the token secret
+func authenticate(r *http.Request, session *command.Session) (string, bool) {
+	cookie, err := r.Cookie(TokenCookie)
+	if err != nil || cookie.Value == "" {
+		return "", false
+	}
+
+	existingToken, err := db.FindToken(token.Hash(cookie.Value))
+	if err != nil || existingToken.Expired() {
+		return "", false
+	}
+
+	session.Authenticate(existingToken)
+	return cookie.Value, true
+}
+
+func formUser(r *http.Request) user.User {
+	return user.User{Username: r.PostFormValue("username"), Password: r.PostFormValue("password")}
+}
+
+// logIn runs a login or a registration and on success stores a new session token in a cookie
+func logIn(w http.ResponseWriter, r *http.Request, session *command.Session, form string, run func(*command.Session) (string, error)) {
+	if message, err := run(session); err != nil {
+		render(w, api.FailureStatus(err), form, page{Error: message})
+		return
+	}
+
+	newToken, secret, err := token.New(session.Username, token.SessionLifetime)
+	if err != nil {
+		render(w, http.StatusInternalServerError, form, page{Error: "Could not log in"})
+		return
+	}
+
+	db.InsertToken(newToken)
+	http.SetCookie(w, &http.Cookie{
+		Name:     TokenCookie,
+		Value:    secret,
+		Path:     "/",
+		Expires:  newToken.Expires,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode})
+	http.Redirect(w, r, "/projects", http.StatusSeeOther)
+}
+
+// execute runs a command submitted through a form and redirects to the changed page on success
+func execute(w http.ResponseWriter, r *http.Request, c command.Command, session *command.Session, redirect string) {
+	message, err := command.ExecuteInSession(c, session)
+	if err != nil {
+		render(w, api.FailureStatus(err), "error", page{User: session.Username, Error: message})
+		return
+	}
+
+	http.Redirect(w, r, redirect, http.StatusSeeOther)
+}
+
+func showProject(w http.ResponseWriter, r *http.Request, session *command.Session, projectName string) {
+	issues, message, err := command.ListCommand{Project: projectName}.Issues()
+	if err != nil {
+		render(w, api.FailureStatus(err), "error", page{User: session.Username, Error: message})
+		return
+	}
+
+	query := r.URL.Query()
+	filter := issue.Filter{Status: query.Get("status"), Assignee: query.Get("assignee"), Text: query.Get("q")}
+
+	var matching []issue.Issue
+	for _, i := range issues {
+		if filter.Matches(i) {
+			matching = append(matching, i)
+		}
+	}
+
+	render(w, http.StatusOK, "project", page{User: session.Username, Project: projectName, Filter: filter, Issues: matching})
+}
+
+func showIssue(w http.ResponseWriter, session *command.Session, projectName string, title string) {
+	foundIssue, comments, message, err := command.FindCommand{Project: projectName, Title: title}.Details()
+	if err != nil {
+		render(w, api.FailureStatus(err), "error", page{User: session.Username, Error: message})
+		return
+	}
+
+	render(w, http.StatusOK, "issue", page{User: session.Username, Issue: foundIssue, Comments: comment.Thread(comments)})
+}
+
+func projectPath(projectName string) string {
+	return "/projects/" + url.PathEscape(projectName)
+}
+
+func issuePath(projectName string, title string) string {
+	return projectPath(projectName) + "/issues/" + url.PathEscape(title)
+}
+
+func render(w http.ResponseWriter, status int, name string, data page) {
+	data.Error = strings.TrimSpace(data.Error)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := pages[name].Execute(w, data); err != nil {
+		log.Println(err)
+	}
+}
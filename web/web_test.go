@@ -0,0 +1,141 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go.fmi/issuetracker/audit"
+	"go.fmi/issuetracker/comment"
+	"go.fmi/issuetracker/db"
+	"go.fmi/issuetracker/issue"
+	"go.fmi/issuetracker/project"
+	"go.fmi/issuetracker/token"
+	"go.fmi/issuetracker/user"
+
+	"bou.ke/monkey"
+)
+
+// patchToken makes every token valid for the given user
+func patchToken(username string) {
+	monkey.Patch(db.FindToken, func(hash string) (token.Token, error) {
+		return token.Token{Hash: hash, Username: username, Expires: time.Now().Add(time.Hour)}, nil
+	})
+}
+
+func request(method string, target string, form url.Values) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.AddCookie(&http.Cookie{Name: TokenCookie, Value: "secret"})
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, r)
+	return w
+}
+
+func TestPageWithoutLogin(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindToken, func(string) (token.Token, error) {
+		return token.Token{}, errors.New("Not found")
+	})
+
+	w := request(http.MethodGet, "/projects", nil)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/login" {
+		t.Error("Expected a redirect to the login page, but got ", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestLoginSetsCookie(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindRegisteredUser, func(username string) (user.User, error) {
+		return user.User{Username: username, Password: user.HashAndSalt("password")}, nil
+	})
+	monkey.Patch(db.InsertAuditEntry, func(audit.Entry) {
+		return
+	})
+
+	var inserted token.Token
+	monkey.Patch(db.InsertToken, func(newToken token.Token) {
+		inserted = newToken
+	})
+
+	w := request(http.MethodPost, "/login", url.Values{"username": {"user"}, "password": {"password"}})
+	if w.Code != http.StatusSeeOther {
+		t.Fatal("Invalid status. Expected: 303, but got ", w.Code)
+	}
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != TokenCookie || token.Hash(cookies[0].Value) != inserted.Hash {
+		t.Error("The session cookie does not hold the stored token: ", cookies)
+	}
+}
+
+func TestLogoutKeepsAccessToken(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	monkey.Patch(db.FindToken, func(hash string) (token.Token, error) {
+		return token.Token{Hash: hash, Username: "ci", Scopes: []token.Scope{token.ScopeRead}, Expires: time.Now().Add(time.Hour)}, nil
+	})
+
+	monkey.Patch(db.DeleteToken, func(string) {
+		t.Errorf("The access token was revoked on logout")
+	})
+
+	w := request(http.MethodPost, "/logout", nil)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/login" {
+		t.Error("Expected a redirect to the login page, but got ", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestIssuePage(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	patchToken("user")
+	monkey.Patch(db.FindExistingProject, func(name string) (project.Project, error) {
+		return project.Project{Name: name}, nil
+	})
+	monkey.Patch(db.FindExistingIssue, func(projectName string, title string) (issue.Issue, error) {
+		return issue.Issue{Project: projectName, Title: title, Reporter: "user", Description: "<script>", Status: issue.StatusOpen}, nil
+	})
+	monkey.Patch(db.FindComments, func(string, string) []comment.Comment {
+		return []comment.Comment{
+			{ID: 1, Content: "root", Commenter: "user"},
+			{ID: 2, Parent: 1, Content: "reply", Commenter: "other"},
+			{ID: 3, Content: "gone", Deleted: true}}
+	})
+
+	w := request(http.MethodGet, "/projects/project/issues/a%2Fb", nil)
+	body := w.Body.String()
+	if w.Code != http.StatusOK {
+		t.Fatal("Invalid status. Expected: 200, but got ", w.Code)
+	}
+
+	for _, expected := range []string{"<h1>a/b</h1>", "&lt;script&gt;", "#2 other", "#3 [deleted]", "/projects/project/issues/a%2Fb/resolve"} {
+		if !strings.Contains(body, expected) {
+			t.Error("The issue page does not contain " + expected)
+		}
+	}
+	if strings.Contains(body, "gone") {
+		t.Error("The issue page shows the content of a deleted comment")
+	}
+}
+
+func TestMissingProjectPage(t *testing.T) {
+	defer monkey.UnpatchAll()
+
+	patchToken("user")
+	monkey.Patch(db.FindExistingProject, func(string) (project.Project, error) {
+		return project.Project{}, errors.New("Not found")
+	})
+
+	w := request(http.MethodGet, "/projects/missing", nil)
+	if w.Code != http.StatusNotFound {
+		t.Error("Invalid status. Expected: 404, but got ", w.Code)
+	}
+}
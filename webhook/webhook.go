@@ -0,0 +1,220 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"go.fmi/issuetracker/comment"
+	"go.fmi/issuetracker/issue"
+)
+
+// Events which are sent to webhooks
+const (
+	IssueCreated  = "issue.created"
+	IssueUpdated  = "issue.updated"
+	IssueResolved = "issue.resolved"
+	CommentAdded  = "comment.added"
+)
+
+// SignatureHeader is the HTTP header which holds the HMAC-SHA256 signature of the payload, made with the secret of the webhook
+const SignatureHeader = "X-Issue-Tracker-Signature"
+
+// Retry policy for failed deliveries
+var (
+	MaxAttempts    = 5
+	InitialBackoff = time.Second
+)
+
+// AllowedNetworks are the private networks to which webhooks may still post, e.g. the one of an internal chat server.
+// Loopback, private and link-local addresses are refused otherwise, so that a project owner can't reach into the
+// network of the server
+var AllowedNetworks []*net.IPNet
+
+// Client posts the payloads. It checks every address it connects to, after the name was resolved and on redirects,
+// and doesn't go through a proxy, which would connect in its place
+var Client = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{Timeout: 10 * time.Second, Control: checkAddress}).DialContext}}
+
+// ErrForbiddenAddress is returned when a webhook would post to an address which isn't allowed
+var ErrForbiddenAddress = errors.New("the address is in a private network")
+
+// Webhook is an abstraction for an URL which is notified about the events in a project
+type Webhook struct {
+	ID      int
+	Project string
+	URL     string
+	Secret  string
+	Creator string
+}
+
+// Payload is the JSON body posted to a webhook
+type Payload struct {
+	Event   string           `json:"event"`
+	Project string           `json:"project"`
+	Title   string           `json:"title"`
+	Actor   string           `json:"actor"`
+	Time    time.Time        `json:"time"`
+	Issue   *issue.Issue     `json:"issue,omitempty"`
+	Comment *comment.Comment `json:"comment,omitempty"`
+}
+
+// Delivery is a record of a single attempt to post a payload to a webhook
+type Delivery struct {
+	WebhookID  int
+	Project    string
+	URL        string
+	Event      string
+	Attempt    int
+	StatusCode int
+	Error      string
+	Success    bool
+	Time       time.Time
+}
+
+// ValidURL checks whether an URL can be used for a webhook
+func ValidURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// CheckHost resolves the host of a webhook URL and returns ErrForbiddenAddress when any of its addresses isn't allowed.
+// A host which can't be resolved yet is accepted, because the addresses are checked again on every delivery
+func CheckHost(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	ips := []net.IP{net.ParseIP(parsed.Hostname())}
+	if ips[0] == nil {
+		if ips, err = net.LookupIP(parsed.Hostname()); err != nil {
+			return nil
+		}
+	}
+
+	for _, ip := range ips {
+		if forbidden(ip) {
+			return ErrForbiddenAddress
+		}
+	}
+
+	return nil
+}
+
+// forbidden checks whether an address is a loopback, private, link-local or otherwise non-public one, which isn't
+// in the allowed networks
+func forbidden(ip net.IP) bool {
+	for _, network := range AllowedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+// checkAddress refuses the connections of the client to addresses which aren't allowed
+func checkAddress(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || forbidden(ip) {
+		return ErrForbiddenAddress
+	}
+
+	return nil
+}
+
+// Sign computes the signature of a payload, which is sent in the SignatureHeader as 'sha256=<hex digest>'
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Deliver posts a payload to a webhook, retrying with exponential backoff until it is accepted or MaxAttempts is reached.
+// Every attempt is passed to record
+func Deliver(hook Webhook, payload Payload, record func(Delivery)) bool {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return false
+	}
+
+	backoff := InitialBackoff
+	for attempt := 1; attempt <= MaxAttempts; attempt++ {
+		delivery := Delivery{
+			WebhookID: hook.ID,
+			Project:   hook.Project,
+			URL:       hook.URL,
+			Event:     payload.Event,
+			Attempt:   attempt,
+			Time:      time.Now().UTC()}
+
+		retry := post(hook, body, &delivery)
+		record(delivery)
+		if delivery.Success || !retry {
+			return delivery.Success
+		}
+
+		if attempt < MaxAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+
+	return false
+}
+
+// post makes a single attempt to deliver a payload and reports whether a failed attempt is worth retrying
+func post(hook Webhook, body []byte, delivery *Delivery) bool {
+	request, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return false
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Issue-Tracker-Event", delivery.Event)
+	request.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+
+	response, err := Client.Do(request)
+	if err != nil {
+		delivery.Error = err.Error()
+		return !errors.Is(err, ErrForbiddenAddress)
+	}
+	defer response.Body.Close()
+
+	delivery.StatusCode = response.StatusCode
+	delivery.Success = response.StatusCode >= 200 && response.StatusCode < 300
+	if !delivery.Success {
+		delivery.Error = "unexpected status " + strconv.Itoa(response.StatusCode)
+	}
+
+	// Client errors won't go away by themselves, except for rate limiting
+	return response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests
+}
+
+// String formats a delivery as a single human-readable line
+func (d Delivery) String() string {
+	line := d.Time.Format("2006-01-02 15:04:05") + " " + d.Event + " to #" + strconv.Itoa(d.WebhookID) +
+		" (" + d.URL + ") attempt " + strconv.Itoa(d.Attempt) + ": "
+	if d.Success {
+		return line + "delivered"
+	}
+
+	return line + "failed - " + d.Error
+}
@@ -0,0 +1,124 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// allowLoopback lets the deliveries reach the test servers, which listen on a loopback address
+func allowLoopback(t *testing.T) {
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	AllowedNetworks = []*net.IPNet{loopback}
+	t.Cleanup(func() {
+		AllowedNetworks = nil
+	})
+}
+
+func TestDeliverRetriesWithSignature(t *testing.T) {
+	allowLoopback(t)
+	InitialBackoff = time.Millisecond
+	defer func() {
+		InitialBackoff = time.Second
+	}()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := ioutil.ReadAll(r.Body)
+
+		if r.Header.Get(SignatureHeader) != Sign("secret", body) {
+			t.Errorf("Payload was not signed with the secret of the webhook")
+		}
+
+		var payload Payload
+		if err := json.Unmarshal(body, &payload); err != nil || payload.Event != IssueCreated {
+			t.Errorf("Invalid payload: " + string(body))
+		}
+
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	var deliveries []Delivery
+	hook := Webhook{ID: 1, Project: "project", URL: server.URL, Secret: "secret"}
+	ok := Deliver(hook, Payload{Event: IssueCreated, Project: "project"}, func(d Delivery) {
+		deliveries = append(deliveries, d)
+	})
+
+	if !ok {
+		t.Errorf("Payload should have been delivered on the third attempt")
+	}
+
+	if len(deliveries) != 3 || deliveries[0].StatusCode != http.StatusServiceUnavailable || !deliveries[2].Success {
+		t.Errorf("Every attempt should be recorded, but got %v", deliveries)
+	}
+}
+
+func TestDeliverDoesNotRetryClientErrors(t *testing.T) {
+	allowLoopback(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	attempts := 0
+	ok := Deliver(Webhook{URL: server.URL}, Payload{Event: IssueResolved}, func(Delivery) {
+		attempts++
+	})
+
+	if ok || attempts != 1 {
+		t.Errorf("Delivery rejected by the client should fail without retrying, but was attempted %d times", attempts)
+	}
+}
+
+func TestValidURL(t *testing.T) {
+	if !ValidURL("https://chat.example.com/hooks/1") {
+		t.Errorf("HTTPS URL should be valid")
+	}
+
+	if ValidURL("ftp://example.com") || ValidURL("not an url") {
+		t.Errorf("Only HTTP and HTTPS URLs should be valid")
+	}
+}
+
+func TestDeliverRefusesPrivateAddresses(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	var deliveries []Delivery
+	ok := Deliver(Webhook{URL: server.URL}, Payload{Event: IssueCreated}, func(d Delivery) {
+		deliveries = append(deliveries, d)
+	})
+
+	if ok || requests != 0 || len(deliveries) != 1 || !strings.Contains(deliveries[0].Error, ErrForbiddenAddress.Error()) {
+		t.Errorf("A payload should not be posted to a loopback address, nor retried, but got %v", deliveries)
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	for _, rawURL := range []string{"http://127.0.0.1:8080/", "http://10.1.2.3/hook", "http://[::1]/", "http://169.254.169.254/latest", "http://localhost/"} {
+		if CheckHost(rawURL) != ErrForbiddenAddress {
+			t.Errorf("%s should be refused", rawURL)
+		}
+	}
+
+	if err := CheckHost("https://93.184.216.34/hook"); err != nil {
+		t.Errorf("A public address should be accepted, but got %v", err)
+	}
+
+	allowLoopback(t)
+	if err := CheckHost("http://127.0.0.1:8080/"); err != nil {
+		t.Errorf("An address in an allowed network should be accepted, but got %v", err)
+	}
+}